@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/karan-bishtt/auth-service/config"
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+)
+
+// runBootstrapInvite creates the first admin invite and prints its link.
+//
+//	auth-service bootstrap-admin-invite -email admin@example.com
+//
+// It refuses to run once an admin exists, later admins are invited through the admin API.
+func runBootstrapInvite(cfg *config.Config, args []string) {
+	flags := flag.NewFlagSet("bootstrap-admin-invite", flag.ExitOnError)
+	email := flags.String("email", "", "email address of the first admin")
+	flags.Parse(args)
+
+	if *email == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if _, err := database.InitDB(cfg.DatabaseURL); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	inviteService := services.NewInviteService()
+	invite, token, err := inviteService.CreateBootstrapInvite(*email)
+	if errors.Is(err, services.ErrAdminAlreadyExists) {
+		log.Fatal("An admin already exists, create invites through the admin API instead")
+	}
+	if err != nil {
+		log.Fatalf("Failed to create invite: %v", err)
+	}
+
+	fmt.Printf("Invite created for %s, expires at %s\n", invite.Email, invite.ExpiresAt.Format("2006-01-02 15:04 MST"))
+	fmt.Printf("Invite token: %s\n", token)
+	fmt.Printf("Register at: %s\n", inviteService.InviteLink(token))
+}
+
+// runCreateServiceClient registers an internal service and prints its credentials.
+//
+//	auth-service create-service-client -name rfp-quote-service -scopes users:read,notifications:send
+//
+// The secret is only printed once, put it in the SERVICE_CLIENT_SECRET of the calling service.
+func runCreateServiceClient(cfg *config.Config, args []string) {
+	flags := flag.NewFlagSet("create-service-client", flag.ExitOnError)
+	name := flags.String("name", "", "name of the calling service")
+	scopes := flags.String("scopes", "", "comma separated scopes, one of "+strings.Join(utils.ServiceScopes, ", "))
+	flags.Parse(args)
+
+	if *name == "" || *scopes == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if _, err := database.InitDB(cfg.DatabaseURL); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	client, secret, err := services.NewServiceClientService().Create(*name, strings.Split(*scopes, ","), nil)
+	if err != nil {
+		log.Fatalf("Failed to create service client: %v", err)
+	}
+
+	fmt.Printf("Service client %s created with scopes %q\n", client.Name, client.Scopes)
+	fmt.Printf("SERVICE_CLIENT_ID=%s\n", client.ClientID)
+	fmt.Printf("SERVICE_CLIENT_SECRET=%s\n", secret)
+}
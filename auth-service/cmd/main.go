@@ -5,21 +5,49 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/karan-bishtt/auth-service/config"
 	"github.com/karan-bishtt/auth-service/internal/database"
 	"github.com/karan-bishtt/auth-service/internal/routes"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
 )
 
 func main() {
 	fmt.Println("starting auth")
 	cfg := config.Load()
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "bootstrap-admin-invite":
+			runBootstrapInvite(cfg, os.Args[2:])
+			return
+		case "create-service-client":
+			runCreateServiceClient(cfg, os.Args[2:])
+			return
+		}
+	}
+
+	if err := utils.LoadSigningKeys(cfg.JWTKeysDir, cfg.JWTActiveKID, cfg.Environment); err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+
 	if _, err := database.InitDB(cfg.DatabaseURL); err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	if err := services.InitMFASecrets(cfg); err != nil {
+		log.Fatalf("Failed to set up MFA secret encryption: %v", err)
+	}
+
+	if err := services.InitDocumentStorage(cfg); err != nil {
+		log.Fatalf("Failed to set up document storage: %v", err)
+	}
+
+	go services.StartCleanupJob(time.Duration(cfg.CleanupIntervalMins) * time.Minute)
+
 	router := routes.SetupAuthRoutes()
 
 	handler := handlers.CORS(
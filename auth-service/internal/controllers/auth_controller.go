@@ -6,21 +6,36 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math/big"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/karan-bishtt/auth-service/config"
 	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/middleware"
 	"github.com/karan-bishtt/auth-service/internal/models"
 	"github.com/karan-bishtt/auth-service/internal/services"
 	"github.com/karan-bishtt/auth-service/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // region validators
 type AuthController struct {
-	notificationService *services.NotificationService
+	notificationService   *services.NotificationService
+	tokenService          *services.TokenService
+	inviteService         *services.InviteService
+	loginThrottle         *services.LoginThrottleService
+	mfaService            *services.MFAService
+	verificationService   *services.VerificationService
+	rateLimiter           *services.RateLimiter
+	passwordService       *services.PasswordService
+	vendorProfileService  *services.VendorProfileService
+	vendorCategoryService *services.VendorCategoryService
+	organizationService   *services.OrganizationService
 }
 
 // Add these request structs
@@ -31,34 +46,56 @@ type ForgotPasswordRequest struct {
 type ResetPasswordRequest struct {
 	Email       string `json:"email" validate:"required,email"`
 	OTP         string `json:"otp" validate:"required,len=6"`
-	NewPassword string `json:"new_password" validate:"required,min=8"`
+	NewPassword string `json:"new_password" validate:"required"`
 }
 
 type RegisterVendorRequest struct {
 	FirstName     string  `json:"firstname" validate:"required"`
 	LastName      string  `json:"lastname" validate:"required"`
 	Email         string  `json:"email" validate:"required,email"`
-	Password      string  `json:"password" validate:"required,min=8"`
+	Password      string  `json:"password" validate:"required"`
 	Revenue       float64 `json:"revenue"`
 	EmployeeCount int     `json:"no_of_employees"`
-	GSTNo         string  `json:"gst_no"`
-	PANNo         string  `json:"pancard_no"`
+	GSTNo         string  `json:"gst_no" validate:"omitempty,gstin,gstin_pan=PANNo"`
+	PANNo         string  `json:"pancard_no" validate:"omitempty,pan"`
 	PhoneNo       string  `json:"mobile"`
-	CategoryID    uint    `json:"category"`
+	CategoryIDs   []uint  `json:"categories" validate:"omitempty,max=20"`
+	CategoryID    uint    `json:"category"`     // single category of older clients, merged into CategoryIDs
+	Organization  string  `json:"organization"` // slug of the buyer organization applied to, the default one when empty
 }
 
 type RegisterAdminRequest struct {
-	FirstName string `json:"firstname" validate:"required"`
-	LastName  string `json:"lastname" validate:"required"`
-	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required,min=8"`
+	FirstName   string `json:"firstname" validate:"required"`
+	LastName    string `json:"lastname" validate:"required"`
+	Email       string `json:"email" validate:"required,email"`
+	Password    string `json:"password" validate:"required"`
+	InviteToken string `json:"invite_token" validate:"required"`
 }
 
-type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type SetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
 	Password string `json:"password" validate:"required"`
 }
 
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type LoginRequest struct {
+	Email        string `json:"email" validate:"required,email"`
+	Password     string `json:"password" validate:"required"`
+	Organization string `json:"organization"` // slug, lets vendors of several organizations pick one
+}
+
 type AuthResponse struct {
 	Status  int         `json:"status"`
 	Message string      `json:"message"`
@@ -93,7 +130,17 @@ type PaginatedResponse struct {
 // region helpers
 func NewAuthController() *AuthController {
 	return &AuthController{
-		notificationService: services.NewNotificationService(),
+		notificationService:   services.NewNotificationService(),
+		tokenService:          services.NewTokenService(),
+		inviteService:         services.NewInviteService(),
+		loginThrottle:         services.NewLoginThrottleService(),
+		mfaService:            services.NewMFAService(),
+		verificationService:   services.NewVerificationService(),
+		rateLimiter:           services.NewRateLimiter(),
+		passwordService:       services.NewPasswordService(),
+		vendorProfileService:  services.NewVendorProfileService(),
+		vendorCategoryService: services.NewVendorCategoryService(),
+		organizationService:   services.NewOrganizationService(),
 	}
 }
 
@@ -127,53 +174,216 @@ func respondWithPagination(w http.ResponseWriter, status int, message string, da
 }
 
 func assignDefaultVendorPermissions(tx *gorm.DB, userID uint) error {
-	vendorPermissions := []string{
-		"read_rfp", "create_quote", "read_quote", "update_quote",
+	return assignDefaultAccessRole(tx, models.DefaultVendorAccessRole, userID)
+}
+
+func assignDefaultAdminPermissions(tx *gorm.DB, userID uint) error {
+	return assignDefaultAccessRole(tx, models.DefaultAdminAccessRole, userID)
+}
+
+// assignDefaultAccessRole grants the permissions bundled in the named access role.
+// The bundles are seeded at startup and can be edited through the admin API.
+func assignDefaultAccessRole(tx *gorm.DB, name string, userID uint) error {
+	var accessRole models.AccessRole
+	if err := tx.Where("name = ?", name).Preload("Permissions").First(&accessRole).Error; err != nil {
+		return err
 	}
+	return grantAccessRole(tx, &accessRole, userID, nil)
+}
 
-	for _, permName := range vendorPermissions {
-		var permission models.Permission
-		if err := tx.Where("name = ?", permName).First(&permission).Error; err != nil {
-			continue // Skip if permission doesn't exist
-		}
+// clientInfo describes the device a request comes from, recorded on the session
+func clientInfo(r *http.Request) services.ClientInfo {
+	return services.ClientInfo{
+		IPAddress: utils.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
 
-		userPermission := models.UserPermission{
-			UserID:       userID,
-			PermissionID: permission.ID,
-		}
-		tx.Create(&userPermission)
+// parseIDParam reads a numeric path variable such as {id}
+func parseIDParam(r *http.Request, name string) (uint, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)[name], 10, 32)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid %s", name)
 	}
-	return nil
+	return uint(id), nil
 }
 
-func assignDefaultAdminPermissions(tx *gorm.DB, userID uint) error {
-	adminPermissions := []string{
-		"create_rfp", "read_rfp", "update_rfp", "delete_rfp",
-		"read_quote", "manage_users", "manage_categories",
+// requireTenant returns the organization the request acts for, answering 401 when
+// the token carries none
+func requireTenant(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	tenantID, ok := middleware.GetTenantIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return 0, false
 	}
+	return tenantID, true
+}
 
-	for _, permName := range adminPermissions {
-		var permission models.Permission
-		if err := tx.Where("name = ?", permName).First(&permission).Error; err != nil {
-			continue // Skip if permission doesn't exist
+// requireTenantMember returns the organization the request acts for after checking
+// the user belongs to it. Users of other organizations are answered with 404 so
+// their existence is not revealed.
+func requireTenantMember(w http.ResponseWriter, r *http.Request, organizationService *services.OrganizationService, userID uint) (uint, bool) {
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return 0, false
+	}
+
+	member, err := organizationService.HasMember(tenantID, userID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch user", "", "", "", "", nil)
+		return 0, false
+	}
+	if !member {
+		respondWithJSON(w, 404, "User not found", "", "", "", "", nil)
+		return 0, false
+	}
+	return tenantID, true
+}
+
+// tenantCategoryIDs selects the IDs of the categories owned by the organization,
+// so vendors serving several organizations only show the categories of one
+func tenantCategoryIDs(tenantID uint) *gorm.DB {
+	return database.DB.Model(&models.Category{}).Select("id").Where("tenant_id = ?", tenantID)
+}
+
+// listVendors answers with a page of the vendors that joined the organization
+// tenantID, with their status there. The status query parameter filters by status.
+func (ac *AuthController) listVendors(w http.ResponseWriter, r *http.Request, tenantID uint) {
+	// Query parameters
+	pageStr := r.URL.Query().Get("page")
+	limitStr := r.URL.Query().Get("limit")
+	status := r.URL.Query().Get("status") // any vendor status, e.g. pending, approved, suspended
+
+	// Set defaults
+	page := 1
+	limit := 10
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
 		}
+	}
 
-		userPermission := models.UserPermission{
-			UserID:       userID,
-			PermissionID: permission.ID,
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
 		}
-		tx.Create(&userPermission)
 	}
-	return nil
+
+	// Only the vendors that joined the organization, with their status there
+	query := database.DB.Joins("JOIN vendor_organizations ON vendor_organizations.user_id = users.id AND vendor_organizations.organization_id = ?", tenantID).
+		Where("users.role = ?", models.RoleVendor).
+		Preload("VendorDetails.Categories", "category_id IN (?)", tenantCategoryIDs(tenantID)).
+		Preload("VendorDetails.Categories.Category").
+		Preload("VendorDetails.Organizations", "organization_id = ?", tenantID).
+		Preload("UserPermissions.Permission")
+
+	// Filter by approval status
+	switch {
+	case status == string(models.VendorPending):
+		// Vendors only enter the approval queue once their email is verified
+		query = query.Where("vendor_organizations.status = ?", models.VendorPending).
+			Where("users.email_verified_at IS NOT NULL")
+	case models.IsValidVendorStatus(models.VendorStatus(status)):
+		query = query.Where("vendor_organizations.status = ?", status)
+	}
+
+	// Get total count
+	var total int64
+	var users []models.User
+	query.Model(&users).Count(&total)
+	// Calculate pagination
+	offset := (page - 1) * limit
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+
+	if err := query.Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch vendors", "", "", "", "", nil)
+		return
+	}
+
+	pagination := Pagination{
+		CurrentPage: page,
+		PerPage:     limit,
+		Total:       total,
+		TotalPages:  totalPages,
+	}
+
+	respondWithPagination(w, 200, "Vendors retrieved successfully", users, pagination)
+}
+
+// sendVerificationEmail emails the user a link to confirm their email address
+func (ac *AuthController) sendVerificationEmail(user *models.User, intro string) error {
+	cfg := config.Load()
+
+	token, err := ac.verificationService.Issue(user.ID, models.VerificationPurposeEmail, time.Duration(cfg.EmailVerificationTTLHours)*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	link := strings.TrimSuffix(cfg.FrontendURL, "/") + "/verify-email?token=" + token
+	subject := "Verify your email address"
+	content := fmt.Sprintf(`
+		Hi %s,
+
+		%s
+
+		Please confirm your email address by opening the link below:
+
+		%s
+
+		The link expires in %d hours.
+	`, user.FirstName+" "+user.LastName, intro, link, cfg.EmailVerificationTTLHours)
+	return ac.notificationService.SendEmail(user.Email, subject, content)
+}
+
+// respondWithPasswordViolations lists every password policy rule the password broke
+func respondWithPasswordViolations(w http.ResponseWriter, violations []utils.PasswordViolation) {
+	respondWithJSON(w, http.StatusBadRequest, "Password does not meet the password policy", "", "", "", "", map[string]interface{}{
+		"violations": violations,
+	})
+}
+
+// respondRateLimited answers a request rejected by the rate limiter
+func respondRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+	respondWithJSON(w, http.StatusTooManyRequests, "Too many requests, try again later", "", "", "", "", nil)
+}
+
+// respondLockedOut answers a login attempt made during a lockout
+func respondLockedOut(w http.ResponseWriter, until time.Time) {
+	retryAfter := int(time.Until(until).Seconds()) + 1
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	respondWithJSON(w, http.StatusTooManyRequests, "Too many failed login attempts, try again later", "", "", "", "", nil)
+}
+
+// recordLoginFailure counts a failed login and emails the user when it locks their account.
+// user is nil when the email does not belong to any account.
+func recordLoginFailure(throttle *services.LoginThrottleService, notifications *services.NotificationService, email, clientIP string, user *models.User) {
+	failure, err := throttle.RecordFailure(email, clientIP)
+	if err != nil {
+		log.Printf("Failed to record failed login: %v", err)
+	}
+
+	if failure.AccountLocked && user != nil {
+		subject := "Your account has been temporarily locked"
+		content := fmt.Sprintf(`
+		Hi %s,
+
+		We locked your account after several failed login attempts. You can try again after %s.
+
+		If this was not you, reset your password once the lockout ends or contact an administrator.
+	`, user.FirstName+" "+user.LastName, failure.LockedUntil.Format(time.RFC1123))
+		notifications.SendEmail(user.Email, subject, content)
+	}
 }
 
 // Helper function to generate 6-digit OTP
-func generateOTP() string {
-	b := make([]byte, 3)
-	rand.Read(b)
-	otp := int(b[0])*3906 + int(b[1])*15 + int(b[2])/17
-	otp = otp%900000 + 100000 // ensures 6 digits: 100000-999999
-	return fmt.Sprintf("%06d", otp)
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(900000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()+100000), nil // 100000-999999
 }
 
 // endregion helpers
@@ -205,6 +415,45 @@ func (ac *AuthController) RegisterVendor(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
+		orgSlug := req.Organization
+		if orgSlug == "" {
+			orgSlug = models.DefaultOrganizationSlug
+		}
+		org, err := ac.organizationService.GetActiveBySlug(orgSlug)
+		if err != nil {
+			if errors.Is(err, services.ErrOrganizationNotFound) {
+				respondWithJSON(w, 400, err.Error(), "vendor", "", "", "", nil)
+				return
+			}
+			respondWithJSON(w, 500, "Failed to find organization", "", "", "", "", nil)
+			return
+		}
+
+		categoryIDs := req.CategoryIDs
+		if req.CategoryID != 0 {
+			categoryIDs = append(categoryIDs, req.CategoryID)
+		}
+		if err := ac.vendorCategoryService.CheckCategories(org.ID, categoryIDs); err != nil {
+			if errors.Is(err, services.ErrInvalidCategory) || errors.Is(err, services.ErrNoCategories) ||
+				errors.Is(err, services.ErrVendorCategoryLimitExceeded) {
+				respondWithJSON(w, 400, err.Error(), "vendor", "", "", "", nil)
+				return
+			}
+			respondWithJSON(w, 500, "Failed to check categories", "", "", "", "", nil)
+			return
+		}
+
+		req.GSTNo = utils.NormalizeTaxID(req.GSTNo)
+		req.PANNo = utils.NormalizeTaxID(req.PANNo)
+		if err := ac.vendorProfileService.CheckGSTINAvailable(database.DB, req.GSTNo, 0); err != nil {
+			if errors.Is(err, services.ErrDuplicateGSTIN) {
+				respondWithJSON(w, 409, err.Error(), "vendor", "", "", "", nil)
+				return
+			}
+			respondWithJSON(w, 500, "Failed to check GSTIN", "", "", "", "", nil)
+			return
+		}
+
 		// Create User
 		user := models.User{
 			FirstName: req.FirstName,
@@ -215,6 +464,16 @@ func (ac *AuthController) RegisterVendor(w http.ResponseWriter, r *http.Request)
 			IsActive:  true,
 		}
 
+		violations, err := ac.passwordService.Validate(database.DB, req.Password, &user)
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to check password", "", "", "", "", nil)
+			return
+		}
+		if len(violations) > 0 {
+			respondWithPasswordViolations(w, violations)
+			return
+		}
+
 		// Start transaction
 		tx := database.DB.Begin()
 		if err := tx.Create(&user).Error; err != nil {
@@ -223,6 +482,12 @@ func (ac *AuthController) RegisterVendor(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
+		if err := ac.passwordService.RecordPassword(tx, user.ID, user.Password); err != nil {
+			tx.Rollback()
+			respondWithJSON(w, 500, "Failed to create user", "", "", "", "", nil)
+			return
+		}
+
 		// Create vendor details (adjust field names to your actual model if needed)
 		vendorDetails := models.VendorDetails{
 			UserID:       user.ID,
@@ -231,8 +496,6 @@ func (ac *AuthController) RegisterVendor(w http.ResponseWriter, r *http.Request)
 			GSTNo:        req.GSTNo,
 			PANNo:        req.PANNo,
 			PhoneNo:      req.PhoneNo,
-			// CategoryID:   &req.CategoryID, // NOTE: keep as-is per your original
-			CategoryID: &req.CategoryID, // NOTE: keep as-is per your original
 		}
 
 		if err := tx.Create(&vendorDetails).Error; err != nil {
@@ -241,27 +504,37 @@ func (ac *AuthController) RegisterVendor(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		tx.Commit()
+		if _, err := ac.organizationService.Join(tx, org.ID, user.ID); err != nil {
+			tx.Rollback()
+			respondWithJSON(w, 500, "Failed to create vendor details", "", "", "", "", nil)
+			return
+		}
 
-		// Generate JWT token
-		refresh, access, err := utils.GenerateTokenPair(user.ID, string(user.Role))
-		if err != nil {
-			respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+		if err := ac.vendorCategoryService.SetCategories(tx, org.ID, user.ID, categoryIDs); err != nil {
+			tx.Rollback()
+			respondWithJSON(w, 500, "Failed to save vendor categories", "", "", "", "", nil)
+			return
+		}
+
+		// Assign default vendor permissions
+		if err := assignDefaultVendorPermissions(tx, user.ID); err != nil {
+			tx.Rollback()
+			respondWithJSON(w, 500, "Failed to assign permission", "", "", "", "", nil)
 			return
 		}
 
-		// Send notification emails
-		email := req.Email
+		tx.Commit()
+
+		// No tokens until the vendor proves they own the email address
 		fullName := req.FirstName + " " + req.LastName
-		subject := "Registered Vendor"
-		content := fmt.Sprintf(`
-			Hi %s,
+		intro := fmt.Sprintf("You have been successfully registered with %s. Once your email is verified your request will be reviewed by an admin.", org.Name)
+		if err := ac.sendVerificationEmail(&user, intro); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		}
 
-			You have been successfully registered. Please wait until your request is 
-			approved by admin.
-		`, fullName)
-		ac.notificationService.SendEmail(email, subject, content)
-		respondWithJSON(w, 200, "Registration for vendor is successfull", "vendor", refresh, access, fullName, nil)
+		respondWithJSON(w, 200, "Registration for vendor is successfull, please verify your email address", "vendor", "", "", fullName, map[string]interface{}{
+			"email_verification_required": true,
+		})
 	}
 }
 
@@ -286,6 +559,13 @@ func (ac *AuthController) RegisterAdmin(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Only invited emails may register as admin
+	invite, err := ac.inviteService.ValidateInvite(req.InviteToken, req.Email)
+	if err != nil {
+		respondWithJSON(w, http.StatusForbidden, err.Error(), "", "", "", "", nil)
+		return
+	}
+
 	// Check if email already exists
 	var existingUser models.User
 	if err := database.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
@@ -293,14 +573,27 @@ func (ac *AuthController) RegisterAdmin(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Create admin user
+	// Create admin user. The invite was delivered to this address, which verifies it.
+	now := time.Now()
 	user := models.User{
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
-		Email:     req.Email,
-		Password:  req.Password, // Will be hashed by BeforeCreate hook
-		Role:      models.RoleAdmin,
-		IsActive:  true,
+		FirstName:       req.FirstName,
+		LastName:        req.LastName,
+		Email:           req.Email,
+		Password:        req.Password, // Will be hashed by BeforeCreate hook
+		Role:            models.RoleAdmin,
+		IsActive:        true,
+		EmailVerifiedAt: &now,
+		OrganizationID:  invite.OrganizationID, // the organization that invited them
+	}
+
+	violations, err := ac.passwordService.Validate(database.DB, req.Password, &user)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to check password", "", "", "", "", nil)
+		return
+	}
+	if len(violations) > 0 {
+		respondWithPasswordViolations(w, violations)
+		return
 	}
 
 	// Start transaction
@@ -312,6 +605,12 @@ func (ac *AuthController) RegisterAdmin(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err := ac.passwordService.RecordPassword(tx, user.ID, user.Password); err != nil {
+		tx.Rollback()
+		respondWithJSON(w, http.StatusInternalServerError, "Failed to create user", "", "", "", "", nil)
+		return
+	}
+
 	// Assign Default admin permission
 	if err := assignDefaultAdminPermissions(tx, user.ID); err != nil {
 		tx.Rollback()
@@ -319,15 +618,35 @@ func (ac *AuthController) RegisterAdmin(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	tx.Commit()
+	// The first admin, invited by the bootstrap command, runs the platform and
+	// sets up the other organizations
+	if invite.InvitedBy == nil {
+		var permission models.Permission
+		if err := tx.Where("name = ?", "manage_organizations").First(&permission).Error; err != nil {
+			tx.Rollback()
+			respondWithJSON(w, 500, "Failed to assign permission", "", "", "", "", nil)
+			return
+		}
+		if _, err := grantPermission(tx, user.ID, permission.ID, nil, "bootstrap admin"); err != nil {
+			tx.Rollback()
+			respondWithJSON(w, 500, "Failed to assign permission", "", "", "", "", nil)
+			return
+		}
+	}
 
-	// generate token
-	refresh, access, err := utils.GenerateTokenPair(user.ID, string(user.Role))
-	if err != nil {
-		respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+	// Redeem the invite in the same transaction so it cannot be used twice
+	if err := ac.inviteService.AcceptInvite(tx, req.InviteToken, req.Email, user.ID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, services.ErrInvalidInvite) || errors.Is(err, services.ErrInviteEmailMismatch) {
+			respondWithJSON(w, http.StatusForbidden, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to accept invite", "", "", "", "", nil)
 		return
 	}
 
+	tx.Commit()
+
 	// Send notification emails
 	email := req.Email
 	fullName := req.FirstName + " " + req.LastName
@@ -338,6 +657,34 @@ func (ac *AuthController) RegisterAdmin(w http.ResponseWriter, r *http.Request)
 		You have been successfully registered.
 	`, fullName)
 	ac.notificationService.SendEmail(email, subject, content)
+
+	tenantID, err := ac.organizationService.ResolveTenant(database.DB, &user, 0)
+	if err != nil {
+		respondWithJSON(w, 403, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	// When policy requires two-factor authentication the new admin enrolls before getting tokens
+	if ac.mfaService.IsRequired(&user) {
+		enrollment, err := utils.GenerateMFAToken(user.ID, string(user.Role), tenantID, utils.TokenTypeMFAEnrollment)
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 200, "Registration for Admin is Successful, two-factor enrollment required", string(models.RoleAdmin), "", "", fullName, map[string]interface{}{
+			"mfa_enrollment_required": true,
+			"enrollment_token":        enrollment,
+		})
+		return
+	}
+
+	// generate token
+	refresh, access, err := ac.tokenService.IssueTokenPair(user.ID, string(user.Role), tenantID, clientInfo(r))
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+		return
+	}
+
 	respondWithJSON(w, 200, "Registration for Admin is Successful", string(models.RoleAdmin), refresh, access, fullName, nil)
 }
 
@@ -361,45 +708,248 @@ func (ac *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find user by email
-	var user models.User
-	if err := database.DB.Where("email = ?", req.Email).Preload("VendorDetails").First(&user).Error; err != nil {
-		respondWithJSON(w, 400, "Email does not exist", "", "", "", "", nil)
+	clientIP := utils.ClientIP(r)
+
+	// Reject locked out accounts and addresses before looking at the password
+	if until, locked, err := ac.loginThrottle.LockedUntil(req.Email, clientIP); err != nil {
+		respondWithJSON(w, 500, "Failed to check login attempts", "", "", "", "", nil)
+		return
+	} else if locked {
+		respondLockedOut(w, until)
+		return
+	}
+
+	// Find user by email. Unknown emails and wrong passwords get the same answer
+	// so the endpoint cannot be used to find out which accounts exist.
+	var user models.User
+	found := database.DB.Where("email = ?", req.Email).First(&user).Error == nil
+	if !found {
+		utils.CheckDummyPassword(req.Password)
+	}
+
+	// Verify password
+	if !found {
+		recordLoginFailure(ac.loginThrottle, ac.notificationService, req.Email, clientIP, nil)
+		respondWithJSON(w, 400, "Invalid email or password", "", "", "", "", nil)
+		return
+	}
+	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		recordLoginFailure(ac.loginThrottle, ac.notificationService, req.Email, clientIP, &user)
+		respondWithJSON(w, 400, "Invalid email or password", "", "", "", "", nil)
+		return
+	}
+
+	// Check if user is active
+	if !user.IsActive {
+		respondWithJSON(w, 400, "Account is deactivated", "", "", "", "", nil)
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
+		respondWithJSON(w, 403, "Email address is not verified", "", "", "", "", map[string]interface{}{
+			"email_verification_required": true,
+		})
+		return
+	}
+
+	tenantID, err := ac.loginTenant(&user, req.Organization)
+	if err != nil {
+		if errors.Is(err, services.ErrOrganizationNotFound) || errors.Is(err, services.ErrNotOrganizationMember) ||
+			errors.Is(err, services.ErrNoOrganization) {
+			respondWithJSON(w, 403, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to check organization", "", "", "", "", nil)
+		return
+	}
+
+	// Every organization approves the vendor on its own, the status that counts
+	// is the one of the organization logged in to
+	if user.Role == models.RoleVendor {
+		membership, err := ac.organizationService.Membership(database.DB, tenantID, user.ID)
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to check vendor status", "", "", "", "", nil)
+			return
+		}
+
+		switch membership.Status {
+		case models.VendorSuspended:
+			respondWithJSON(w, 403, "Vendor account is suspended", "", "", "", "", map[string]interface{}{
+				"vendor_status": membership.Status,
+			})
+			return
+		case models.VendorBlacklisted:
+			respondWithJSON(w, 403, "Vendor account is blacklisted", "", "", "", "", map[string]interface{}{
+				"vendor_status": membership.Status,
+			})
+			return
+		case models.VendorRejected:
+			respondWithJSON(w, 400, "Vendor application was rejected", "", "", "", "", map[string]interface{}{
+				"vendor_status": membership.Status,
+			})
+			return
+		}
+
+		// Vendors awaiting approval only get a token for their profile and KYC documents
+		if membership.Status != models.VendorApproved {
+			onboardingToken, err := utils.GenerateOnboardingToken(user.ID, tenantID)
+			if err != nil {
+				respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+				return
+			}
+			respondWithJSON(w, 400, "Account is not approved by admin", "", "", "", "", map[string]interface{}{
+				"approval_pending": true,
+				"vendor_status":    membership.Status,
+				"tenant_id":        tenantID,
+				"onboarding_token": onboardingToken,
+			})
+			return
+		}
+	}
+
+	fullName := user.FirstName + " " + user.LastName
+
+	// Second step: the password alone is not enough once TOTP is enabled or required
+	mfaEnabled, err := ac.mfaService.IsEnabled(user.ID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to check two-factor authentication", "", "", "", "", nil)
+		return
+	}
+	if mfaEnabled {
+		challenge, err := utils.GenerateMFAToken(user.ID, string(user.Role), tenantID, utils.TokenTypeMFAChallenge)
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 200, "Two-factor verification required", string(user.Role), "", "", fullName, map[string]interface{}{
+			"mfa_required":    true,
+			"challenge_token": challenge,
+		})
+		return
+	}
+	if ac.mfaService.IsRequired(&user) {
+		enrollment, err := utils.GenerateMFAToken(user.ID, string(user.Role), tenantID, utils.TokenTypeMFAEnrollment)
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 200, "Two-factor enrollment required", string(user.Role), "", "", fullName, map[string]interface{}{
+			"mfa_enrollment_required": true,
+			"enrollment_token":        enrollment,
+		})
+		return
+	}
+
+	if err := ac.loginThrottle.RecordSuccess(req.Email); err != nil {
+		log.Printf("Failed to reset login attempts for user %d: %v", user.ID, err)
+	}
+
+	// Generate JWT tokens
+	refresh, access, err := ac.tokenService.IssueTokenPair(user.ID, string(user.Role), tenantID, clientInfo(r))
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Login successful", string(user.Role), refresh, access, fullName, map[string]interface{}{
+		"user_id":   user.ID,
+		"email":     user.Email,
+		"tenant_id": tenantID,
+	})
+}
+
+// loginTenant picks the organization a login acts for, see OrganizationService.ResolveTenant.
+// slug is the organization asked for in the request and may be empty.
+func (ac *AuthController) loginTenant(user *models.User, slug string) (uint, error) {
+	var requested uint
+	if slug != "" {
+		org, err := ac.organizationService.GetActiveBySlug(slug)
+		if err != nil {
+			return 0, err
+		}
+		requested = org.ID
+	}
+	return ac.organizationService.ResolveTenant(database.DB, user, requested)
+}
+
+// VerifyEmail confirms the email address with the token from the verification link
+func (ac *AuthController) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		userID, err := ac.verificationService.Consume(tx, req.Token, models.VerificationPurposeEmail)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", userID).
+			Update("email_verified_at", time.Now()).Error
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to verify email", "", "", "", "", nil)
 		return
 	}
 
-	// Verify password
-	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		respondWithJSON(w, 400, "Invalid password", "", "", "", "", nil)
+	respondWithJSON(w, 200, "Email verified successfully", "", "", "", "", nil)
+}
+
+// ResendVerification sends a new verification link. The answer is the same whether
+// or not the email belongs to an unverified account.
+func (ac *AuthController) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
 		return
 	}
 
-	// Check if user is active
-	if !user.IsActive {
-		respondWithJSON(w, 400, "Account is deactivated", "", "", "", "", nil)
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
 		return
 	}
 
-	if user.Role == models.RoleVendor && !user.VendorDetails.IsApproved {
-		respondWithJSON(w, 400, "Account is not approved by admin", "", "", "", "", nil)
-		return
+	limits := []struct {
+		key   string
+		limit int
+	}{
+		{"resend-verification:email:" + strings.ToLower(req.Email), 3},
+		{"resend-verification:ip:" + utils.ClientIP(r), 10},
+	}
+	for _, l := range limits {
+		allowed, retryAfter, err := ac.rateLimiter.Allow(l.key, l.limit, time.Hour)
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to send verification email", "", "", "", "", nil)
+			return
+		}
+		if !allowed {
+			respondRateLimited(w, retryAfter)
+			return
+		}
 	}
 
-	// Generate JWT tokens
-	refresh, access, err := utils.GenerateTokenPair(user.ID, string(user.Role))
-	if err != nil {
-		respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
-		return
+	var user models.User
+	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err == nil && user.EmailVerifiedAt == nil {
+		if err := ac.sendVerificationEmail(&user, "Here is your new verification link."); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		}
 	}
 
-	fullName := user.FirstName + " " + user.LastName
-	respondWithJSON(w, 200, "Login successful", string(user.Role), refresh, access, fullName, map[string]interface{}{
-		"user_id": user.ID,
-		"email":   user.Email,
-	})
+	respondWithJSON(w, 200, "If the account exists and is not verified yet, a verification email has been sent", "", "", "", "", nil)
 }
 
-// RefreshToken handles token refresh
+// RefreshToken rotates a refresh token into a new access/refresh pair
 func (ac *AuthController) RefreshToken(w http.ResponseWriter, r *http.Request) {
 
 	if r.Method != http.MethodPost {
@@ -419,79 +969,97 @@ func (ac *AuthController) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate new access token
-	newAccessToken, err := utils.RefreshAccessToken(req.RefreshToken)
+	refresh, access, err := ac.tokenService.RotateRefreshToken(req.RefreshToken, clientInfo(r))
 	if err != nil {
-		respondWithJSON(w, 401, "Invalid refresh token", "", "", "", "", nil)
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			respondWithJSON(w, 401, "Refresh token was already used, please login again", "", "", "", "", nil)
+		case errors.Is(err, services.ErrInvalidRefreshToken):
+			respondWithJSON(w, 401, "Invalid refresh token", "", "", "", "", nil)
+		default:
+			respondWithJSON(w, 500, "Failed to refresh token", "", "", "", "", nil)
+		}
 		return
 	}
 
-	respondWithJSON(w, 200, "Token refreshed successfully", "", "", newAccessToken, "", nil)
+	respondWithJSON(w, 200, "Token refreshed successfully", "", refresh, access, "", nil)
 }
 
-// GetVendors - get all vendors with filtering
-func (ac *AuthController) GetVendors(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondWithJSON(w, 405, "Method not allowed", "", "", "", "", nil)
+// Logout revokes the session the given refresh token belongs to
+func (ac *AuthController) Logout(w http.ResponseWriter, r *http.Request) {
+	var req RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
 		return
 	}
 
-	// Query parameters
-	pageStr := r.URL.Query().Get("page")
-	limitStr := r.URL.Query().Get("limit")
-	status := r.URL.Query().Get("status") // pending, approved, rejected
-
-	// Set defaults
-	page := 1
-	limit := 10
-
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+	// Validate request
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
 	}
 
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
+	if err := ac.tokenService.RevokeRefreshToken(req.RefreshToken); err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			respondWithJSON(w, 401, "Invalid refresh token", "", "", "", "", nil)
+		} else {
+			respondWithJSON(w, 500, "Failed to logout", "", "", "", "", nil)
 		}
+		return
 	}
 
-	query := database.DB.Where("role = ?", models.RoleVendor).
-		Preload("VendorDetails").
-		Preload("UserPermissions.Permission")
+	respondWithJSON(w, 200, "Logged out successfully", "", "", "", "", nil)
+}
 
-	// Filter by approval status
-	switch status {
-	case "pending":
-		query = query.Joins("LEFT JOIN vendor_details ON users.id = vendor_details.user_id").
-			Where("vendor_details.is_approved = ? OR vendor_details.is_approved IS NULL", false)
-	case "approved":
-		query = query.Joins("JOIN vendor_details ON users.id = vendor_details.user_id").
-			Where("vendor_details.is_approved = ?", true)
+// LogoutAll revokes every session of the current user
+func (ac *AuthController) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
 	}
 
-	// Get total count
-	var total int64
-	var users []models.User
-	query.Model(&users).Count(&total)
-	// Calculate pagination
-	offset := (page - 1) * limit
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	if err := ac.tokenService.RevokeAllForUser(userID); err != nil {
+		respondWithJSON(w, 500, "Failed to logout", "", "", "", "", nil)
+		return
+	}
 
-	if err := query.Offset(offset).Limit(limit).Find(&users).Error; err != nil {
-		respondWithJSON(w, 500, "Failed to fetch vendors", "", "", "", "", nil)
+	respondWithJSON(w, 200, "Logged out from all sessions", "", "", "", "", nil)
+}
+
+// JWKS publishes the public keys used to verify tokens issued by this service
+func (ac *AuthController) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(utils.PublicJWKS())
+}
+
+// GetVendors - get all vendors of the admin's organization with filtering
+func (ac *AuthController) GetVendors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithJSON(w, 405, "Method not allowed", "", "", "", "", nil)
 		return
 	}
 
-	pagination := Pagination{
-		CurrentPage: page,
-		PerPage:     limit,
-		Total:       total,
-		TotalPages:  totalPages,
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	ac.listVendors(w, r, tenantID)
+}
+
+// GetTenantVendors lists the vendors of the organization given by the tenant_id
+// query parameter, for internal services such as the vendor directory
+func (ac *AuthController) GetTenantVendors(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := strconv.ParseUint(r.URL.Query().Get("tenant_id"), 10, 32)
+	if err != nil || tenantID == 0 {
+		respondWithJSON(w, 400, "Invalid tenant_id", "", "", "", "", nil)
+		return
 	}
 
-	respondWithPagination(w, 200, "Categories retrieved successfully", users, pagination)
+	ac.listVendors(w, r, uint(tenantID))
 }
 
 // GetVendors - get all vendors with filtering
@@ -504,7 +1072,7 @@ func (ac *AuthController) GetVendorsByCategory(w http.ResponseWriter, r *http.Re
 	// Query parameters
 	pageStr := r.URL.Query().Get("page")
 	limitStr := r.URL.Query().Get("limit")
-	status := r.URL.Query().Get("status") // pending, approved, rejected
+	status := r.URL.Query().Get("status") // any vendor status, e.g. pending, approved, suspended
 
 	// Set defaults
 	page := 1
@@ -513,6 +1081,11 @@ func (ac *AuthController) GetVendorsByCategory(w http.ResponseWriter, r *http.Re
 	vars := mux.Vars(r)
 	categoryId := vars["id"] // Ensure the 'id' is present in the route
 
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
 	if pageStr != "" {
 		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
 			page = p
@@ -525,16 +1098,25 @@ func (ac *AuthController) GetVendorsByCategory(w http.ResponseWriter, r *http.Re
 		}
 	}
 
-	// Build the query with correct joins and where clauses
-	query := database.DB.Joins("JOIN vendor_details ON users.id = vendor_details.user_id").
-		Where("users.role = ? AND vendor_details.category_id = ?", models.RoleVendor, categoryId)
+	// Build the query with correct joins and where clauses. Categories of other
+	// organizations find no vendors.
+	query := database.DB.Joins("JOIN vendor_organizations ON vendor_organizations.user_id = users.id AND vendor_organizations.organization_id = ?", tenantID).
+		Joins("JOIN vendor_categories ON users.id = vendor_categories.user_id").
+		Joins("JOIN categories ON categories.id = vendor_categories.category_id AND categories.tenant_id = ?", tenantID).
+		Where("users.role = ? AND vendor_categories.category_id = ?", models.RoleVendor, categoryId)
+
+	// Filter by the review status of the category itself
+	if categoryStatus := r.URL.Query().Get("category_status"); categoryStatus != "" {
+		query = query.Where("vendor_categories.status = ?", categoryStatus)
+	}
 
 	// Filter by approval status
-	switch status {
-	case "pending":
-		query = query.Where("vendor_details.is_approved = ? OR vendor_details.is_approved IS NULL", false)
-	case "approved":
-		query = query.Where("vendor_details.is_approved = ?", true)
+	switch {
+	case status == string(models.VendorPending):
+		query = query.Where("vendor_organizations.status = ?", models.VendorPending).
+			Where("users.email_verified_at IS NOT NULL")
+	case models.IsValidVendorStatus(models.VendorStatus(status)):
+		query = query.Where("vendor_organizations.status = ?", status)
 	}
 
 	// Get total count with error handling
@@ -551,7 +1133,10 @@ func (ac *AuthController) GetVendorsByCategory(w http.ResponseWriter, r *http.Re
 
 	// Fetch vendors with pagination
 	var users []models.User
-	if err := query.Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+	if err := query.Preload("VendorDetails.Categories", "category_id IN (?)", tenantCategoryIDs(tenantID)).
+		Preload("VendorDetails.Categories.Category").
+		Preload("VendorDetails.Organizations", "organization_id = ?", tenantID).
+		Offset(offset).Limit(limit).Find(&users).Error; err != nil {
 		log.Printf("Error fetching vendors: %v", err)
 		respondWithJSON(w, 500, "Failed to fetch vendors", "", "", "", "", nil)
 		return
@@ -569,7 +1154,8 @@ func (ac *AuthController) GetVendorsByCategory(w http.ResponseWriter, r *http.Re
 	respondWithPagination(w, 200, "Vendors retrieved successfully", users, pagination)
 }
 
-// GetVendors - get all vendors with filtering
+// GetVendorById - get a vendor, for internal services. The optional tenant_id
+// query parameter limits it to the vendors of an organization.
 func (ac *AuthController) GetVendorById(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondWithJSON(w, 405, "Method not allowed", "", "", "", "", nil)
@@ -592,9 +1178,18 @@ func (ac *AuthController) GetVendorById(w http.ResponseWriter, r *http.Request)
 
 	var user models.User
 	query := database.DB.Where("id = ? AND role = ?", vendorID, models.RoleVendor).
-		Preload("VendorDetails").
+		Preload("VendorDetails.Categories.Category").
 		Preload("UserPermissions.Permission")
 
+	// Services acting for one organization only see the vendor's membership there
+	if tenantID, err := strconv.ParseUint(r.URL.Query().Get("tenant_id"), 10, 32); err == nil && tenantID > 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM vendor_organizations WHERE vendor_organizations.user_id = users.id AND vendor_organizations.organization_id = ?)", tenantID).
+			Preload("VendorDetails.Organizations", "organization_id = ?", tenantID).
+			Preload("VendorDetails.Categories", "category_id IN (?)", tenantCategoryIDs(uint(tenantID)))
+	} else {
+		query = query.Preload("VendorDetails.Organizations")
+	}
+
 	// Execute query
 	if err := query.First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -608,72 +1203,37 @@ func (ac *AuthController) GetVendorById(w http.ResponseWriter, r *http.Request)
 	respondWithJSON(w, 200, "Vendor retrieved successfully", "", "", "", "", user)
 }
 
-// ApproveVendor - approve/reject a vendor
-func (ac *AuthController) ApproveVendor(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondWithJSON(w, 405, "Method not allowed", "", "", "", "", nil)
-		return
-	}
-
-	var req struct {
-		IsApproved bool   `json:"is_approved"`
-		VendorID   int    `json:"vendor_id" validate:"required"`
-		Notes      string `json:"notes"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+// UnlockUser - lifts a login lockout of a user
+func (ac *AuthController) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
 		return
 	}
-	vendorID := req.VendorID
-	// Find vendor
-	var user models.User
-	if err := database.DB.Where("id = ? AND role = ?", uint(vendorID), models.RoleVendor).
-		Preload("VendorDetails").
-		First(&user).Error; err != nil {
-		respondWithJSON(w, 404, "Vendor not found", "", "", "", "", nil)
+	if _, ok := requireTenantMember(w, r, ac.organizationService, userID); !ok {
 		return
 	}
 
-	if user.VendorDetails == nil {
-		respondWithJSON(w, 400, "Vendor details not found", "", "", "", "", nil)
+	var user models.User
+	if err := database.DB.Select("id", "email").First(&user, userID).Error; err != nil {
+		respondWithJSON(w, 404, "User not found", "", "", "", "", nil)
 		return
 	}
 
-	// Update approval status
-	now := time.Now()
-	updates := map[string]interface{}{
-		"is_approved":    req.IsApproved,
-		"approved_at":    &now,
-		"approval_notes": req.Notes,
-	}
-
-	if err := database.DB.Model(user.VendorDetails).Updates(updates).Error; err != nil {
-		respondWithJSON(w, 500, "Failed to update approval status", "", "", "", "", nil)
+	unlocked, err := ac.loginThrottle.Unlock(user.Email)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to unlock user", "", "", "", "", nil)
 		return
 	}
 
-	// Fetch updated user
-	database.DB.Where("id = ?", uint(vendorID)).Preload("VendorDetails").First(&user)
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	log.Printf("User %d unlocked by admin %d", user.ID, actorID)
 
-	action := "rejected"
-	subject := "Rejected Vendor"
-	if req.IsApproved {
-		action = "approved"
-		subject = "Approved Vendor"
+	message := "User unlocked successfully"
+	if !unlocked {
+		message = "User was not locked"
 	}
-
-	// Send notification emails
-	email := user.Email
-	fullName := user.FirstName + " " + user.LastName
-	content := fmt.Sprintf(`
-		Hi %s,
-
-		Your request to join the RFP system has been %s.
-	`, fullName, action)
-	ac.notificationService.SendEmail(email, subject, content)
-
-	respondWithJSON(w, 200, fmt.Sprintf("Vendor %s successfully", action), "", "", "", "", user)
+	respondWithJSON(w, 200, message, "", "", "", "", nil)
 }
 
 // ForgotPassword - sends OTP to user's email
@@ -695,6 +1255,26 @@ func (ac *AuthController) ForgotPassword(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Limit how often codes can be mailed to one address or requested from one client
+	limits := []struct {
+		key   string
+		limit int
+	}{
+		{"forgot-password:email:" + strings.ToLower(req.Email), 3},
+		{"forgot-password:ip:" + utils.ClientIP(r), 10},
+	}
+	for _, l := range limits {
+		allowed, retryAfter, err := ac.rateLimiter.Allow(l.key, l.limit, time.Hour)
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to process request", "", "", "", "", nil)
+			return
+		}
+		if !allowed {
+			respondRateLimited(w, retryAfter)
+			return
+		}
+	}
+
 	// Check if user exists
 	var user models.User
 	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
@@ -707,12 +1287,22 @@ func (ac *AuthController) ForgotPassword(w http.ResponseWriter, r *http.Request)
 	database.DB.Where("email = ?", req.Email).Delete(&models.PasswordResetOTP{})
 
 	// Generate new OTP
-	otp := generateOTP()
+	otp, err := generateOTP()
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to process request", "", "", "", "", nil)
+		return
+	}
+
+	otpHash, err := utils.HashPassword(otp)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to process request", "", "", "", "", nil)
+		return
+	}
 
-	// Save OTP to database
+	// Save OTP to database, only its hash is stored
 	resetOTP := models.PasswordResetOTP{
 		Email:     req.Email,
-		OTP:       otp,
+		OTPHash:   otpHash,
 		Attempts:  0,
 		ExpiresAt: time.Now().Add(15 * time.Minute), // OTP expires in 15 minutes
 	}
@@ -760,76 +1350,242 @@ func (ac *AuthController) ResetPassword(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Find OTP record
-	var resetOTP models.PasswordResetOTP
-	if err := database.DB.Where("email = ?", req.Email).First(&resetOTP).Error; err != nil {
-		respondWithJSON(w, 400, "Invalid or expired OTP", "", "", "", "", nil)
+	// The OTP row is locked while it is checked, so parallel guesses are counted
+	// one after the other and cannot get past the attempt limit. A rejected OTP
+	// commits the new attempt count, rejected is the message to answer with.
+	var user models.User
+	var rejected string
+	var violations []utils.PasswordViolation
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var resetOTP models.PasswordResetOTP
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("email = ?", req.Email).
+			First(&resetOTP).Error; err != nil {
+			rejected = "Invalid or expired OTP"
+			return nil
+		}
+
+		// Check if OTP has expired
+		if time.Now().After(resetOTP.ExpiresAt) {
+			rejected = "OTP has expired"
+			return tx.Delete(&resetOTP).Error
+		}
+
+		// Check if attempts exceeded
+		if resetOTP.Attempts >= 3 {
+			rejected = "Too many failed attempts. Please request a new OTP"
+			return tx.Delete(&resetOTP).Error
+		}
+
+		// Verify OTP, bcrypt compares in constant time
+		if !utils.CheckPasswordHash(req.OTP, resetOTP.OTPHash) {
+			attempts := resetOTP.Attempts + 1
+			if attempts >= 3 {
+				rejected = "Too many failed attempts. Please request a new OTP"
+				return tx.Delete(&resetOTP).Error
+			}
+			rejected = fmt.Sprintf("Invalid OTP. %d attempts remaining", 3-attempts)
+			return tx.Model(&resetOTP).Update("attempts", attempts).Error
+		}
+
+		// OTP is valid, update password
+		if err := tx.Where("email = ?", req.Email).First(&user).Error; err != nil {
+			rejected = "User not found"
+			return nil
+		}
+
+		// The OTP stays valid so the user can retry with a password that meets the policy
+		var err error
+		violations, err = ac.passwordService.Validate(tx, req.NewPassword, &user)
+		if err != nil || len(violations) > 0 {
+			return err
+		}
+
+		if err := ac.passwordService.SetPassword(tx, &user, req.NewPassword); err != nil {
+			return err
+		}
+		if err := tx.Delete(&resetOTP).Error; err != nil {
+			return err
+		}
+
+		// Whoever knew the old password must not stay logged in
+		return ac.tokenService.RevokeAllForUserTx(tx, user.ID)
+	})
+	if err != nil {
+		log.Printf("Failed to reset password of %s: %v", req.Email, err)
+		respondWithJSON(w, 500, "Failed to update password", "", "", "", "", nil)
+		return
+	}
+	if rejected != "" {
+		respondWithJSON(w, 400, rejected, "", "", "", "", nil)
+		return
+	}
+	if len(violations) > 0 {
+		respondWithPasswordViolations(w, violations)
+		return
+	}
+
+	// Send confirmation email
+	subject := "Password Reset Successful"
+	content := fmt.Sprintf(`
+		Hi %s,
+
+		Your password has been successfully reset. You can now login with your new password.
+
+		If you did not make this change, please contact support immediately.
+	`, user.FirstName)
+
+	go ac.notificationService.SendEmail(req.Email, subject, content)
+
+	respondWithJSON(w, 200, "Password reset successful", "", "", "", "", nil)
+}
+
+// SetPassword sets a password with the token emailed to imported vendors and to
+// users whose password an admin reset. Following the link also confirms the email address.
+func (ac *AuthController) SetPassword(w http.ResponseWriter, r *http.Request) {
+	var req SetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	// The token is only redeemed once the password meets the policy, so the
+	// vendor can retry with a better one
+	userID, err := ac.verificationService.Lookup(req.Token, models.VerificationPurposeSetPassword)
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
 		return
 	}
 
-	// Check if OTP has expired
-	if time.Now().After(resetOTP.ExpiresAt) {
-		database.DB.Delete(&resetOTP)
-		respondWithJSON(w, 400, "OTP has expired", "", "", "", "", nil)
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		respondWithJSON(w, 400, services.ErrInvalidVerificationToken.Error(), "", "", "", "", nil)
 		return
 	}
 
-	// Check if attempts exceeded
-	if resetOTP.Attempts >= 3 {
-		database.DB.Delete(&resetOTP)
-		respondWithJSON(w, 400, "Too many failed attempts. Please request a new OTP", "", "", "", "", nil)
+	violations, err := ac.passwordService.Validate(database.DB, req.Password, &user)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to process password", "", "", "", "", nil)
+		return
+	}
+	if len(violations) > 0 {
+		respondWithPasswordViolations(w, violations)
 		return
 	}
 
-	// Verify OTP
-	if resetOTP.OTP != req.OTP {
-		// Increment attempts
-		resetOTP.Attempts++
-		if resetOTP.Attempts >= 3 {
-			database.DB.Delete(&resetOTP)
-			respondWithJSON(w, 400, "Too many failed attempts. Please request a new OTP", "", "", "", "", nil)
-		} else {
-			database.DB.Save(&resetOTP)
-			remainingAttempts := 3 - resetOTP.Attempts
-			respondWithJSON(w, 400, fmt.Sprintf("Invalid OTP. %d attempts remaining", remainingAttempts), "", "", "", "", nil)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := ac.verificationService.Consume(tx, req.Token, models.VerificationPurposeSetPassword); err != nil {
+			return err
+		}
+		if err := ac.passwordService.SetPassword(tx, &user, req.Password); err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", user.ID).
+			Update("email_verified_at", time.Now()).Error
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+			return
 		}
+		respondWithJSON(w, 500, "Failed to set password", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Password set successfully, you can now log in", "", "", "", "", nil)
+}
+
+// ChangePassword - changes the password of the logged in user
+func (ac *AuthController) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
 		return
 	}
 
-	// OTP is valid, update password
 	var user models.User
-	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		respondWithJSON(w, 400, "User not found", "", "", "", "", nil)
+	if err := database.DB.First(&user, userID).Error; err != nil || !user.IsActive {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	// Wrong current passwords count towards the login lockout, a stolen session
+	// must not become a way to guess the password without limit
+	clientIP := utils.ClientIP(r)
+	if until, locked, err := ac.loginThrottle.LockedUntil(user.Email, clientIP); err != nil {
+		respondWithJSON(w, 500, "Failed to check login attempts", "", "", "", "", nil)
+		return
+	} else if locked {
+		respondLockedOut(w, until)
+		return
+	}
+
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.Password) {
+		recordLoginFailure(ac.loginThrottle, ac.notificationService, user.Email, clientIP, &user)
+		respondWithJSON(w, 400, "Current password is incorrect", "", "", "", "", nil)
 		return
 	}
 
-	// Hash new password
-	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err := ac.loginThrottle.RecordSuccess(user.Email); err != nil {
+		log.Printf("Failed to reset login attempts for user %d: %v", user.ID, err)
+	}
+
+	violations, err := ac.passwordService.Validate(database.DB, req.NewPassword, &user)
 	if err != nil {
 		respondWithJSON(w, 500, "Failed to process password", "", "", "", "", nil)
 		return
 	}
+	if len(violations) > 0 {
+		respondWithPasswordViolations(w, violations)
+		return
+	}
 
-	// Update password
-	if err := database.DB.Model(&user).Update("password", hashedPassword).Error; err != nil {
+	// Log out every session together with the password change and hand this one a fresh pair
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ac.passwordService.SetPassword(tx, &user, req.NewPassword); err != nil {
+			return err
+		}
+		return ac.tokenService.RevokeAllForUserTx(tx, user.ID)
+	}); err != nil {
 		respondWithJSON(w, 500, "Failed to update password", "", "", "", "", nil)
 		return
 	}
 
-	// Delete OTP record
-	database.DB.Delete(&resetOTP)
+	tenantID, _ := middleware.GetTenantIDFromContext(r)
+	refresh, access, err := ac.tokenService.IssueTokenPair(user.ID, string(user.Role), tenantID, clientInfo(r))
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+		return
+	}
 
-	// Send confirmation email
-	subject := "Password Reset Successful"
+	subject := "Password Changed"
 	content := fmt.Sprintf(`
 		Hi %s,
 
-		Your password has been successfully reset. You can now login with your new password.
+		The password of your account was just changed.
 
-		If you did not make this change, please contact support immediately.
+		If you did not make this change, reset your password and contact support immediately.
 	`, user.FirstName)
 
-	go ac.notificationService.SendEmail(req.Email, subject, content)
+	go ac.notificationService.SendEmail(user.Email, subject, content)
 
-	respondWithJSON(w, 200, "Password reset successful", "", "", "", "", nil)
+	fullName := user.FirstName + " " + user.LastName
+	respondWithJSON(w, 200, "Password changed successfully", string(user.Role), refresh, access, fullName, nil)
 }
@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+// region validators
+type InviteController struct {
+	notificationService *services.NotificationService
+	inviteService       *services.InviteService
+	organizationService *services.OrganizationService
+}
+
+type CreateInviteRequest struct {
+	Email          string `json:"email" validate:"required,email"`
+	OrganizationID uint   `json:"organization_id"` // another organization, only for admins managing organizations
+}
+
+// InviteResponse is an invite as listed in the admin API
+type InviteResponse struct {
+	models.AdminInvite
+	Status models.InviteStatus `json:"status"`
+}
+
+// endregion validators
+
+// region helpers
+func NewInviteController() *InviteController {
+	return &InviteController{
+		notificationService: services.NewNotificationService(),
+		inviteService:       services.NewInviteService(),
+		organizationService: services.NewOrganizationService(),
+	}
+}
+
+// endregion helpers
+
+// CreateInvite invites an email address to register as admin
+func (ic *InviteController) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	var req CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, "Data is not in correct format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	var existingUser models.User
+	if err := database.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+		respondWithJSON(w, http.StatusConflict, "Email is already present", "", "", "", "", nil)
+		return
+	}
+
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+	actorID, _ := middleware.GetUserIDFromContext(r)
+
+	// Admins invite into their own organization. Bringing in the first admin of
+	// another organization is part of managing organizations.
+	orgID := tenantID
+	if req.OrganizationID != 0 && req.OrganizationID != tenantID {
+		allowed, err := middleware.UserHasPermission(actorID, "organization", "manage")
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to check permissions", "", "", "", "", nil)
+			return
+		}
+		if !allowed {
+			respondWithJSON(w, http.StatusForbidden, "Only admins managing organizations can invite into another organization", "", "", "", "", nil)
+			return
+		}
+		orgID = req.OrganizationID
+	}
+
+	org, err := ic.organizationService.Get(orgID)
+	if err != nil {
+		if errors.Is(err, services.ErrOrganizationNotFound) {
+			respondWithJSON(w, http.StatusBadRequest, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to fetch organization", "", "", "", "", nil)
+		return
+	}
+
+	invite, token, err := ic.inviteService.CreateInvite(req.Email, org.ID, &actorID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to create invite", "", "", "", "", nil)
+		return
+	}
+
+	subject := "Admin invitation"
+	content := fmt.Sprintf(`
+		Hi,
+
+		You have been invited to register as an admin of %s. Use the link below to create your account:
+
+		%s
+
+		This invitation expires on %s and can only be used once.
+	`, org.Name, ic.inviteService.InviteLink(token), invite.ExpiresAt.Format(time.RFC1123))
+	ic.notificationService.SendEmail(invite.Email, subject, content)
+
+	respondWithJSON(w, http.StatusCreated, "Invite sent successfully", "", "", "", "", InviteResponse{
+		AdminInvite: *invite,
+		Status:      invite.Status(),
+	})
+}
+
+// ListInvites lists the invites of the organization, newest first, optionally filtered by status
+func (ic *InviteController) ListInvites(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	pageStr := r.URL.Query().Get("page")
+	limitStr := r.URL.Query().Get("limit")
+
+	page := 1
+	limit := 20
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	query := database.DB.Model(&models.AdminInvite{}).Where("organization_id = ?", tenantID)
+	switch models.InviteStatus(r.URL.Query().Get("status")) {
+	case "":
+	case models.InviteStatusAccepted:
+		query = query.Where("accepted_at IS NOT NULL")
+	case models.InviteStatusRevoked:
+		query = query.Where("revoked_at IS NOT NULL AND accepted_at IS NULL")
+	case models.InviteStatusExpired:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at <= ?", time.Now())
+	case models.InviteStatusPending:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", time.Now())
+	default:
+		respondWithJSON(w, http.StatusBadRequest, "Invalid status", "", "", "", "", nil)
+		return
+	}
+	if email := strings.TrimSpace(r.URL.Query().Get("email")); email != "" {
+		query = query.Where("email = ?", strings.ToLower(email))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch invites", "", "", "", "", nil)
+		return
+	}
+
+	var invites []models.AdminInvite
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&invites).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch invites", "", "", "", "", nil)
+		return
+	}
+
+	data := make([]InviteResponse, 0, len(invites))
+	for _, invite := range invites {
+		data = append(data, InviteResponse{AdminInvite: invite, Status: invite.Status()})
+	}
+
+	pagination := Pagination{
+		CurrentPage: page,
+		PerPage:     limit,
+		Total:       total,
+		TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+	}
+
+	respondWithPagination(w, 200, "Invites retrieved successfully", data, pagination)
+}
+
+// RevokeInvite cancels an invite that has not been accepted yet
+func (ic *InviteController) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	inviteID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, err.Error(), "", "", "", "", nil)
+		return
+	}
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	var invite models.AdminInvite
+	if err := database.DB.Where("organization_id = ?", tenantID).First(&invite, inviteID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithJSON(w, http.StatusNotFound, "Invite not found", "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to fetch invite", "", "", "", "", nil)
+		return
+	}
+
+	if invite.AcceptedAt != nil {
+		respondWithJSON(w, http.StatusConflict, "Invite has already been accepted", "", "", "", "", nil)
+		return
+	}
+
+	if invite.RevokedAt == nil {
+		now := time.Now()
+		if err := database.DB.Model(&invite).Update("revoked_at", &now).Error; err != nil {
+			respondWithJSON(w, 500, "Failed to revoke invite", "", "", "", "", nil)
+			return
+		}
+		invite.RevokedAt = &now
+	}
+
+	respondWithJSON(w, 200, "Invite revoked successfully", "", "", "", "", InviteResponse{
+		AdminInvite: invite,
+		Status:      invite.Status(),
+	})
+}
@@ -0,0 +1,371 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+)
+
+// region validators
+type MFAController struct {
+	notificationService *services.NotificationService
+	tokenService        *services.TokenService
+	loginThrottle       *services.LoginThrottleService
+	mfaService          *services.MFAService
+	organizationService *services.OrganizationService
+}
+
+type MFAVerifyLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"` // TOTP or recovery code
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type MFADisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// endregion validators
+
+// region helpers
+func NewMFAController() *MFAController {
+	return &MFAController{
+		notificationService: services.NewNotificationService(),
+		tokenService:        services.NewTokenService(),
+		loginThrottle:       services.NewLoginThrottleService(),
+		organizationService: services.NewOrganizationService(),
+		mfaService:          services.NewMFAService(),
+	}
+}
+
+func (mc *MFAController) currentUser(r *http.Request) (*models.User, bool) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		return nil, false
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil || !user.IsActive {
+		return nil, false
+	}
+	return &user, true
+}
+
+// endregion helpers
+
+// VerifyLogin completes a login with the challenge token and a TOTP or recovery code
+func (mc *MFAController) VerifyLogin(w http.ResponseWriter, r *http.Request) {
+	var req MFAVerifyLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	claims, err := utils.ValidateMFAToken(req.ChallengeToken, utils.TokenTypeMFAChallenge)
+	if err != nil || claims.TenantID == 0 {
+		respondWithJSON(w, 401, "Invalid or expired challenge token", "", "", "", "", nil)
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, claims.UserID).Error; err != nil || !user.IsActive {
+		respondWithJSON(w, 401, "Invalid or expired challenge token", "", "", "", "", nil)
+		return
+	}
+
+	// Wrong codes count towards the same lockout as wrong passwords
+	clientIP := utils.ClientIP(r)
+	if until, locked, err := mc.loginThrottle.LockedUntil(user.Email, clientIP); err != nil {
+		respondWithJSON(w, 500, "Failed to check login attempts", "", "", "", "", nil)
+		return
+	} else if locked {
+		respondLockedOut(w, until)
+		return
+	}
+
+	// A challenge completes one login only, like a refresh token rotates once
+	if err := mc.mfaService.VerifyChallenge(user.ID, claims.ID, claims.ExpiresAt.Time, req.Code); err != nil {
+		if errors.Is(err, services.ErrMFAChallengeUsed) {
+			respondWithJSON(w, 401, "Invalid or expired challenge token", "", "", "", "", nil)
+			return
+		}
+		if errors.Is(err, services.ErrInvalidMFACode) {
+			recordLoginFailure(mc.loginThrottle, mc.notificationService, user.Email, clientIP, &user)
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+			return
+		}
+		if errors.Is(err, services.ErrMFANotEnabled) {
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to verify code", "", "", "", "", nil)
+		return
+	}
+
+	if err := mc.loginThrottle.RecordSuccess(user.Email); err != nil {
+		log.Printf("Failed to reset login attempts for user %d: %v", user.ID, err)
+	}
+
+	// The organization was picked at the first login step
+	refresh, access, err := mc.tokenService.IssueTokenPair(user.ID, string(user.Role), claims.TenantID, clientInfo(r))
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+		return
+	}
+
+	fullName := user.FirstName + " " + user.LastName
+	respondWithJSON(w, 200, "Login successful", string(user.Role), refresh, access, fullName, map[string]interface{}{
+		"user_id":   user.ID,
+		"email":     user.Email,
+		"tenant_id": claims.TenantID,
+	})
+}
+
+// GetStatus reports whether two-factor authentication is enabled for the current user
+func (mc *MFAController) GetStatus(w http.ResponseWriter, r *http.Request) {
+	user, ok := mc.currentUser(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	mfa, err := mc.mfaService.Get(user.ID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch two-factor status", "", "", "", "", nil)
+		return
+	}
+
+	status := map[string]interface{}{
+		"enabled":  false,
+		"required": mc.mfaService.IsRequired(user),
+	}
+	if mfa != nil && mfa.Enabled {
+		remaining, err := mc.mfaService.RemainingRecoveryCodes(user.ID)
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to fetch two-factor status", "", "", "", "", nil)
+			return
+		}
+		status["enabled"] = true
+		status["enabled_at"] = mfa.EnabledAt
+		status["recovery_codes_remaining"] = remaining
+	}
+
+	respondWithJSON(w, 200, "Two-factor status retrieved successfully", "", "", "", "", status)
+}
+
+// Setup starts enrollment and returns the secret and provisioning URI for the authenticator app
+func (mc *MFAController) Setup(w http.ResponseWriter, r *http.Request) {
+	user, ok := mc.currentUser(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	secret, uri, err := mc.mfaService.BeginEnrollment(user)
+	if err != nil {
+		if errors.Is(err, services.ErrMFAAlreadyEnabled) {
+			respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to start two-factor enrollment", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Scan the provisioning URI and confirm with a code", "", "", "", "", map[string]interface{}{
+		"secret":           secret,
+		"provisioning_uri": uri,
+	})
+}
+
+// Enable confirms enrollment with a code from the app and returns the recovery codes.
+// When called with an enrollment token it also completes the pending login.
+func (mc *MFAController) Enable(w http.ResponseWriter, r *http.Request) {
+	user, ok := mc.currentUser(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	recoveryCodes, err := mc.mfaService.ConfirmEnrollment(user.ID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMFACode), errors.Is(err, services.ErrMFANotStarted):
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		case errors.Is(err, services.ErrMFAAlreadyEnabled):
+			respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+		default:
+			respondWithJSON(w, 500, "Failed to enable two-factor authentication", "", "", "", "", nil)
+		}
+		return
+	}
+
+	data := map[string]interface{}{
+		"recovery_codes": recoveryCodes,
+	}
+
+	if middleware.GetTokenTypeFromContext(r) != utils.TokenTypeMFAEnrollment {
+		respondWithJSON(w, 200, "Two-factor authentication enabled", "", "", "", "", data)
+		return
+	}
+
+	if err := mc.loginThrottle.RecordSuccess(user.Email); err != nil {
+		log.Printf("Failed to reset login attempts for user %d: %v", user.ID, err)
+	}
+
+	tenantID, _ := middleware.GetTenantIDFromContext(r)
+	refresh, access, err := mc.tokenService.IssueTokenPair(user.ID, string(user.Role), tenantID, clientInfo(r))
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+		return
+	}
+
+	fullName := user.FirstName + " " + user.LastName
+	respondWithJSON(w, 200, "Two-factor authentication enabled", string(user.Role), refresh, access, fullName, data)
+}
+
+// Disable turns two-factor authentication off, which needs both the password and a code
+func (mc *MFAController) Disable(w http.ResponseWriter, r *http.Request) {
+	user, ok := mc.currentUser(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	if mc.mfaService.IsRequired(user) {
+		respondWithJSON(w, 403, "Two-factor authentication is required for your account", "", "", "", "", nil)
+		return
+	}
+
+	var req MFADisableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		respondWithJSON(w, 400, "Invalid password", "", "", "", "", nil)
+		return
+	}
+
+	if err := mc.mfaService.Verify(user.ID, req.Code); err != nil {
+		if errors.Is(err, services.ErrInvalidMFACode) || errors.Is(err, services.ErrMFANotEnabled) {
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to verify code", "", "", "", "", nil)
+		return
+	}
+
+	if err := mc.mfaService.Disable(user.ID); err != nil {
+		respondWithJSON(w, 500, "Failed to disable two-factor authentication", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Two-factor authentication disabled", "", "", "", "", nil)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes after checking a current code
+func (mc *MFAController) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user, ok := mc.currentUser(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	if err := mc.mfaService.Verify(user.ID, req.Code); err != nil {
+		if errors.Is(err, services.ErrInvalidMFACode) || errors.Is(err, services.ErrMFANotEnabled) {
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to verify code", "", "", "", "", nil)
+		return
+	}
+
+	recoveryCodes, err := mc.mfaService.RegenerateRecoveryCodes(user.ID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to generate recovery codes", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Recovery codes regenerated", "", "", "", "", map[string]interface{}{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// ResetUserMFA lets an admin remove the two-factor setup of a user who lost their device.
+// The user has to enroll again on the next login if policy requires it.
+func (mc *MFAController) ResetUserMFA(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	if _, ok := requireTenantMember(w, r, mc.organizationService, userID); !ok {
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		respondWithJSON(w, 404, "User not found", "", "", "", "", nil)
+		return
+	}
+
+	if err := mc.mfaService.Disable(user.ID); err != nil {
+		respondWithJSON(w, 500, "Failed to reset two-factor authentication", "", "", "", "", nil)
+		return
+	}
+
+	// Sessions that passed the old second factor should not survive the reset
+	if err := mc.tokenService.RevokeAllForUser(user.ID); err != nil {
+		log.Printf("Failed to revoke sessions of user %d: %v", user.ID, err)
+	}
+
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	log.Printf("Two-factor authentication of user %d reset by admin %d", user.ID, actorID)
+
+	respondWithJSON(w, 200, "Two-factor authentication reset successfully", "", "", "", "", nil)
+}
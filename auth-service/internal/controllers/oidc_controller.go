@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+// region validators
+type OIDCController struct {
+	tokenService        *services.TokenService
+	mfaService          *services.MFAService
+	oidcService         *services.OIDCService
+	organizationService *services.OrganizationService
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+// endregion validators
+
+// region helpers
+func NewOIDCController() *OIDCController {
+	return &OIDCController{
+		tokenService:        services.NewTokenService(),
+		mfaService:          services.NewMFAService(),
+		oidcService:         services.NewOIDCService(),
+		organizationService: services.NewOrganizationService(),
+	}
+}
+
+// provisionAdmin creates the local admin account on the first sign-on. The random
+// password is never handed out, the admin can set one through forgot password.
+// The identity provider is the one of the default organization, so that is where
+// provisioned admins go.
+func (oc *OIDCController) provisionAdmin(identity *services.OIDCClaims) (*models.User, error) {
+	password, err := utils.NewSecureToken()
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := oc.organizationService.GetActiveBySlug(models.DefaultOrganizationSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	lastName := identity.LastName
+	if lastName == "" {
+		lastName = "-"
+	}
+
+	now := time.Now()
+	user := models.User{
+		FirstName:       identity.FirstName,
+		LastName:        lastName,
+		Email:           identity.Email,
+		Password:        password, // Will be hashed by BeforeCreate hook
+		Role:            models.RoleAdmin,
+		IsActive:        true,
+		EmailVerifiedAt: &now,
+		OrganizationID:  &org.ID,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if err := assignDefaultAdminPermissions(tx, user.ID); err != nil {
+			return err
+		}
+		return oc.oidcService.LinkIdentity(tx, user.ID, identity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Provisioned admin %d for %s through single sign-on", user.ID, identity.Email)
+	return &user, nil
+}
+
+// endregion helpers
+
+// Login starts single sign-on and returns the identity provider URL to redirect to
+func (oc *OIDCController) Login(w http.ResponseWriter, r *http.Request) {
+	authorizationURL, err := oc.oidcService.AuthorizationURL()
+	if err != nil {
+		if errors.Is(err, services.ErrOIDCDisabled) {
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+			return
+		}
+		log.Printf("Failed to start single sign-on: %v", err)
+		respondWithJSON(w, 502, "Identity provider is unavailable", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Redirect to the identity provider", "", "", "", "", map[string]interface{}{
+		"authorization_url": authorizationURL,
+	})
+}
+
+// Callback completes single sign-on with the code and state the identity provider
+// redirected back with, and logs the admin in
+func (oc *OIDCController) Callback(w http.ResponseWriter, r *http.Request) {
+	var req OIDCCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	identity, err := oc.oidcService.Exchange(req.Code, req.State)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOIDCDisabled):
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+		case errors.Is(err, services.ErrInvalidOIDCState), errors.Is(err, services.ErrInvalidIDToken):
+			respondWithJSON(w, 401, err.Error(), "", "", "", "", nil)
+		case errors.Is(err, services.ErrOIDCEmailMissing):
+			respondWithJSON(w, 403, err.Error(), "", "", "", "", nil)
+		default:
+			log.Printf("Failed to complete single sign-on: %v", err)
+			respondWithJSON(w, 502, "Identity provider is unavailable", "", "", "", "", nil)
+		}
+		return
+	}
+
+	// Group membership is checked on every sign-on so removing someone from the
+	// group at the identity provider takes away their access
+	if _, ok := oc.oidcService.RoleFor(identity.Groups); !ok {
+		respondWithJSON(w, 403, "Your account is not allowed to sign in to this application", "", "", "", "", nil)
+		return
+	}
+
+	user, err := oc.oidcService.FindUser(identity)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to look up user", "", "", "", "", nil)
+		return
+	}
+
+	if user == nil {
+		// Identities with an unverified email only sign in once linked to an account
+		if !identity.EmailVerified {
+			respondWithJSON(w, 403, services.ErrOIDCEmailMissing.Error(), "", "", "", "", nil)
+			return
+		}
+		user, err = oc.provisionAdmin(identity)
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to create user", "", "", "", "", nil)
+			return
+		}
+	} else {
+		if user.Role != models.RoleAdmin {
+			respondWithJSON(w, 403, "Single sign-on is only available for admin accounts", "", "", "", "", nil)
+			return
+		}
+		if !user.IsActive {
+			respondWithJSON(w, 400, "Account is deactivated", "", "", "", "", nil)
+			return
+		}
+		if err := oc.oidcService.LinkIdentity(database.DB, user.ID, identity); err != nil {
+			respondWithJSON(w, 500, "Failed to link identity", "", "", "", "", nil)
+			return
+		}
+	}
+
+	tenantID, err := oc.organizationService.ResolveTenant(database.DB, user, 0)
+	if err != nil {
+		if errors.Is(err, services.ErrNoOrganization) {
+			respondWithJSON(w, 403, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to check organization", "", "", "", "", nil)
+		return
+	}
+
+	fullName := user.FirstName + " " + user.LastName
+
+	// The identity provider enforces its own second factor, but a TOTP the admin set
+	// up locally is still asked for
+	mfaEnabled, err := oc.mfaService.IsEnabled(user.ID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to check two-factor authentication", "", "", "", "", nil)
+		return
+	}
+	if mfaEnabled {
+		challenge, err := utils.GenerateMFAToken(user.ID, string(user.Role), tenantID, utils.TokenTypeMFAChallenge)
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 200, "Two-factor verification required", string(user.Role), "", "", fullName, map[string]interface{}{
+			"mfa_required":    true,
+			"challenge_token": challenge,
+		})
+		return
+	}
+
+	refresh, access, err := oc.tokenService.IssueTokenPair(user.ID, string(user.Role), tenantID, clientInfo(r))
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to generate tokens", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Login successful", string(user.Role), refresh, access, fullName, map[string]interface{}{
+		"user_id":   user.ID,
+		"email":     user.Email,
+		"tenant_id": tenantID,
+	})
+}
@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+// region validators
+type OrganizationController struct {
+	organizationService   *services.OrganizationService
+	vendorCategoryService *services.VendorCategoryService
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,max=150"`
+	Slug string `json:"slug" validate:"required,max=100"`
+}
+
+type UpdateOrganizationRequest struct {
+	Name     *string `json:"name" validate:"omitempty,min=1,max=150"`
+	IsActive *bool   `json:"is_active"`
+}
+
+type JoinOrganizationRequest struct {
+	Organization string `json:"organization" validate:"required"`
+	CategoryIDs  []uint `json:"categories" validate:"required,min=1,max=20"`
+}
+
+// PublicOrganization is what anyone may know about an organization, used by the
+// registration and login pages
+type PublicOrganization struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// endregion validators
+
+// region helpers
+func NewOrganizationController() *OrganizationController {
+	return &OrganizationController{
+		organizationService:   services.NewOrganizationService(),
+		vendorCategoryService: services.NewVendorCategoryService(),
+	}
+}
+
+// endregion helpers
+
+// GetOrganization returns the public details of an active organization by its slug
+func (oc *OrganizationController) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	org, err := oc.organizationService.GetActiveBySlug(mux.Vars(r)["slug"])
+	if err != nil {
+		if errors.Is(err, services.ErrOrganizationNotFound) {
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to fetch organization", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Organization retrieved successfully", "", "", "", "", PublicOrganization{
+		ID:   org.ID,
+		Name: org.Name,
+		Slug: org.Slug,
+	})
+}
+
+// ListOrganizations lists every organization of the platform
+func (oc *OrganizationController) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	orgs, err := oc.organizationService.List()
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch organizations", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Organizations retrieved successfully", "", "", "", "", orgs)
+}
+
+// CreateOrganization adds a buyer organization. Its first admin is invited
+// through the invites endpoint with its organization_id.
+func (oc *OrganizationController) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var req CreateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	org, err := oc.organizationService.Create(req.Name, req.Slug)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSlug):
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		case errors.Is(err, services.ErrOrganizationExists):
+			respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+		default:
+			respondWithJSON(w, 500, "Failed to create organization", "", "", "", "", nil)
+		}
+		return
+	}
+
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	log.Printf("Organization %d (%s) created by user %d", org.ID, org.Slug, actorID)
+
+	respondWithJSON(w, http.StatusCreated, "Organization created successfully", "", "", "", "", org)
+}
+
+// UpdateOrganization renames or (de)activates an organization
+func (oc *OrganizationController) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	orgID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	var req UpdateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	if req.IsActive != nil && !*req.IsActive {
+		if tenantID, _ := middleware.GetTenantIDFromContext(r); tenantID == orgID {
+			respondWithJSON(w, 409, "You cannot deactivate your own organization", "", "", "", "", nil)
+			return
+		}
+	}
+
+	org, err := oc.organizationService.Update(orgID, req.Name, req.IsActive)
+	if err != nil {
+		if errors.Is(err, services.ErrOrganizationNotFound) {
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to update organization", "", "", "", "", nil)
+		return
+	}
+
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	log.Printf("Organization %d updated by user %d", org.ID, actorID)
+
+	respondWithJSON(w, 200, "Organization updated successfully", "", "", "", "", org)
+}
+
+// ListMemberships lists the organizations the logged in vendor belongs to with
+// their approval status in each
+func (oc *OrganizationController) ListMemberships(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	memberships, err := oc.organizationService.Memberships(userID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch organizations", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Organizations retrieved successfully", "", "", "", "", memberships)
+}
+
+// JoinOrganization applies the logged in vendor to another buyer organization
+// with the categories they serve there. The organization reviews the vendor like
+// a new registration; the vendor logs in to it once approved.
+func (oc *OrganizationController) JoinOrganization(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	var req JoinOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	org, err := oc.organizationService.GetActiveBySlug(req.Organization)
+	if err != nil {
+		if errors.Is(err, services.ErrOrganizationNotFound) {
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to find organization", "", "", "", "", nil)
+		return
+	}
+
+	if err := oc.vendorCategoryService.CheckCategories(org.ID, req.CategoryIDs); err != nil {
+		if errors.Is(err, services.ErrInvalidCategory) || errors.Is(err, services.ErrNoCategories) ||
+			errors.Is(err, services.ErrVendorCategoryLimitExceeded) {
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to check category", "", "", "", "", nil)
+		return
+	}
+
+	var membership *models.VendorOrganization
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if membership, err = oc.organizationService.Join(tx, org.ID, userID); err != nil {
+			return err
+		}
+		return oc.vendorCategoryService.SetCategories(tx, org.ID, userID, req.CategoryIDs)
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrAlreadyMember) {
+			respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to join organization", "", "", "", "", nil)
+		return
+	}
+	membership.Organization = org
+
+	log.Printf("Vendor %d applied to organization %d", userID, org.ID)
+
+	respondWithJSON(w, http.StatusCreated, "Application sent, the organization will review your account", "", "", "", "", membership)
+}
@@ -0,0 +1,602 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+// region validators
+type PermissionController struct {
+	organizationService *services.OrganizationService
+}
+
+type GrantPermissionRequest struct {
+	PermissionID uint   `json:"permission_id" validate:"required"`
+	Reason       string `json:"reason"`
+}
+
+type AccessRoleRequest struct {
+	Name          string `json:"name" validate:"required,max=100"`
+	Description   string `json:"description" validate:"max=255"`
+	PermissionIDs []uint `json:"permission_ids" validate:"required,min=1"`
+}
+
+type AssignAccessRoleRequest struct {
+	AccessRoleID uint `json:"role_id" validate:"required"`
+}
+
+// accessRoleTemplateNote is returned with every change to an access role, so the
+// admin making it knows it does not reach the users the role was assigned to
+const accessRoleTemplateNote = "Roles are templates applied when assigned. Users already assigned this role keep the permissions they were granted, grant or revoke them per user."
+
+// AccessRoleChangeResponse is an updated or deleted access role with accessRoleTemplateNote
+type AccessRoleChangeResponse struct {
+	*models.AccessRole
+	Note string `json:"note"`
+}
+
+// endregion validators
+
+// region helpers
+func NewPermissionController() *PermissionController {
+	return &PermissionController{
+		organizationService: services.NewOrganizationService(),
+	}
+}
+
+// platformResource is the resource of permissions that reach beyond a single
+// organization, only admins holding them may hand them out
+const platformResource = "organization"
+
+// canGrant reports whether the actor may hand out or take away every permission,
+// writing the error response when they may not
+func canGrant(w http.ResponseWriter, actorID uint, permissions []models.Permission) bool {
+	for _, permission := range permissions {
+		if permission.Resource != platformResource {
+			continue
+		}
+		allowed, err := middleware.UserHasPermission(actorID, permission.Resource, permission.Action)
+		if err != nil {
+			respondWithJSON(w, 500, "Failed to check permissions", "", "", "", "", nil)
+			return false
+		}
+		if !allowed {
+			respondWithJSON(w, 403, fmt.Sprintf("Only admins holding %s:%s can grant or revoke it", permission.Resource, permission.Action), "", "", "", "", nil)
+			return false
+		}
+	}
+	return true
+}
+
+func recordPermissionAudit(tx *gorm.DB, entry models.PermissionAuditLog) error {
+	return tx.Create(&entry).Error
+}
+
+// grantPermission gives a user a permission unless they already hold it.
+// It reports whether a new UserPermission row was created.
+func grantPermission(tx *gorm.DB, userID, permissionID uint, actorID *uint, details string) (bool, error) {
+	var count int64
+	if err := tx.Model(&models.UserPermission{}).
+		Where("user_id = ? AND permission_id = ?", userID, permissionID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	userPermission := models.UserPermission{
+		UserID:       userID,
+		PermissionID: permissionID,
+	}
+	if err := tx.Create(&userPermission).Error; err != nil {
+		return false, err
+	}
+	if err := services.BumpPermissionsVersion(tx, userID); err != nil {
+		return false, err
+	}
+
+	return true, recordPermissionAudit(tx, models.PermissionAuditLog{
+		ActorID:      actorID,
+		TargetUserID: &userID,
+		Action:       models.AuditActionGrant,
+		PermissionID: &permissionID,
+		Details:      details,
+	})
+}
+
+// revokePermission removes a permission from a user.
+// It reports whether the user held the permission.
+func revokePermission(tx *gorm.DB, userID, permissionID uint, actorID *uint, details string) (bool, error) {
+	result := tx.Where("user_id = ? AND permission_id = ?", userID, permissionID).Delete(&models.UserPermission{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	if err := ensureUserManagerRemains(tx, userID, permissionID); err != nil {
+		return false, err
+	}
+	if err := services.BumpPermissionsVersion(tx, userID); err != nil {
+		return false, err
+	}
+
+	return true, recordPermissionAudit(tx, models.PermissionAuditLog{
+		ActorID:      actorID,
+		TargetUserID: &userID,
+		Action:       models.AuditActionRevoke,
+		PermissionID: &permissionID,
+		Details:      details,
+	})
+}
+
+// ensureUserManagerRemains refuses to revoke user:manage from the last active
+// user of the organization holding it, whichever way it is revoked
+func ensureUserManagerRemains(tx *gorm.DB, userID, permissionID uint) error {
+	var permission models.Permission
+	if err := tx.First(&permission, permissionID).Error; err != nil {
+		return err
+	}
+	if permission.Resource != "user" || permission.Action != "manage" {
+		return nil
+	}
+
+	var user models.User
+	if err := tx.Select("id", "organization_id").First(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.OrganizationID == nil {
+		return nil
+	}
+	return services.EnsureOtherUserManager(tx, *user.OrganizationID, userID)
+}
+
+// grantAccessRole grants every permission of the bundle the user does not hold yet
+func grantAccessRole(tx *gorm.DB, accessRole *models.AccessRole, userID uint, actorID *uint) error {
+	details := fmt.Sprintf("granted through access role %s", accessRole.Name)
+	for _, permission := range accessRole.Permissions {
+		if _, err := grantPermission(tx, userID, permission.ID, actorID, details); err != nil {
+			return err
+		}
+	}
+
+	return recordPermissionAudit(tx, models.PermissionAuditLog{
+		ActorID:      actorID,
+		TargetUserID: &userID,
+		Action:       models.AuditActionRoleAssign,
+		AccessRoleID: &accessRole.ID,
+		Details:      accessRole.Name,
+	})
+}
+
+// findUserForPermissions loads the user of the route, who must belong to the
+// admin's organization
+func (pc *PermissionController) findUserForPermissions(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	userID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, "Invalid user ID", "", "", "", "", nil)
+		return nil, false
+	}
+	if _, ok := requireTenantMember(w, r, pc.organizationService, userID); !ok {
+		return nil, false
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithJSON(w, 404, "User not found", "", "", "", "", nil)
+		} else {
+			respondWithJSON(w, 500, "Failed to fetch user", "", "", "", "", nil)
+		}
+		return nil, false
+	}
+	return &user, true
+}
+
+// endregion helpers
+
+// ListPermissions - all permissions that can be granted
+func (pc *PermissionController) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	var permissions []models.Permission
+	if err := database.DB.Order("resource, action").Find(&permissions).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch permissions", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Permissions retrieved successfully", "", "", "", "", permissions)
+}
+
+// GetUserPermissions - permissions currently held by a user
+func (pc *PermissionController) GetUserPermissions(w http.ResponseWriter, r *http.Request) {
+	user, ok := pc.findUserForPermissions(w, r)
+	if !ok {
+		return
+	}
+
+	var userPermissions []models.UserPermission
+	if err := database.DB.Where("user_id = ?", user.ID).
+		Preload("Permission").
+		Find(&userPermissions).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch user permissions", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "User permissions retrieved successfully", "", "", "", "", userPermissions)
+}
+
+// GrantUserPermission - grant a single permission to a user
+func (pc *PermissionController) GrantUserPermission(w http.ResponseWriter, r *http.Request) {
+	actorID, _ := middleware.GetUserIDFromContext(r)
+
+	user, ok := pc.findUserForPermissions(w, r)
+	if !ok {
+		return
+	}
+
+	var req GrantPermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	var permission models.Permission
+	if err := database.DB.First(&permission, req.PermissionID).Error; err != nil {
+		respondWithJSON(w, 404, "Permission not found", "", "", "", "", nil)
+		return
+	}
+	if !canGrant(w, actorID, []models.Permission{permission}) {
+		return
+	}
+
+	var granted bool
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		granted, err = grantPermission(tx, user.ID, permission.ID, &actorID, req.Reason)
+		return err
+	})
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to grant permission", "", "", "", "", nil)
+		return
+	}
+	middleware.InvalidateUserPermissions(user.ID)
+
+	if !granted {
+		respondWithJSON(w, 200, "User already has this permission", "", "", "", "", permission)
+		return
+	}
+	respondWithJSON(w, 200, "Permission granted successfully", "", "", "", "", permission)
+}
+
+// RevokeUserPermission - revoke a single permission from a user
+func (pc *PermissionController) RevokeUserPermission(w http.ResponseWriter, r *http.Request) {
+	actorID, _ := middleware.GetUserIDFromContext(r)
+
+	user, ok := pc.findUserForPermissions(w, r)
+	if !ok {
+		return
+	}
+
+	permissionID, err := parseIDParam(r, "permission_id")
+	if err != nil {
+		respondWithJSON(w, 400, "Invalid permission ID", "", "", "", "", nil)
+		return
+	}
+
+	// Taking a permission away needs the same standing as handing it out
+	var permission models.Permission
+	if err := database.DB.First(&permission, permissionID).Error; err != nil {
+		respondWithJSON(w, 404, "Permission not found", "", "", "", "", nil)
+		return
+	}
+	if !canGrant(w, actorID, []models.Permission{permission}) {
+		return
+	}
+
+	var revoked bool
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		revoked, err = revokePermission(tx, user.ID, permission.ID, &actorID, r.URL.Query().Get("reason"))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrLastUserManager) {
+			respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to revoke permission", "", "", "", "", nil)
+		return
+	}
+	middleware.InvalidateUserPermissions(user.ID)
+
+	if !revoked {
+		respondWithJSON(w, 404, "User does not have this permission", "", "", "", "", nil)
+		return
+	}
+	respondWithJSON(w, 200, "Permission revoked successfully", "", "", "", "", nil)
+}
+
+// ListAccessRoles - all named permission bundles
+func (pc *PermissionController) ListAccessRoles(w http.ResponseWriter, r *http.Request) {
+	var accessRoles []models.AccessRole
+	if err := database.DB.Preload("Permissions").Order("name").Find(&accessRoles).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch roles", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Roles retrieved successfully", "", "", "", "", accessRoles)
+}
+
+// CreateAccessRole - define a new named permission bundle
+func (pc *PermissionController) CreateAccessRole(w http.ResponseWriter, r *http.Request) {
+	actorID, _ := middleware.GetUserIDFromContext(r)
+
+	var req AccessRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	var existingRole models.AccessRole
+	if err := database.DB.Where("name = ?", req.Name).First(&existingRole).Error; err == nil {
+		respondWithJSON(w, 409, "Role already exists", "", "", "", "", nil)
+		return
+	}
+
+	var permissions []models.Permission
+	if err := database.DB.Where("id IN ?", req.PermissionIDs).Find(&permissions).Error; err != nil || len(permissions) != len(req.PermissionIDs) {
+		respondWithJSON(w, 400, "One or more permissions do not exist", "", "", "", "", nil)
+		return
+	}
+
+	accessRole := models.AccessRole{
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   &actorID,
+		Permissions: permissions,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&accessRole).Error; err != nil {
+			return err
+		}
+		return recordPermissionAudit(tx, models.PermissionAuditLog{
+			ActorID:      &actorID,
+			Action:       models.AuditActionRoleCreate,
+			AccessRoleID: &accessRole.ID,
+			Details:      fmt.Sprintf("%s: %v", accessRole.Name, req.PermissionIDs),
+		})
+	})
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to create role", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Role created successfully", "", "", "", "", accessRole)
+}
+
+// UpdateAccessRole - change the description or permissions of a bundle. Users who
+// were already granted the role keep their existing permissions, the response says so.
+func (pc *PermissionController) UpdateAccessRole(w http.ResponseWriter, r *http.Request) {
+	actorID, _ := middleware.GetUserIDFromContext(r)
+
+	accessRoleID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, "Invalid role ID", "", "", "", "", nil)
+		return
+	}
+
+	var req AccessRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	var accessRole models.AccessRole
+	if err := database.DB.First(&accessRole, accessRoleID).Error; err != nil {
+		respondWithJSON(w, 404, "Role not found", "", "", "", "", nil)
+		return
+	}
+
+	isDefaultRole := accessRole.Name == models.DefaultAdminAccessRole || accessRole.Name == models.DefaultVendorAccessRole
+	if isDefaultRole && req.Name != accessRole.Name {
+		respondWithJSON(w, 400, "Default roles cannot be renamed", "", "", "", "", nil)
+		return
+	}
+
+	var permissions []models.Permission
+	if err := database.DB.Where("id IN ?", req.PermissionIDs).Find(&permissions).Error; err != nil || len(permissions) != len(req.PermissionIDs) {
+		respondWithJSON(w, 400, "One or more permissions do not exist", "", "", "", "", nil)
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&accessRole).Updates(map[string]interface{}{
+			"name":        req.Name,
+			"description": req.Description,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&accessRole).Association("Permissions").Replace(permissions); err != nil {
+			return err
+		}
+		return recordPermissionAudit(tx, models.PermissionAuditLog{
+			ActorID:      &actorID,
+			Action:       models.AuditActionRoleUpdate,
+			AccessRoleID: &accessRole.ID,
+			Details:      fmt.Sprintf("%s: %v", req.Name, req.PermissionIDs),
+		})
+	})
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to update role", "", "", "", "", nil)
+		return
+	}
+
+	database.DB.Preload("Permissions").First(&accessRole, accessRole.ID)
+	respondWithJSON(w, 200, "Role updated successfully, users already assigned it keep their permissions", "", "", "", "",
+		AccessRoleChangeResponse{AccessRole: &accessRole, Note: accessRoleTemplateNote})
+}
+
+// DeleteAccessRole - remove a bundle. Permissions already granted through it are
+// kept, the response says so.
+func (pc *PermissionController) DeleteAccessRole(w http.ResponseWriter, r *http.Request) {
+	actorID, _ := middleware.GetUserIDFromContext(r)
+
+	accessRoleID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, "Invalid role ID", "", "", "", "", nil)
+		return
+	}
+
+	var accessRole models.AccessRole
+	if err := database.DB.First(&accessRole, accessRoleID).Error; err != nil {
+		respondWithJSON(w, 404, "Role not found", "", "", "", "", nil)
+		return
+	}
+
+	if accessRole.Name == models.DefaultAdminAccessRole || accessRole.Name == models.DefaultVendorAccessRole {
+		respondWithJSON(w, 400, "Default roles cannot be deleted", "", "", "", "", nil)
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&accessRole).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Delete(&accessRole).Error; err != nil {
+			return err
+		}
+		return recordPermissionAudit(tx, models.PermissionAuditLog{
+			ActorID:      &actorID,
+			Action:       models.AuditActionRoleDelete,
+			AccessRoleID: &accessRole.ID,
+			Details:      accessRole.Name,
+		})
+	})
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to delete role", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Role deleted successfully, users assigned it keep their permissions", "", "", "", "",
+		AccessRoleChangeResponse{AccessRole: &accessRole, Note: accessRoleTemplateNote})
+}
+
+// AssignAccessRole - grant every permission of a bundle to a user
+func (pc *PermissionController) AssignAccessRole(w http.ResponseWriter, r *http.Request) {
+	actorID, _ := middleware.GetUserIDFromContext(r)
+
+	user, ok := pc.findUserForPermissions(w, r)
+	if !ok {
+		return
+	}
+
+	var req AssignAccessRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	var accessRole models.AccessRole
+	if err := database.DB.Preload("Permissions").First(&accessRole, req.AccessRoleID).Error; err != nil {
+		respondWithJSON(w, 404, "Role not found", "", "", "", "", nil)
+		return
+	}
+	if !canGrant(w, actorID, accessRole.Permissions) {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return grantAccessRole(tx, &accessRole, user.ID, &actorID)
+	})
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to assign role", "", "", "", "", nil)
+		return
+	}
+	middleware.InvalidateUserPermissions(user.ID)
+
+	respondWithJSON(w, 200, "Role assigned successfully", "", "", "", "", accessRole)
+}
+
+// GetPermissionAuditLog - who changed which access of the users of the admin's organization and when
+func (pc *PermissionController) GetPermissionAuditLog(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	pageStr := r.URL.Query().Get("page")
+	limitStr := r.URL.Query().Get("limit")
+
+	page := 1
+	limit := 50
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	query := database.DB.Model(&models.PermissionAuditLog{}).
+		Where("target_user_id IN (SELECT id FROM users WHERE organization_id = ? UNION SELECT user_id FROM vendor_organizations WHERE organization_id = ?)", tenantID, tenantID)
+	if userID := r.URL.Query().Get("user_id"); userID != "" {
+		query = query.Where("target_user_id = ?", userID)
+	}
+	if actorID := r.URL.Query().Get("actor_id"); actorID != "" {
+		query = query.Where("actor_id = ?", actorID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch audit log", "", "", "", "", nil)
+		return
+	}
+
+	var entries []models.PermissionAuditLog
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch audit log", "", "", "", "", nil)
+		return
+	}
+
+	pagination := Pagination{
+		CurrentPage: page,
+		PerPage:     limit,
+		Total:       total,
+		TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+	}
+
+	respondWithPagination(w, 200, "Audit log retrieved successfully", entries, pagination)
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+)
+
+// region validators
+type ServiceClientController struct {
+	serviceClientService *services.ServiceClientService
+}
+
+type CreateServiceClientRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1"`
+}
+
+// ServiceTokenResponse is the OAuth 2.0 client credentials token response
+type ServiceTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// endregion validators
+
+// region helpers
+func NewServiceClientController() *ServiceClientController {
+	return &ServiceClientController{
+		serviceClientService: services.NewServiceClientService(),
+	}
+}
+
+// respondWithOAuthError answers the token endpoint in the format OAuth 2.0 clients expect
+func respondWithOAuthError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="auth-service"`)
+	}
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+// endregion helpers
+
+// Token issues a service token for the client credentials grant. Credentials are
+// accepted as HTTP basic auth or as client_id and client_secret form fields.
+func (sc *ServiceClientController) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondWithOAuthError(w, 400, "invalid_request", "Request body must be form encoded")
+		return
+	}
+
+	if grantType := r.PostForm.Get("grant_type"); grantType != "client_credentials" {
+		respondWithOAuthError(w, 400, "unsupported_grant_type", "Only the client_credentials grant is supported")
+		return
+	}
+
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID == "" || secret == "" {
+		respondWithOAuthError(w, 401, "invalid_client", "Client credentials are required")
+		return
+	}
+
+	client, scope, err := sc.serviceClientService.Authenticate(clientID, secret, r.PostForm.Get("scope"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidClient):
+			respondWithOAuthError(w, 401, "invalid_client", err.Error())
+		case errors.Is(err, services.ErrInvalidScope):
+			respondWithOAuthError(w, 400, "invalid_scope", err.Error())
+		default:
+			respondWithOAuthError(w, 500, "server_error", "Failed to authenticate client")
+		}
+		return
+	}
+
+	token, err := utils.GenerateServiceToken(client.ClientID, scope)
+	if err != nil {
+		respondWithOAuthError(w, 500, "server_error", "Failed to generate token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(ServiceTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(utils.ServiceTokenDuration.Seconds()),
+		Scope:       scope,
+	})
+}
+
+// CreateServiceClient registers an internal service and returns its secret once
+func (sc *ServiceClientController) CreateServiceClient(w http.ResponseWriter, r *http.Request) {
+	var req CreateServiceClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, "Data is not in correct format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	client, secret, err := sc.serviceClientService.Create(req.Name, req.Scopes, &actorID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScope) {
+			respondWithJSON(w, http.StatusBadRequest, err.Error(), "", "", "", "", map[string]interface{}{
+				"allowed_scopes": utils.ServiceScopes,
+			})
+			return
+		}
+		respondWithJSON(w, 500, "Failed to create service client", "", "", "", "", nil)
+		return
+	}
+
+	log.Printf("Service client %s (%s) with scopes %q created by admin %d", client.Name, client.ClientID, client.Scopes, actorID)
+
+	respondWithJSON(w, http.StatusCreated, "Service client created, store the secret now as it is not shown again", "", "", "", "", map[string]interface{}{
+		"client":        client,
+		"client_secret": secret,
+	})
+}
+
+// ListServiceClients lists every registered service client
+func (sc *ServiceClientController) ListServiceClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := sc.serviceClientService.List()
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch service clients", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Service clients retrieved successfully", "", "", "", "", map[string]interface{}{
+		"clients":        clients,
+		"allowed_scopes": utils.ServiceScopes,
+	})
+}
+
+// RevokeServiceClient disables a service client so it cannot get new tokens
+func (sc *ServiceClientController) RevokeServiceClient(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	client, err := sc.serviceClientService.Revoke(id)
+	if err != nil {
+		if errors.Is(err, services.ErrServiceClientNotFound) {
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to revoke service client", "", "", "", "", nil)
+		return
+	}
+
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	log.Printf("Service client %s (%s) revoked by admin %d", client.Name, client.ClientID, actorID)
+
+	respondWithJSON(w, 200, "Service client revoked successfully", "", "", "", "", client)
+}
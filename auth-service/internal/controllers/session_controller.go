@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+)
+
+// region validators
+type SessionController struct {
+	tokenService        *services.TokenService
+	organizationService *services.OrganizationService
+}
+
+// SessionResponse is a session as shown to its user
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
+
+// endregion validators
+
+// region helpers
+func NewSessionController() *SessionController {
+	return &SessionController{
+		tokenService:        services.NewTokenService(),
+		organizationService: services.NewOrganizationService(),
+	}
+}
+
+func toSessionResponses(sessions []models.UserSession, currentSessionID string) []SessionResponse {
+	responses := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, SessionResponse{
+			ID:         session.ID,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    currentSessionID != "" && session.SessionID == currentSessionID,
+		})
+	}
+	return responses
+}
+
+// endregion helpers
+
+// ListSessions lists where the current user is logged in
+func (sc *SessionController) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	sessions, err := sc.tokenService.ListActiveSessions(userID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch sessions", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Sessions retrieved successfully", "", "", "", "", toSessionResponses(sessions, middleware.GetSessionIDFromContext(r)))
+}
+
+// RevokeSession logs the current user out of one of their sessions
+func (sc *SessionController) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	sessionID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	if err := sc.tokenService.RevokeSession(userID, sessionID); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to revoke session", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Session revoked successfully", "", "", "", "", nil)
+}
+
+// ListUserSessions lists the active sessions a user of the admin's organization
+// started for it
+func (sc *SessionController) ListUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+	tenantID, ok := requireTenantMember(w, r, sc.organizationService, userID)
+	if !ok {
+		return
+	}
+
+	sessions, err := sc.tokenService.ListActiveSessions(userID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch sessions", "", "", "", "", nil)
+		return
+	}
+
+	tenantSessions := make([]models.UserSession, 0, len(sessions))
+	for _, session := range sessions {
+		if session.OrganizationID == nil || *session.OrganizationID == tenantID {
+			tenantSessions = append(tenantSessions, session)
+		}
+	}
+
+	respondWithJSON(w, 200, "Sessions retrieved successfully", "", "", "", "", toSessionResponses(tenantSessions, ""))
+}
+
+// RevokeUserSessions logs a user of the admin's organization out everywhere.
+// Vendors are only logged out of the sessions they started for the organization.
+func (sc *SessionController) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+	tenantID, ok := requireTenantMember(w, r, sc.organizationService, userID)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Select("id", "role").First(&user, userID).Error; err != nil {
+		respondWithJSON(w, 404, "User not found", "", "", "", "", nil)
+		return
+	}
+
+	revoke := sc.tokenService.RevokeAllForUser
+	if user.Role == models.RoleVendor {
+		revoke = func(userID uint) error {
+			return sc.tokenService.RevokeForOrganization(userID, tenantID)
+		}
+	}
+	if err := revoke(user.ID); err != nil {
+		respondWithJSON(w, 500, "Failed to revoke sessions", "", "", "", "", nil)
+		return
+	}
+
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	log.Printf("All sessions of user %d revoked by admin %d", user.ID, actorID)
+
+	respondWithJSON(w, 200, "Sessions revoked successfully", "", "", "", "", nil)
+}
@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/config"
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+// region validators
+type UserController struct {
+	notificationService *services.NotificationService
+	verificationService *services.VerificationService
+	userService         *services.UserService
+}
+
+type DeactivateUserRequest struct {
+	Reason string `json:"reason" validate:"max=1000"`
+}
+
+type ChangeRoleRequest struct {
+	Role models.Role `json:"role" validate:"required"`
+}
+
+// endregion validators
+
+// region helpers
+func NewUserController() *UserController {
+	return &UserController{
+		notificationService: services.NewNotificationService(),
+		verificationService: services.NewVerificationService(),
+		userService:         services.NewUserService(),
+	}
+}
+
+// respondUserError answers the errors shared by the account management endpoints
+func respondUserError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+	case errors.Is(err, services.ErrInvalidRole), errors.Is(err, services.ErrSelfManagement):
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+	case errors.Is(err, services.ErrUserUnchanged), errors.Is(err, services.ErrLastActiveAdmin),
+		errors.Is(err, services.ErrSharedVendor), errors.Is(err, services.ErrLastUserManager):
+		respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+	default:
+		respondWithJSON(w, 500, fallback, "", "", "", "", nil)
+	}
+}
+
+// replaceRolePermissions swaps the default permissions of the old role for those
+// of the new one. Permissions granted individually are dropped as well, they were
+// given for the old role.
+func replaceRolePermissions(tx *gorm.DB, userID uint, from, to models.Role, actorID *uint) error {
+	var permissionIDs []uint
+	if err := tx.Model(&models.UserPermission{}).Where("user_id = ?", userID).Pluck("permission_id", &permissionIDs).Error; err != nil {
+		return err
+	}
+	details := fmt.Sprintf("role changed from %s to %s", from, to)
+	for _, permissionID := range permissionIDs {
+		if _, err := revokePermission(tx, userID, permissionID, actorID, details); err != nil {
+			return err
+		}
+	}
+
+	name := models.DefaultVendorAccessRole
+	if to == models.RoleAdmin {
+		name = models.DefaultAdminAccessRole
+	}
+	var accessRole models.AccessRole
+	if err := tx.Where("name = ?", name).Preload("Permissions").First(&accessRole).Error; err != nil {
+		return err
+	}
+	return grantAccessRole(tx, &accessRole, userID, actorID)
+}
+
+// endregion helpers
+
+// ListUsers lists the users of the organization, admins included. It can search
+// by name or email with "q" and filter by "role" and "is_active".
+func (uc *UserController) ListUsers(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	pageStr := r.URL.Query().Get("page")
+	limitStr := r.URL.Query().Get("limit")
+
+	page := 1
+	limit := 20
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	filter := services.UserFilter{
+		OrganizationID: tenantID,
+		Search:         r.URL.Query().Get("q"),
+		Role:           models.Role(r.URL.Query().Get("role")),
+	}
+	if filter.Role != "" && filter.Role != models.RoleAdmin && filter.Role != models.RoleVendor {
+		respondWithJSON(w, 400, services.ErrInvalidRole.Error(), "", "", "", "", nil)
+		return
+	}
+	if isActive := r.URL.Query().Get("is_active"); isActive != "" {
+		active, err := strconv.ParseBool(isActive)
+		if err != nil {
+			respondWithJSON(w, 400, "is_active must be true or false", "", "", "", "", nil)
+			return
+		}
+		filter.IsActive = &active
+	}
+
+	users, total, err := uc.userService.List(filter, page, limit)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch users", "", "", "", "", nil)
+		return
+	}
+
+	pagination := Pagination{
+		CurrentPage: page,
+		PerPage:     limit,
+		Total:       total,
+		TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+	}
+
+	respondWithPagination(w, 200, "Users retrieved successfully", users, pagination)
+}
+
+// GetUser returns a user with their vendor details and permissions
+func (uc *UserController) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := uc.userService.Get(tenantID, userID)
+	if err != nil {
+		respondUserError(w, err, "Failed to fetch user")
+		return
+	}
+
+	respondWithJSON(w, 200, "User retrieved successfully", "", "", "", "", user)
+}
+
+// DeactivateUser blocks an account and logs the user out everywhere
+func (uc *UserController) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	var req DeactivateUserRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+			return
+		}
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	user, err := uc.userService.SetActive(tenantID, userID, actorID, false)
+	if err != nil {
+		respondUserError(w, err, "Failed to deactivate user")
+		return
+	}
+	middleware.InvalidateUserPermissions(user.ID)
+	log.Printf("User %d deactivated by admin %d", user.ID, actorID)
+
+	reason := ""
+	if req.Reason != "" {
+		reason = "\n\t\tReason: " + req.Reason + "\n"
+	}
+	subject := "Your account has been deactivated"
+	content := fmt.Sprintf(`
+		Hi %s,
+
+		Your account has been deactivated by an administrator and you have been logged out.
+		%s
+		If you believe this is a mistake, please contact support.
+	`, user.FirstName+" "+user.LastName, reason)
+	go uc.notificationService.SendEmail(user.Email, subject, content)
+
+	respondWithJSON(w, 200, "User deactivated successfully", "", "", "", "", user)
+}
+
+// ReactivateUser lets a deactivated user log in again
+func (uc *UserController) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	user, err := uc.userService.SetActive(tenantID, userID, actorID, true)
+	if err != nil {
+		respondUserError(w, err, "Failed to reactivate user")
+		return
+	}
+	middleware.InvalidateUserPermissions(user.ID)
+	log.Printf("User %d reactivated by admin %d", user.ID, actorID)
+
+	subject := "Your account has been reactivated"
+	content := fmt.Sprintf(`
+		Hi %s,
+
+		Your account has been reactivated. You can log in again.
+	`, user.FirstName+" "+user.LastName)
+	go uc.notificationService.SendEmail(user.Email, subject, content)
+
+	respondWithJSON(w, 200, "User reactivated successfully", "", "", "", "", user)
+}
+
+// ForcePasswordReset invalidates the password of a user, logs them out and emails
+// them a link to choose a new one
+func (uc *UserController) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := uc.userService.ForcePasswordReset(tenantID, userID)
+	if err != nil {
+		respondUserError(w, err, "Failed to reset password")
+		return
+	}
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	log.Printf("Password reset of user %d forced by admin %d", user.ID, actorID)
+
+	cfg := config.Load()
+	token, err := uc.verificationService.Issue(user.ID, models.VerificationPurposeSetPassword, time.Duration(cfg.PasswordResetLinkTTLHours)*time.Hour)
+	if err != nil {
+		// The password is already invalidated, the user can still use forgot password
+		log.Printf("Failed to issue password reset link for user %d: %v", user.ID, err)
+		respondWithJSON(w, 500, "Password was reset but the reset link could not be sent", "", "", "", "", nil)
+		return
+	}
+
+	link := strings.TrimSuffix(cfg.FrontendURL, "/") + "/set-password?token=" + token
+	subject := "Please choose a new password"
+	content := fmt.Sprintf(`
+		Hi %s,
+
+		An administrator has reset your password and you have been logged out.
+
+		Please choose a new password by opening the link below:
+
+		%s
+
+		The link expires in %d hours.
+	`, user.FirstName+" "+user.LastName, link, cfg.PasswordResetLinkTTLHours)
+	go uc.notificationService.SendEmail(user.Email, subject, content)
+
+	respondWithJSON(w, 200, "Password reset, the user has been emailed a link to set a new one", "", "", "", "", nil)
+}
+
+// ChangeRole switches a user between admin and vendor. The permissions of the
+// old role are replaced by the defaults of the new one and the user is logged out.
+func (uc *UserController) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	var user *models.User
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var previous models.Role
+		var err error
+		user, previous, err = uc.userService.ChangeRole(tx, tenantID, userID, actorID, req.Role)
+		if err != nil {
+			return err
+		}
+		return replaceRolePermissions(tx, user.ID, previous, user.Role, &actorID)
+	})
+	if err != nil {
+		respondUserError(w, err, "Failed to change role")
+		return
+	}
+	middleware.InvalidateUserPermissions(user.ID)
+	uc.userService.RevokeSessions(user.ID)
+	log.Printf("Role of user %d changed to %s by admin %d", user.ID, user.Role, actorID)
+
+	subject := "Your account role has changed"
+	content := fmt.Sprintf(`
+		Hi %s,
+
+		An administrator changed your account to a %s account. Please log in again.
+	`, user.FirstName+" "+user.LastName, user.Role)
+	go uc.notificationService.SendEmail(user.Email, subject, content)
+
+	respondWithJSON(w, 200, "Role changed successfully", "", "", "", "", user)
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+)
+
+// region validators
+type VendorCategoryController struct {
+	vendorCategoryService *services.VendorCategoryService
+	vendorContactService  *services.VendorContactService
+}
+
+type ReviewVendorCategoryRequest struct {
+	Notes string `json:"notes"`
+}
+
+// CategoryVendor is a vendor invited to RFPs of a category. ContactEmails are the
+// vendor's contacts subscribed to the category, RFPs go to them as well.
+type CategoryVendor struct {
+	ID            uint     `json:"id"`
+	FirstName     string   `json:"first_name"`
+	LastName      string   `json:"last_name"`
+	Email         string   `json:"email"`
+	ContactEmails []string `json:"contact_emails,omitempty"`
+}
+
+// endregion validators
+
+// region helpers
+func NewVendorCategoryController() *VendorCategoryController {
+	return &VendorCategoryController{
+		vendorCategoryService: services.NewVendorCategoryService(),
+		vendorContactService:  services.NewVendorContactService(),
+	}
+}
+
+func (cc *VendorCategoryController) reviewVendorCategory(w http.ResponseWriter, r *http.Request, approve bool) {
+	vendorID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+	categoryID, err := parseIDParam(r, "category_id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	var req ReviewVendorCategoryRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+			return
+		}
+	}
+
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	vendorCategory, err := cc.vendorCategoryService.Review(tenantID, vendorID, categoryID, actorID, approve, req.Notes)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrVendorCategoryNotFound):
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+		case errors.Is(err, services.ErrVendorCategoryNotPending):
+			respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+		default:
+			respondWithJSON(w, 500, "Failed to review vendor category", "", "", "", "", nil)
+		}
+		return
+	}
+
+	action := "rejected"
+	if approve {
+		action = "approved"
+	}
+	respondWithJSON(w, 200, fmt.Sprintf("Vendor category %s successfully", action), "", "", "", "", vendorCategory)
+}
+
+// endregion helpers
+
+// ListVendorCategories lists vendor categories of the admin's organization, pending ones by default
+func (cc *VendorCategoryController) ListVendorCategories(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	pageStr := r.URL.Query().Get("page")
+	limitStr := r.URL.Query().Get("limit")
+
+	page := 1
+	limit := 20
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	status := models.VendorCategoryStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = models.VendorCategoryPending
+	}
+
+	query := database.DB.Model(&models.VendorCategory{}).
+		Where("status = ?", status).
+		Where("category_id IN (?)", database.DB.Model(&models.Category{}).Select("id").Where("tenant_id = ?", tenantID))
+	if categoryID := r.URL.Query().Get("category_id"); categoryID != "" {
+		query = query.Where("category_id = ?", categoryID)
+	}
+	if status == models.VendorCategoryPending {
+		// Categories of vendors under review are approved with the vendor
+		query = query.Where("user_id IN (?)", database.DB.Model(&models.VendorOrganization{}).
+			Select("user_id").
+			Where("organization_id = ? AND status = ?", tenantID, models.VendorApproved))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch vendor categories", "", "", "", "", nil)
+		return
+	}
+
+	var vendorCategories []models.VendorCategory
+	offset := (page - 1) * limit
+	if err := query.Preload("User").Preload("Category").
+		Order("created_at ASC").Offset(offset).Limit(limit).
+		Find(&vendorCategories).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch vendor categories", "", "", "", "", nil)
+		return
+	}
+
+	pagination := Pagination{
+		CurrentPage: page,
+		PerPage:     limit,
+		Total:       total,
+		TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+	}
+
+	respondWithPagination(w, 200, "Vendor categories retrieved successfully", vendorCategories, pagination)
+}
+
+// ApproveVendorCategory lets an approved vendor receive RFPs of a category they added
+func (cc *VendorCategoryController) ApproveVendorCategory(w http.ResponseWriter, r *http.Request) {
+	cc.reviewVendorCategory(w, r, true)
+}
+
+// RejectVendorCategory refuses a category an approved vendor added
+func (cc *VendorCategoryController) RejectVendorCategory(w http.ResponseWriter, r *http.Request) {
+	cc.reviewVendorCategory(w, r, false)
+}
+
+// GetCategoryVendors lists the approved vendors approved for a category, for
+// internal services inviting vendors to RFPs. The optional tenant_id query
+// parameter makes sure the category belongs to the organization of the RFP.
+func (cc *VendorCategoryController) GetCategoryVendors(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	if tenantStr := r.URL.Query().Get("tenant_id"); tenantStr != "" {
+		tenantID, err := strconv.ParseUint(tenantStr, 10, 32)
+		if err != nil || tenantID == 0 {
+			respondWithJSON(w, 400, "Invalid tenant_id", "", "", "", "", nil)
+			return
+		}
+		var count int64
+		if err := database.DB.Model(&models.Category{}).
+			Where("id = ? AND tenant_id = ?", categoryID, tenantID).
+			Count(&count).Error; err != nil {
+			respondWithJSON(w, 500, "Failed to fetch vendors", "", "", "", "", nil)
+			return
+		}
+		if count == 0 {
+			respondWithJSON(w, 404, "Category not found", "", "", "", "", nil)
+			return
+		}
+	}
+
+	users, err := cc.vendorCategoryService.ApprovedVendors(categoryID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch vendors", "", "", "", "", nil)
+		return
+	}
+
+	userIDs := make([]uint, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	contactEmails, err := cc.vendorContactService.SubscribedEmails(categoryID, userIDs)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch vendors", "", "", "", "", nil)
+		return
+	}
+
+	vendors := make([]CategoryVendor, 0, len(users))
+	for _, user := range users {
+		vendor := CategoryVendor{
+			ID:        user.ID,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Email:     user.Email,
+		}
+		for _, email := range contactEmails[user.ID] {
+			if !strings.EqualFold(email, user.Email) {
+				vendor.ContactEmails = append(vendor.ContactEmails, email)
+			}
+		}
+		vendors = append(vendors, vendor)
+	}
+
+	respondWithJSON(w, 200, "Vendors retrieved successfully", "", "", "", "", vendors)
+}
@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+)
+
+// region validators
+type VendorContactController struct {
+	vendorContactService *services.VendorContactService
+	organizationService  *services.OrganizationService
+}
+
+type VendorContactRequest struct {
+	Name        string `json:"name" validate:"required,max=150"`
+	Email       string `json:"email" validate:"required,email,max=255"`
+	Phone       string `json:"phone" validate:"omitempty,max=20"`
+	Role        string `json:"role" validate:"required"`
+	IsPrimary   *bool  `json:"is_primary"`
+	CategoryIDs []uint `json:"category_ids" validate:"omitempty,max=20"`
+}
+
+type VendorAddressRequest struct {
+	Type       string `json:"type" validate:"required"`
+	Label      string `json:"label" validate:"omitempty,max=100"`
+	Line1      string `json:"line1" validate:"required,max=255"`
+	Line2      string `json:"line2" validate:"omitempty,max=255"`
+	City       string `json:"city" validate:"required,max=100"`
+	State      string `json:"state" validate:"omitempty,max=100"`
+	PostalCode string `json:"postal_code" validate:"omitempty,max=20"`
+	Country    string `json:"country" validate:"required,max=100"`
+	IsPrimary  *bool  `json:"is_primary"`
+}
+
+// endregion validators
+
+// region helpers
+func NewVendorContactController() *VendorContactController {
+	return &VendorContactController{
+		vendorContactService: services.NewVendorContactService(),
+		organizationService:  services.NewOrganizationService(),
+	}
+}
+
+func (req VendorContactRequest) input() services.VendorContactInput {
+	return services.VendorContactInput{
+		Name:        req.Name,
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Role:        models.ContactRole(req.Role),
+		IsPrimary:   req.IsPrimary,
+		CategoryIDs: req.CategoryIDs,
+	}
+}
+
+func (req VendorAddressRequest) input() services.VendorAddressInput {
+	return services.VendorAddressInput{
+		Type:       models.AddressType(req.Type),
+		Label:      req.Label,
+		Line1:      req.Line1,
+		Line2:      req.Line2,
+		City:       req.City,
+		State:      req.State,
+		PostalCode: req.PostalCode,
+		Country:    req.Country,
+		IsPrimary:  req.IsPrimary,
+	}
+}
+
+func decodeContactRequest(w http.ResponseWriter, r *http.Request) (*VendorContactRequest, bool) {
+	var req VendorContactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return nil, false
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return nil, false
+	}
+	if !models.IsValidContactRole(models.ContactRole(req.Role)) {
+		respondWithJSON(w, 400, services.ErrInvalidContactRole.Error(), "", "", "", "", map[string]interface{}{
+			"allowed_roles": models.ContactRoles,
+		})
+		return nil, false
+	}
+	return &req, true
+}
+
+func decodeAddressRequest(w http.ResponseWriter, r *http.Request) (*VendorAddressRequest, bool) {
+	var req VendorAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return nil, false
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return nil, false
+	}
+	if !models.IsValidAddressType(models.AddressType(req.Type)) {
+		respondWithJSON(w, 400, services.ErrInvalidAddressType.Error(), "", "", "", "", map[string]interface{}{
+			"allowed_types": models.AddressTypes,
+		})
+		return nil, false
+	}
+	return &req, true
+}
+
+// respondContactError answers the errors shared by the contact and address endpoints
+func respondContactError(w http.ResponseWriter, err error, userID uint, action string) {
+	switch {
+	case errors.Is(err, services.ErrContactNotFound), errors.Is(err, services.ErrAddressNotFound),
+		errors.Is(err, services.ErrUserNotFound):
+		respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+	case errors.Is(err, services.ErrInvalidContactRole), errors.Is(err, services.ErrInvalidAddressType),
+		errors.Is(err, services.ErrContactCategory), errors.Is(err, services.ErrVendorCategoryLimitExceeded),
+		errors.Is(err, services.ErrContactLimitExceeded), errors.Is(err, services.ErrAddressLimitExceeded):
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+	case errors.Is(err, services.ErrPrimaryRequired):
+		respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+	default:
+		log.Printf("Failed to %s of vendor %d: %v", action, userID, err)
+		respondWithJSON(w, 500, "Failed to "+action, "", "", "", "", nil)
+	}
+}
+
+// endregion helpers
+
+// ListContacts lists the contacts of the logged in vendor with the categories
+// each one is subscribed to
+func (vc *VendorContactController) ListContacts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	contacts, err := vc.vendorContactService.ListContacts(userID, 0)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch contacts", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Contacts retrieved successfully", "", "", "", "", contacts)
+}
+
+// CreateContact adds a contact to the logged in vendor. The contact gets the RFPs
+// of the categories in category_ids, which must be categories the vendor serves.
+func (vc *VendorContactController) CreateContact(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	req, ok := decodeContactRequest(w, r)
+	if !ok {
+		return
+	}
+
+	contact, err := vc.vendorContactService.CreateContact(userID, req.input())
+	if err != nil {
+		respondContactError(w, err, userID, "create contact")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, "Contact created successfully", "", "", "", "", contact)
+}
+
+// UpdateContact replaces a contact of the logged in vendor, including its subscriptions
+func (vc *VendorContactController) UpdateContact(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	contactID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	req, ok := decodeContactRequest(w, r)
+	if !ok {
+		return
+	}
+
+	contact, err := vc.vendorContactService.UpdateContact(contactID, userID, req.input())
+	if err != nil {
+		respondContactError(w, err, userID, "update contact")
+		return
+	}
+
+	respondWithJSON(w, 200, "Contact updated successfully", "", "", "", "", contact)
+}
+
+// DeleteContact removes a contact of the logged in vendor
+func (vc *VendorContactController) DeleteContact(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	contactID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	if err := vc.vendorContactService.DeleteContact(contactID, userID); err != nil {
+		respondContactError(w, err, userID, "delete contact")
+		return
+	}
+
+	respondWithJSON(w, 200, "Contact deleted successfully", "", "", "", "", nil)
+}
+
+// ListAddresses lists the billing and shipping addresses of the logged in vendor
+func (vc *VendorContactController) ListAddresses(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	addresses, err := vc.vendorContactService.ListAddresses(userID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch addresses", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Addresses retrieved successfully", "", "", "", "", addresses)
+}
+
+// CreateAddress adds a billing or shipping address to the logged in vendor
+func (vc *VendorContactController) CreateAddress(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	req, ok := decodeAddressRequest(w, r)
+	if !ok {
+		return
+	}
+
+	address, err := vc.vendorContactService.CreateAddress(userID, req.input())
+	if err != nil {
+		respondContactError(w, err, userID, "create address")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, "Address created successfully", "", "", "", "", address)
+}
+
+// UpdateAddress replaces an address of the logged in vendor
+func (vc *VendorContactController) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	addressID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	req, ok := decodeAddressRequest(w, r)
+	if !ok {
+		return
+	}
+
+	address, err := vc.vendorContactService.UpdateAddress(addressID, userID, req.input())
+	if err != nil {
+		respondContactError(w, err, userID, "update address")
+		return
+	}
+
+	respondWithJSON(w, 200, "Address updated successfully", "", "", "", "", address)
+}
+
+// DeleteAddress removes an address of the logged in vendor
+func (vc *VendorContactController) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	addressID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	if err := vc.vendorContactService.DeleteAddress(addressID, userID); err != nil {
+		respondContactError(w, err, userID, "delete address")
+		return
+	}
+
+	respondWithJSON(w, 200, "Address deleted successfully", "", "", "", "", nil)
+}
+
+// ListVendorContacts lists the contacts of a vendor of the admin's organization,
+// with their subscriptions to the categories of that organization
+func (vc *VendorContactController) ListVendorContacts(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+	tenantID, ok := requireTenantMember(w, r, vc.organizationService, vendorID)
+	if !ok {
+		return
+	}
+
+	contacts, err := vc.vendorContactService.ListContacts(vendorID, tenantID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch contacts", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Contacts retrieved successfully", "", "", "", "", contacts)
+}
+
+// ListVendorAddresses lists the addresses of a vendor of the admin's organization
+func (vc *VendorContactController) ListVendorAddresses(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+	if _, ok := requireTenantMember(w, r, vc.organizationService, vendorID); !ok {
+		return
+	}
+
+	addresses, err := vc.vendorContactService.ListAddresses(vendorID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch addresses", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Addresses retrieved successfully", "", "", "", "", addresses)
+}
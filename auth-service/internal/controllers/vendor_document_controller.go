@@ -0,0 +1,416 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"gorm.io/gorm"
+)
+
+// region validators
+type VendorDocumentController struct {
+	notificationService   *services.NotificationService
+	vendorDocumentService *services.VendorDocumentService
+	organizationService   *services.OrganizationService
+}
+
+type ReviewDocumentRequest struct {
+	Notes string `json:"notes"`
+}
+
+// VendorDocumentsResponse lists the documents of a vendor with what is still needed for approval
+type VendorDocumentsResponse struct {
+	Documents        []models.VendorDocument `json:"documents"`
+	RequiredTypes    []models.DocumentType   `json:"required_types"`
+	MissingTypes     []models.DocumentType   `json:"missing_types"`
+	PendingDocuments int64                   `json:"pending_documents"`
+}
+
+// endregion validators
+
+// region helpers
+func NewVendorDocumentController() *VendorDocumentController {
+	return &VendorDocumentController{
+		notificationService:   services.NewNotificationService(),
+		vendorDocumentService: services.NewVendorDocumentService(),
+		organizationService:   services.NewOrganizationService(),
+	}
+}
+
+func (dc *VendorDocumentController) documentsResponse(userID uint) (*VendorDocumentsResponse, error) {
+	documents, err := dc.vendorDocumentService.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	missing, pending, err := dc.vendorDocumentService.MissingForApproval(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VendorDocumentsResponse{
+		Documents:        documents,
+		RequiredTypes:    services.RequiredDocumentTypes(),
+		MissingTypes:     missing,
+		PendingDocuments: pending,
+	}, nil
+}
+
+// serveDocument streams the file of a document as a download
+func (dc *VendorDocumentController) serveDocument(w http.ResponseWriter, document *models.VendorDocument) {
+	file, err := dc.vendorDocumentService.Open(document)
+	if err != nil {
+		if errors.Is(err, services.ErrDocumentNotStored) {
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to read document", "", "", "", "", nil)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", document.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(document.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": document.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Failed to send document %d: %v", document.ID, err)
+	}
+}
+
+func (dc *VendorDocumentController) reviewDocument(w http.ResponseWriter, r *http.Request, approve bool) {
+	documentID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	var req ReviewDocumentRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+			return
+		}
+	}
+
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	document, err := dc.vendorDocumentService.Review(tenantID, documentID, actorID, approve, req.Notes)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDocumentNotFound):
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+		case errors.Is(err, services.ErrDocumentNotPending):
+			respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+		default:
+			respondWithJSON(w, 500, "Failed to review document", "", "", "", "", nil)
+		}
+		return
+	}
+
+	action := "rejected"
+	if approve {
+		action = "approved"
+	}
+
+	// Only rejections are mailed, the vendor has to upload a replacement
+	if !approve {
+		var user models.User
+		if err := database.DB.First(&user, document.UserID).Error; err == nil {
+			fullName := user.FirstName + " " + user.LastName
+			content := fmt.Sprintf(`
+		Hi %s,
+
+		Your document %s (%s) has been rejected, please upload a new one.
+		%s
+	`, fullName, document.FileName, document.Type, req.Notes)
+			dc.notificationService.SendTenantEmail(tenantID, user.Email, "Document rejected", content)
+		}
+	}
+
+	respondWithJSON(w, 200, fmt.Sprintf("Document %s successfully", action), "", "", "", "", document)
+}
+
+// endregion helpers
+
+// UploadDocument stores a KYC document of the logged in vendor. It expects a
+// multipart form with the document type in "type" and the file in "file".
+func (dc *VendorDocumentController) UploadDocument(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	// Room for the other form fields on top of the file, the exact file size is
+	// checked while storing it
+	maxSize := dc.vendorDocumentService.MaxDocumentSize()
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithJSON(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Document must not be larger than %d MB", maxSize>>20), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 400, "Request must be a multipart form", "", "", "", "", nil)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	docType := models.DocumentType(r.FormValue("type"))
+	if !models.IsValidDocumentType(docType) {
+		respondWithJSON(w, 400, services.ErrInvalidDocumentType.Error(), "", "", "", "", map[string]interface{}{
+			"allowed_types": models.DocumentTypes,
+		})
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondWithJSON(w, 400, "File is required", "", "", "", "", nil)
+		return
+	}
+	defer file.Close()
+
+	document, err := dc.vendorDocumentService.Upload(userID, docType, header.Filename, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDocumentTooLarge):
+			respondWithJSON(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Document must not be larger than %d MB", maxSize>>20), "", "", "", "", nil)
+		case errors.Is(err, services.ErrUnsupportedDocument):
+			respondWithJSON(w, http.StatusUnsupportedMediaType, err.Error(), "", "", "", "", nil)
+		case errors.Is(err, services.ErrDocumentEmpty), errors.Is(err, services.ErrInvalidDocumentType):
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		default:
+			log.Printf("Failed to store document of vendor %d: %v", userID, err)
+			respondWithJSON(w, 500, "Failed to upload document", "", "", "", "", nil)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, "Document uploaded, it will be reviewed by an admin", "", "", "", "", document)
+}
+
+// ListDocuments lists the documents of the logged in vendor
+func (dc *VendorDocumentController) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	response, err := dc.documentsResponse(userID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch documents", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Documents retrieved successfully", "", "", "", "", response)
+}
+
+// DownloadDocument returns the file of a document of the logged in vendor
+func (dc *VendorDocumentController) DownloadDocument(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	documentID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	document, err := dc.vendorDocumentService.Get(documentID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrDocumentNotFound) {
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to fetch document", "", "", "", "", nil)
+		return
+	}
+
+	dc.serveDocument(w, document)
+}
+
+// DeleteDocument removes a document of the logged in vendor that is not approved
+func (dc *VendorDocumentController) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return
+	}
+
+	documentID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	if err := dc.vendorDocumentService.Delete(documentID, userID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrDocumentNotFound):
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+		case errors.Is(err, services.ErrApprovedDocumentDeleted):
+			respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+		default:
+			respondWithJSON(w, 500, "Failed to delete document", "", "", "", "", nil)
+		}
+		return
+	}
+
+	respondWithJSON(w, 200, "Document deleted successfully", "", "", "", "", nil)
+}
+
+// ListReviewQueue lists documents of the vendors of the admin's organization,
+// pending ones by default
+func (dc *VendorDocumentController) ListReviewQueue(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	pageStr := r.URL.Query().Get("page")
+	limitStr := r.URL.Query().Get("limit")
+
+	page := 1
+	limit := 20
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	status := models.DocumentStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = models.DocumentPending
+	}
+
+	query := database.DB.Model(&models.VendorDocument{}).
+		Where("status = ?", status).
+		Where("user_id IN (?)", database.DB.Model(&models.VendorOrganization{}).Select("user_id").Where("organization_id = ?", tenantID))
+	if docType := r.URL.Query().Get("type"); docType != "" {
+		query = query.Where("type = ?", docType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch documents", "", "", "", "", nil)
+		return
+	}
+
+	var documents []models.VendorDocument
+	offset := (page - 1) * limit
+	if err := query.Order("created_at ASC").Offset(offset).Limit(limit).Find(&documents).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch documents", "", "", "", "", nil)
+		return
+	}
+
+	pagination := Pagination{
+		CurrentPage: page,
+		PerPage:     limit,
+		Total:       total,
+		TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+	}
+
+	respondWithPagination(w, 200, "Documents retrieved successfully", documents, pagination)
+}
+
+// ListVendorDocuments lists the documents of a vendor of the admin's organization
+func (dc *VendorDocumentController) ListVendorDocuments(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+	if _, ok := requireTenantMember(w, r, dc.organizationService, vendorID); !ok {
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ? AND role = ?", vendorID, models.RoleVendor).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondWithJSON(w, 404, "Vendor not found", "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to fetch vendor", "", "", "", "", nil)
+		return
+	}
+
+	response, err := dc.documentsResponse(vendorID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch documents", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Documents retrieved successfully", "", "", "", "", response)
+}
+
+// DownloadVendorDocument returns the file of a document of any vendor of the
+// admin's organization
+func (dc *VendorDocumentController) DownloadVendorDocument(w http.ResponseWriter, r *http.Request) {
+	documentID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	document, err := dc.vendorDocumentService.Get(documentID, 0)
+	if err != nil {
+		if errors.Is(err, services.ErrDocumentNotFound) {
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to fetch document", "", "", "", "", nil)
+		return
+	}
+
+	member, err := dc.organizationService.HasMember(tenantID, document.UserID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch document", "", "", "", "", nil)
+		return
+	}
+	if !member {
+		respondWithJSON(w, 404, services.ErrDocumentNotFound.Error(), "", "", "", "", nil)
+		return
+	}
+
+	dc.serveDocument(w, document)
+}
+
+// ApproveDocument accepts a vendor document
+func (dc *VendorDocumentController) ApproveDocument(w http.ResponseWriter, r *http.Request) {
+	dc.reviewDocument(w, r, true)
+}
+
+// RejectDocument refuses a vendor document, the vendor is asked to upload a new one
+func (dc *VendorDocumentController) RejectDocument(w http.ResponseWriter, r *http.Request) {
+	dc.reviewDocument(w, r, false)
+}
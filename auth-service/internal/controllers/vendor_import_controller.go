@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/config"
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+// maxImportFileSize bounds the size of an uploaded vendor list
+const maxImportFileSize = 5 << 20
+
+// region validators
+type VendorImportController struct {
+	notificationService *services.NotificationService
+	verificationService *services.VerificationService
+	vendorImportService *services.VendorImportService
+}
+
+// endregion validators
+
+// region helpers
+func NewVendorImportController() *VendorImportController {
+	return &VendorImportController{
+		notificationService: services.NewNotificationService(),
+		verificationService: services.NewVerificationService(),
+		vendorImportService: services.NewVendorImportService(),
+	}
+}
+
+// sendInvitations emails every vendor imported by the organization tenantID a
+// link to set their password
+func (ic *VendorImportController) sendInvitations(tenantID uint, users []models.User) {
+	cfg := config.Load()
+	ttl := time.Duration(cfg.VendorInviteTTLHours) * time.Hour
+
+	for _, user := range users {
+		token, err := ic.verificationService.Issue(user.ID, models.VerificationPurposeSetPassword, ttl)
+		if err != nil {
+			log.Printf("Failed to issue invitation of imported vendor %d: %v", user.ID, err)
+			continue
+		}
+
+		link := strings.TrimSuffix(cfg.FrontendURL, "/") + "/set-password?token=" + token
+		subject := "You have been invited to the vendor portal"
+		content := fmt.Sprintf(`
+		Hi %s,
+
+		An account has been created for you on our vendor portal.
+
+		Please choose your password by opening the link below:
+
+		%s
+
+		The link expires in %d hours. Once your password is set you can log in and
+		upload the documents needed to approve your account.
+	`, user.FirstName+" "+user.LastName, link, cfg.VendorInviteTTLHours)
+		if err := ic.notificationService.SendTenantEmail(tenantID, user.Email, subject, content); err != nil {
+			log.Printf("Failed to send invitation to imported vendor %d: %v", user.ID, err)
+		}
+	}
+}
+
+// endregion helpers
+
+// ImportVendors validates a CSV or XLSX list of vendors sent as "file" in a
+// multipart form. Nothing is created unless commit=true is given and every row
+// is valid; the imported vendors join the admin's organization and are invited
+// by email to set their password.
+func (ic *VendorImportController) ImportVendors(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithJSON(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("File must not be larger than %d MB", maxImportFileSize>>20), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 400, "Request must be a multipart form", "", "", "", "", nil)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	commit := r.FormValue("commit") == "true"
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondWithJSON(w, 400, "File is required", "", "", "", "", nil)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxImportFileSize {
+		respondWithJSON(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("File must not be larger than %d MB", maxImportFileSize>>20), "", "", "", "", nil)
+		return
+	}
+
+	rows, rowErrors, err := ic.vendorImportService.Parse(tenantID, file, header.Size, header.Filename)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnsupportedImportFile):
+			respondWithJSON(w, http.StatusUnsupportedMediaType, err.Error(), "", "", "", "", nil)
+		case errors.Is(err, services.ErrImportFileEmpty),
+			errors.Is(err, services.ErrImportTooManyRows),
+			errors.Is(err, services.ErrImportMissingColumns),
+			errors.Is(err, services.ErrInvalidCSV),
+			errors.Is(err, utils.ErrInvalidXLSX):
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		default:
+			respondWithJSON(w, 500, "Failed to read file", "", "", "", "", nil)
+		}
+		return
+	}
+
+	duplicates, err := ic.vendorImportService.CheckDuplicates(rows)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to validate vendors", "", "", "", "", nil)
+		return
+	}
+	rowErrors = append(rowErrors, duplicates...)
+	sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+
+	invalid := make(map[int]bool, len(rowErrors))
+	for _, rowError := range rowErrors {
+		invalid[rowError.Row] = true
+	}
+
+	report := services.VendorImportReport{
+		DryRun:    true,
+		TotalRows: len(rows),
+		ValidRows: len(rows) - len(invalid),
+		Errors:    rowErrors,
+	}
+	if report.Errors == nil {
+		report.Errors = []services.ImportRowError{}
+	}
+
+	if !commit {
+		respondWithJSON(w, 200, "Dry run completed, nothing was imported", "", "", "", "", report)
+		return
+	}
+	if len(rowErrors) > 0 {
+		respondWithJSON(w, 400, "File has errors, nothing was imported", "", "", "", "", report)
+		return
+	}
+
+	report.DryRun = false
+	var created []models.User
+	for start := 0; start < len(rows); start += services.ImportBatchSize {
+		end := start + services.ImportBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		var users []models.User
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			users, err = ic.vendorImportService.CreateBatch(tx, tenantID, rows[start:end])
+			if err != nil {
+				return err
+			}
+			for _, user := range users {
+				if err := assignDefaultVendorPermissions(tx, user.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			// Earlier batches stay imported, their vendors are still invited
+			log.Printf("Failed to import vendors of rows %d-%d: %v", rows[start].Row, rows[end-1].Row, err)
+			go ic.sendInvitations(tenantID, created)
+			report.Created = len(created)
+			respondWithJSON(w, 500, fmt.Sprintf("Import stopped at row %d, %d vendors were imported", rows[start].Row, len(created)), "", "", "", "", report)
+			return
+		}
+		created = append(created, users...)
+	}
+	report.Created = len(created)
+
+	go ic.sendInvitations(tenantID, created)
+
+	respondWithJSON(w, http.StatusCreated, fmt.Sprintf("%d vendors imported, invitations are being sent", len(created)), "", "", "", "", report)
+}
@@ -0,0 +1,394 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+// region validators
+type VendorProfileController struct {
+	notificationService   *services.NotificationService
+	vendorProfileService  *services.VendorProfileService
+	vendorCategoryService *services.VendorCategoryService
+}
+
+// UpdateVendorProfileRequest uses the field names of vendor registration.
+// Only the fields present are changed.
+type UpdateVendorProfileRequest struct {
+	FirstName     *string  `json:"firstname" validate:"omitempty,min=1,max=100"`
+	LastName      *string  `json:"lastname" validate:"omitempty,min=1,max=100"`
+	PhoneNo       *string  `json:"mobile" validate:"omitempty,max=15"`
+	Revenue       *float64 `json:"revenue" validate:"omitempty,min=0"`
+	EmployeeCount *int     `json:"no_of_employees" validate:"omitempty,min=0"`
+	CategoryIDs   []uint   `json:"categories" validate:"omitempty,max=20"` // replaces the categories, new ones are reviewed
+	GSTNo         *string  `json:"gst_no" validate:"omitempty,gstin,gstin_pan=PANNo"`
+	PANNo         *string  `json:"pancard_no" validate:"omitempty,pan"`
+}
+
+type ReviewProfileChangeRequest struct {
+	Notes string `json:"notes"`
+}
+
+// VendorProfileResponse is the vendor's own profile with the change awaiting verification, if any
+type VendorProfileResponse struct {
+	User          models.User                 `json:"user"`
+	PendingChange *models.VendorProfileChange `json:"pending_change"`
+}
+
+// endregion validators
+
+// region helpers
+func NewVendorProfileController() *VendorProfileController {
+	return &VendorProfileController{
+		notificationService:   services.NewNotificationService(),
+		vendorProfileService:  services.NewVendorProfileService(),
+		vendorCategoryService: services.NewVendorCategoryService(),
+	}
+}
+
+// loadVendor returns the vendor with their details and the categories they serve for
+// the organization they logged in to, writing the error response when it fails
+func (vc *VendorProfileController) loadVendor(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	userID, ok := middleware.GetUserIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, 401, "Unauthorized", "", "", "", "", nil)
+		return nil, false
+	}
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	var user models.User
+	if err := vendorProfileQuery(tenantID).
+		Where("id = ? AND role = ?", userID, models.RoleVendor).
+		First(&user).Error; err != nil {
+		respondWithJSON(w, 404, "Vendor not found", "", "", "", "", nil)
+		return nil, false
+	}
+
+	if user.VendorDetails == nil {
+		respondWithJSON(w, 404, "Vendor details not found", "", "", "", "", nil)
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// vendorProfileQuery preloads the vendor details with the categories and membership
+// of the organization orgID
+func vendorProfileQuery(orgID uint) *gorm.DB {
+	return database.DB.
+		Preload("VendorDetails.Categories", "category_id IN (?)", database.DB.Model(&models.Category{}).Select("id").Where("tenant_id = ?", orgID)).
+		Preload("VendorDetails.Categories.Category").
+		Preload("VendorDetails.Organizations", "organization_id = ?", orgID).
+		Preload("VendorDetails.Organizations.Organization")
+}
+
+func (vc *VendorProfileController) reviewProfileChange(w http.ResponseWriter, r *http.Request, approve bool) {
+	changeID, err := parseIDParam(r, "id")
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	var req ReviewProfileChangeRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+			return
+		}
+	}
+
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+	actorID, _ := middleware.GetUserIDFromContext(r)
+	change, err := vc.vendorProfileService.Review(tenantID, changeID, actorID, approve, req.Notes)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrProfileChangeNotFound):
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+		case errors.Is(err, services.ErrProfileChangeNotPending), errors.Is(err, services.ErrDuplicateGSTIN):
+			respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+		default:
+			respondWithJSON(w, 500, "Failed to review profile change", "", "", "", "", nil)
+		}
+		return
+	}
+
+	action := "rejected"
+	if approve {
+		action = "approved"
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, change.UserID).Error; err == nil {
+		fullName := user.FirstName + " " + user.LastName
+		content := fmt.Sprintf(`
+		Hi %s,
+
+		Your request to change your GST/PAN details has been %s.
+		%s
+	`, fullName, action, req.Notes)
+		vc.notificationService.SendTenantEmail(tenantID, user.Email, "Profile change "+action, content)
+	}
+
+	respondWithJSON(w, 200, fmt.Sprintf("Profile change %s successfully", action), "", "", "", "", change)
+}
+
+// endregion helpers
+
+// GetProfile returns the profile of the logged in vendor
+func (vc *VendorProfileController) GetProfile(w http.ResponseWriter, r *http.Request) {
+	user, ok := vc.loadVendor(w, r)
+	if !ok {
+		return
+	}
+
+	pending, err := vc.vendorProfileService.PendingChange(user.ID)
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to fetch profile", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Profile retrieved successfully", "", "", "", "", VendorProfileResponse{
+		User:          *user,
+		PendingChange: pending,
+	})
+}
+
+// UpdateProfile changes the contact details, company details and categories of the
+// logged in vendor. New GST/PAN values only take effect once an admin verified them,
+// new categories of an approved vendor once an admin reviewed them. Categories are
+// those of the organization the vendor logged in to.
+func (vc *VendorProfileController) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	user, ok := vc.loadVendor(w, r)
+	if !ok {
+		return
+	}
+	tenantID, _ := middleware.GetTenantIDFromContext(r)
+
+	var req UpdateVendorProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithJSON(w, 400, "Invalid request format", "", "", "", "", nil)
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	details := user.VendorDetails
+
+	if req.CategoryIDs != nil {
+		if err := vc.vendorCategoryService.CheckCategories(tenantID, req.CategoryIDs); err != nil {
+			if errors.Is(err, services.ErrInvalidCategory) || errors.Is(err, services.ErrNoCategories) ||
+				errors.Is(err, services.ErrVendorCategoryLimitExceeded) {
+				respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+				return
+			}
+			respondWithJSON(w, 500, "Failed to check category", "", "", "", "", nil)
+			return
+		}
+	}
+
+	userUpdates := map[string]interface{}{}
+	if req.FirstName != nil {
+		userUpdates["first_name"] = strings.TrimSpace(*req.FirstName)
+	}
+	if req.LastName != nil {
+		userUpdates["last_name"] = strings.TrimSpace(*req.LastName)
+	}
+
+	detailUpdates := map[string]interface{}{}
+	if req.PhoneNo != nil {
+		detailUpdates["phone_no"] = strings.TrimSpace(*req.PhoneNo)
+	}
+	if req.Revenue != nil {
+		detailUpdates["revenue"] = *req.Revenue
+	}
+	if req.EmployeeCount != nil {
+		detailUpdates["no_of_employee"] = *req.EmployeeCount
+	}
+
+	// Regulated fields are compared with the verified values
+	gstNo, panNo := details.GSTNo, details.PANNo
+	if req.GSTNo != nil {
+		gstNo = utils.NormalizeTaxID(*req.GSTNo)
+	}
+	if req.PANNo != nil {
+		panNo = utils.NormalizeTaxID(*req.PANNo)
+	}
+	regulatedChanged := gstNo != details.GSTNo || panNo != details.PANNo
+
+	if regulatedChanged {
+		// Only one of the two may have been sent, so check them together as well
+		if gstNo != "" && panNo != "" && utils.PANFromGSTIN(gstNo) != panNo {
+			respondWithJSON(w, 400, "GSTIN does not contain the PAN of the vendor", "", "", "", "", nil)
+			return
+		}
+		if gstNo != details.GSTNo {
+			if err := vc.vendorProfileService.CheckGSTINAvailable(database.DB, gstNo, user.ID); err != nil {
+				if errors.Is(err, services.ErrDuplicateGSTIN) {
+					respondWithJSON(w, 409, err.Error(), "", "", "", "", nil)
+					return
+				}
+				respondWithJSON(w, 500, "Failed to check GSTIN", "", "", "", "", nil)
+				return
+			}
+		}
+	}
+
+	var pending *models.VendorProfileChange
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(userUpdates) > 0 {
+			if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(userUpdates).Error; err != nil {
+				return err
+			}
+		}
+		if len(detailUpdates) > 0 {
+			if err := tx.Model(&models.VendorDetails{}).Where("id = ?", details.ID).Updates(detailUpdates).Error; err != nil {
+				return err
+			}
+		}
+		if req.CategoryIDs != nil {
+			if err := vc.vendorCategoryService.SetCategories(tx, tenantID, user.ID, req.CategoryIDs); err != nil {
+				return err
+			}
+		}
+		if regulatedChanged {
+			var err error
+			pending, err = vc.vendorProfileService.RequestChange(tx, details, gstNo, panNo)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithJSON(w, 500, "Failed to update profile", "", "", "", "", nil)
+		return
+	}
+
+	if err := vendorProfileQuery(tenantID).First(user, user.ID).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch profile", "", "", "", "", nil)
+		return
+	}
+	if pending == nil {
+		if pending, err = vc.vendorProfileService.PendingChange(user.ID); err != nil {
+			respondWithJSON(w, 500, "Failed to fetch profile", "", "", "", "", nil)
+			return
+		}
+	}
+
+	message := "Profile updated successfully"
+	if regulatedChanged {
+		message = "Profile updated, the GST/PAN change is pending verification by an admin"
+	}
+	respondWithJSON(w, 200, message, "", "", "", "", VendorProfileResponse{
+		User:          *user,
+		PendingChange: pending,
+	})
+}
+
+// CancelPendingChange withdraws the GST/PAN change of the logged in vendor
+func (vc *VendorProfileController) CancelPendingChange(w http.ResponseWriter, r *http.Request) {
+	user, ok := vc.loadVendor(w, r)
+	if !ok {
+		return
+	}
+
+	if err := vc.vendorProfileService.CancelPendingChange(user.ID); err != nil {
+		if errors.Is(err, services.ErrNoPendingProfileChange) {
+			respondWithJSON(w, 404, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to cancel profile change", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Profile change cancelled successfully", "", "", "", "", nil)
+}
+
+// ListProfileChanges lists GST/PAN changes of the vendors of the admin's
+// organization, pending ones by default
+func (vc *VendorProfileController) ListProfileChanges(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	pageStr := r.URL.Query().Get("page")
+	limitStr := r.URL.Query().Get("limit")
+
+	page := 1
+	limit := 20
+
+	if pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	status := models.ProfileChangeStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = models.ProfileChangePending
+	}
+
+	query := database.DB.Model(&models.VendorProfileChange{}).
+		Where("status = ?", status).
+		Where("user_id IN (?)", database.DB.Model(&models.VendorOrganization{}).Select("user_id").Where("organization_id = ?", tenantID))
+	if vendorID := r.URL.Query().Get("vendor_id"); vendorID != "" {
+		query = query.Where("user_id = ?", vendorID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch profile changes", "", "", "", "", nil)
+		return
+	}
+
+	var changes []models.VendorProfileChange
+	offset := (page - 1) * limit
+	if err := query.Preload("User").Order("created_at ASC").Offset(offset).Limit(limit).Find(&changes).Error; err != nil {
+		respondWithJSON(w, 500, "Failed to fetch profile changes", "", "", "", "", nil)
+		return
+	}
+
+	pagination := Pagination{
+		CurrentPage: page,
+		PerPage:     limit,
+		Total:       total,
+		TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+	}
+
+	respondWithPagination(w, 200, "Profile changes retrieved successfully", changes, pagination)
+}
+
+// ApproveProfileChange verifies a GST/PAN change and applies it to the vendor
+func (vc *VendorProfileController) ApproveProfileChange(w http.ResponseWriter, r *http.Request) {
+	vc.reviewProfileChange(w, r, true)
+}
+
+// RejectProfileChange refuses a GST/PAN change, the vendor keeps the verified values
+func (vc *VendorProfileController) RejectProfileChange(w http.ResponseWriter, r *http.Request) {
+	vc.reviewProfileChange(w, r, false)
+}
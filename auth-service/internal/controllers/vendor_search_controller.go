@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/services"
+)
+
+// region validators
+type VendorSearchController struct {
+	vendorSearchService *services.VendorSearchService
+}
+
+// endregion validators
+
+// region helpers
+func NewVendorSearchController() *VendorSearchController {
+	return &VendorSearchController{
+		vendorSearchService: services.NewVendorSearchService(),
+	}
+}
+
+// parseVendorSearchFilter reads the search and filters from the query string.
+// Lists such as category_id and status take comma separated values.
+func parseVendorSearchFilter(r *http.Request) (services.VendorSearchFilter, error) {
+	params := r.URL.Query()
+	filter := services.VendorSearchFilter{
+		Query:  params.Get("q"),
+		Sort:   params.Get("sort"),
+		Cursor: params.Get("cursor"),
+	}
+
+	for _, value := range splitListParam(params["category_id"]) {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || id == 0 {
+			return filter, errors.New("invalid category_id")
+		}
+		filter.CategoryIDs = append(filter.CategoryIDs, uint(id))
+	}
+
+	for _, value := range splitListParam(params["status"]) {
+		status := models.VendorStatus(value)
+		if !models.IsValidVendorStatus(status) {
+			return filter, services.ErrInvalidVendorStatus
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	var err error
+	if filter.MinRevenue, err = parseFloatParam(params, "revenue_min"); err != nil {
+		return filter, err
+	}
+	if filter.MaxRevenue, err = parseFloatParam(params, "revenue_max"); err != nil {
+		return filter, err
+	}
+	if filter.MinRevenue != nil && filter.MaxRevenue != nil && *filter.MinRevenue > *filter.MaxRevenue {
+		return filter, errors.New("revenue_min must not be greater than revenue_max")
+	}
+
+	if filter.MinEmployees, err = parseIntParam(params, "employees_min"); err != nil {
+		return filter, err
+	}
+	if filter.MaxEmployees, err = parseIntParam(params, "employees_max"); err != nil {
+		return filter, err
+	}
+	if filter.MinEmployees != nil && filter.MaxEmployees != nil && *filter.MinEmployees > *filter.MaxEmployees {
+		return filter, errors.New("employees_min must not be greater than employees_max")
+	}
+
+	if filter.RegisteredFrom, err = parseDateParam(params, "registered_from"); err != nil {
+		return filter, err
+	}
+	if filter.RegisteredTo, err = parseDateParam(params, "registered_to"); err != nil {
+		return filter, err
+	}
+	if filter.RegisteredTo != nil {
+		// registered_to includes the whole day
+		to := filter.RegisteredTo.AddDate(0, 0, 1)
+		filter.RegisteredTo = &to
+	}
+
+	if limitStr := params.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > services.MaxVendorSearchLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", services.MaxVendorSearchLimit)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// splitListParam splits the comma separated values of a repeatable query parameter
+func splitListParam(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func parseFloatParam(params map[string][]string, name string) (*float64, error) {
+	values := params[name]
+	if len(values) == 0 || values[0] == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(values[0], 64)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("%s must be a positive number", name)
+	}
+	return &value, nil
+}
+
+func parseIntParam(params map[string][]string, name string) (*int, error) {
+	values := params[name]
+	if len(values) == 0 || values[0] == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(values[0])
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return &value, nil
+}
+
+func parseDateParam(params map[string][]string, name string) (*time.Time, error) {
+	values := params[name]
+	if len(values) == 0 || values[0] == "" {
+		return nil, nil
+	}
+	value, err := time.Parse("2006-01-02", values[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a date in YYYY-MM-DD format", name)
+	}
+	return &value, nil
+}
+
+func (sc *VendorSearchController) search(w http.ResponseWriter, r *http.Request, tenantID uint) {
+	filter, err := parseVendorSearchFilter(r)
+	if err != nil {
+		respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+		return
+	}
+
+	result, err := sc.vendorSearchService.Search(tenantID, filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVendorSort) || errors.Is(err, services.ErrInvalidCursor) {
+			respondWithJSON(w, 400, err.Error(), "", "", "", "", nil)
+			return
+		}
+		respondWithJSON(w, 500, "Failed to search vendors", "", "", "", "", nil)
+		return
+	}
+
+	respondWithJSON(w, 200, "Vendors retrieved successfully", "", "", "", "", result)
+}
+
+// endregion helpers
+
+// SearchVendors searches the vendors of the admin's organization by name and email
+// with filters on category, status, revenue, employee count and registration date.
+// Pages are fetched with the next_cursor of the previous one; the first page also
+// counts the vendors per filter value for filter UIs.
+func (sc *VendorSearchController) SearchVendors(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := requireTenant(w, r)
+	if !ok {
+		return
+	}
+
+	sc.search(w, r, tenantID)
+}
+
+// SearchTenantVendors is SearchVendors for internal services, the organization
+// is given by the tenant_id query parameter
+func (sc *VendorSearchController) SearchTenantVendors(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := strconv.ParseUint(r.URL.Query().Get("tenant_id"), 10, 32)
+	if err != nil || tenantID == 0 {
+		respondWithJSON(w, 400, "Invalid tenant_id", "", "", "", "", nil)
+		return
+	}
+
+	sc.search(w, r, uint(tenantID))
+}
@@ -50,6 +50,25 @@ func InitDB(databaseURL string) (*gorm.DB, error) {
 	}
 	fmt.Println("✅ Database connection successful!")
 
+	// Users created before email verification existed are treated as verified
+	backfillEmailVerified := !DB.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt")
+
+	// Data created before organizations existed belongs to the default organization
+	introduceOrganizations := !DB.Migrator().HasTable(&models.Organization{})
+
+	// Vendor approval used to be global and stored on vendor_details
+	legacyVendorApproval := DB.Migrator().HasColumn(&models.VendorDetails{}, "is_approved")
+
+	// Reset OTPs used to be stored in plaintext, drop them along with the column
+	if DB.Migrator().HasColumn(&models.PasswordResetOTP{}, "otp") {
+		if err := DB.Exec("DELETE FROM password_reset_otps").Error; err != nil {
+			return nil, fmt.Errorf("failed to purge plaintext OTPs: %w", err)
+		}
+		if err := DB.Migrator().DropColumn(&models.PasswordResetOTP{}, "otp"); err != nil {
+			return nil, fmt.Errorf("failed to drop plaintext OTP column: %w", err)
+		}
+	}
+
 	// Auto migrate tables
 	fmt.Println("Running auto migrate...")
 	err = DB.AutoMigrate(
@@ -58,6 +77,30 @@ func InitDB(databaseURL string) (*gorm.DB, error) {
 		&models.Permission{},
 		&models.UserPermission{},
 		&models.PasswordResetOTP{},
+		&models.AccessRole{},
+		&models.PermissionAuditLog{},
+		&models.RefreshToken{},
+		&models.AdminInvite{},
+		&models.LoginThrottle{},
+		&models.UserMFA{},
+		&models.MFARecoveryCode{},
+		&models.UsedMFAChallenge{},
+		&models.VerificationToken{},
+		&models.RateLimit{},
+		&models.PasswordHistory{},
+		&models.UserSession{},
+		&models.OIDCIdentity{},
+		&models.OIDCLoginState{},
+		&models.ServiceClient{},
+		&models.VendorProfileChange{},
+		&models.VendorDocument{},
+		&models.VendorStatusHistory{},
+		&models.VendorCategory{},
+		&models.Organization{},
+		&models.VendorOrganization{},
+		&models.VendorContact{},
+		&models.VendorContactCategory{},
+		&models.VendorAddress{},
 	)
 
 	if err != nil {
@@ -65,6 +108,43 @@ func InitDB(databaseURL string) (*gorm.DB, error) {
 	}
 	fmt.Println("✅ Auto migration completed!")
 
+	if backfillEmailVerified {
+		result := DB.Model(&models.User{}).Where("email_verified_at IS NULL").Update("email_verified_at", gorm.Expr("created_at"))
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to backfill email verification: %w", result.Error)
+		}
+		fmt.Printf("  ✅ Marked %d existing users as verified\n", result.RowsAffected)
+	}
+
+	// Vendors used to serve a single category stored on vendor_details
+	if DB.Migrator().HasColumn(&models.VendorDetails{}, "category_id") {
+		if err := migrateVendorCategories(); err != nil {
+			return nil, fmt.Errorf("failed to migrate vendor categories: %w", err)
+		}
+	}
+
+	// Full-text search of vendors by name and email
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_users_search ON users USING GIN ((" + models.UserSearchDocument + "))").Error; err != nil {
+		return nil, fmt.Errorf("failed to create user search index: %w", err)
+	}
+
+	defaultOrg, err := seedDefaultOrganization()
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed default organization: %w", err)
+	}
+
+	if introduceOrganizations {
+		if err := backfillOrganizations(defaultOrg.ID); err != nil {
+			return nil, fmt.Errorf("failed to backfill organizations: %w", err)
+		}
+	}
+
+	if legacyVendorApproval {
+		if err := migrateVendorOrganizations(defaultOrg.ID); err != nil {
+			return nil, fmt.Errorf("failed to migrate vendor approvals: %w", err)
+		}
+	}
+
 	// Seed default permissions if needed
 	fmt.Println("Adding default permissions...")
 	if err := seedDefaultPermissions(); err != nil {
@@ -72,9 +152,132 @@ func InitDB(databaseURL string) (*gorm.DB, error) {
 	}
 	fmt.Println("✅ Default permissions seeded!")
 
+	fmt.Println("Adding default access roles...")
+	if err := seedDefaultAccessRoles(); err != nil {
+		return nil, fmt.Errorf("failed to seed access roles: %w", err)
+	}
+	fmt.Println("✅ Default access roles seeded!")
+
+	// Existing admins ran the only organization there was, they keep managing organizations.
+	// Admins onboarded later only get it when granted explicitly.
+	if introduceOrganizations {
+		if err := grantPermissionToAdmins("manage_organizations"); err != nil {
+			return nil, fmt.Errorf("failed to grant organization management: %w", err)
+		}
+	}
+
 	return DB, nil
 }
 
+// migrateVendorCategories moves vendor_details.category_id into vendor_categories.
+// The category of an approved vendor counts as approved.
+func migrateVendorCategories() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`
+			INSERT INTO vendor_categories (user_id, category_id, status, reviewed_by, reviewed_at, created_at, updated_at)
+			SELECT user_id, category_id,
+				CASE WHEN is_approved THEN ? ELSE ? END,
+				approved_by, approved_at, created_at, NOW()
+			FROM vendor_details
+			WHERE category_id IS NOT NULL AND category_id > 0
+			ON CONFLICT (user_id, category_id) DO NOTHING`,
+			models.VendorCategoryApproved, models.VendorCategoryPending)
+		if result.Error != nil {
+			return result.Error
+		}
+		fmt.Printf("  ✅ Moved %d vendor categories\n", result.RowsAffected)
+
+		return tx.Migrator().DropColumn(&models.VendorDetails{}, "category_id")
+	})
+}
+
+// seedDefaultOrganization creates the organization existing data is moved into
+func seedDefaultOrganization() (*models.Organization, error) {
+	org := models.Organization{Name: "Default", Slug: models.DefaultOrganizationSlug, IsActive: true}
+	if err := DB.Where("slug = ?", org.Slug).FirstOrCreate(&org).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// backfillOrganizations puts the admins and pending admin invites that predate
+// organizations into the default organization
+func backfillOrganizations(orgID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("role = ? AND organization_id IS NULL", models.RoleAdmin).
+			Update("organization_id", orgID)
+		if result.Error != nil {
+			return result.Error
+		}
+		fmt.Printf("  ✅ Moved %d admins into the default organization\n", result.RowsAffected)
+
+		return tx.Model(&models.AdminInvite{}).
+			Where("organization_id IS NULL").
+			Update("organization_id", orgID).Error
+	})
+}
+
+// migrateVendorOrganizations turns the global approval stored on vendor_details into
+// a membership of the default organization and drops the old columns. Databases that
+// predate the approval lifecycle only have is_approved.
+func migrateVendorOrganizations(orgID uint) error {
+	statusExpr := "CASE WHEN is_approved THEN 'approved' ELSE 'pending' END"
+	if DB.Migrator().HasColumn(&models.VendorDetails{}, "status") {
+		statusExpr = "COALESCE(NULLIF(status, ''), 'pending')"
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`
+			INSERT INTO vendor_organizations (user_id, organization_id, status, approved_by, approved_at, approval_notes, created_at, updated_at)
+			SELECT user_id, ?, `+statusExpr+`, approved_by, approved_at, COALESCE(approval_notes, ''), created_at, NOW()
+			FROM vendor_details
+			ON CONFLICT (user_id, organization_id) DO NOTHING`, orgID)
+		if result.Error != nil {
+			return result.Error
+		}
+		fmt.Printf("  ✅ Moved %d vendor approvals into the default organization\n", result.RowsAffected)
+
+		if err := tx.Model(&models.VendorStatusHistory{}).
+			Where("organization_id = 0").
+			Update("organization_id", orgID).Error; err != nil {
+			return err
+		}
+
+		for _, column := range []string{"status", "is_approved", "approved_by", "approved_at", "approval_notes"} {
+			if !tx.Migrator().HasColumn(&models.VendorDetails{}, column) {
+				continue
+			}
+			if err := tx.Migrator().DropColumn(&models.VendorDetails{}, column); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// grantPermissionToAdmins grants the named permission to every admin that lacks it
+func grantPermissionToAdmins(name string) error {
+	var permission models.Permission
+	if err := DB.Where("name = ?", name).First(&permission).Error; err != nil {
+		return err
+	}
+
+	result := DB.Exec(`
+		INSERT INTO user_permissions (user_id, permission_id, created_at, updated_at)
+		SELECT id, ?, NOW(), NOW() FROM users
+		WHERE role = ? AND NOT EXISTS (
+			SELECT 1 FROM user_permissions
+			WHERE user_permissions.user_id = users.id AND user_permissions.permission_id = ?
+		)`, permission.ID, models.RoleAdmin, permission.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	fmt.Printf("  ✅ Granted %s to %d admins\n", name, result.RowsAffected)
+
+	return nil
+}
+
 func seedDefaultPermissions() error {
 	permissions := []models.Permission{
 		{Name: "create_rfp", Description: "Create RFP", Resource: "rfp", Action: "create"},
@@ -87,6 +290,7 @@ func seedDefaultPermissions() error {
 		{Name: "delete_quote", Description: "Delete Quote", Resource: "quote", Action: "delete"},
 		{Name: "manage_users", Description: "Manage Users", Resource: "user", Action: "manage"},
 		{Name: "manage_categories", Description: "Manage Categories", Resource: "category", Action: "manage"},
+		{Name: "manage_organizations", Description: "Manage Organizations", Resource: "organization", Action: "manage"},
 	}
 
 	for _, permission := range permissions {
@@ -111,6 +315,85 @@ func seedDefaultPermissions() error {
 	return nil
 }
 
+// seedDefaultAccessRoles creates the permission bundles granted at registration.
+// Existing bundles are left untouched so admin changes survive restarts.
+func seedDefaultAccessRoles() error {
+	accessRoles := map[string][]string{
+		models.DefaultAdminAccessRole: {
+			"create_rfp", "read_rfp", "update_rfp", "delete_rfp",
+			"read_quote", "manage_users", "manage_categories",
+		},
+		models.DefaultVendorAccessRole: {
+			"read_rfp", "create_quote", "read_quote", "update_quote",
+		},
+	}
+
+	for name, permissionNames := range accessRoles {
+		var existingRole models.AccessRole
+		result := DB.Where("name = ?", name).First(&existingRole)
+		if result.Error == nil {
+			fmt.Printf("  ℹ️  Access role already exists: %s\n", name)
+			continue
+		}
+		if result.Error != gorm.ErrRecordNotFound {
+			return fmt.Errorf("failed to query access role %s: %w", name, result.Error)
+		}
+
+		var permissions []models.Permission
+		if err := DB.Where("name IN ?", permissionNames).Find(&permissions).Error; err != nil {
+			return fmt.Errorf("failed to load permissions for %s: %w", name, err)
+		}
+
+		accessRole := models.AccessRole{
+			Name:        name,
+			Description: "Granted by default at registration",
+			Permissions: permissions,
+		}
+		if err := DB.Create(&accessRole).Error; err != nil {
+			return fmt.Errorf("failed to create access role %s: %w", name, err)
+		}
+		fmt.Printf("  ✅ Created access role: %s\n", name)
+
+		// One-time backfill, revoking every permission of a vendor is a legitimate
+		// admin action afterwards and must not be undone on restart
+		if name == models.DefaultVendorAccessRole {
+			if err := backfillVendorPermissions(permissions); err != nil {
+				return fmt.Errorf("failed to backfill vendor permissions: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// backfillVendorPermissions grants the default vendor permissions to vendors
+// that registered before permissions were assigned at vendor registration
+func backfillVendorPermissions(permissions []models.Permission) error {
+	var vendorIDs []uint
+	if err := DB.Model(&models.User{}).
+		Where("role = ?", models.RoleVendor).
+		Where("NOT EXISTS (SELECT 1 FROM user_permissions WHERE user_permissions.user_id = users.id)").
+		Pluck("id", &vendorIDs).Error; err != nil {
+		return err
+	}
+	if len(vendorIDs) == 0 || len(permissions) == 0 {
+		return nil
+	}
+
+	var userPermissions []models.UserPermission
+	for _, vendorID := range vendorIDs {
+		for _, permission := range permissions {
+			userPermissions = append(userPermissions, models.UserPermission{UserID: vendorID, PermissionID: permission.ID})
+		}
+	}
+	if err := DB.Create(&userPermissions).Error; err != nil {
+		return err
+	}
+	fmt.Printf("  ✅ Granted default permissions to %d vendors\n", len(vendorIDs))
+
+	return nil
+}
+
 // Helper function to mask password in database URL for logging
 func maskPassword(databaseURL string) string {
 	// Simple masking - you might want to use regex for better parsing
@@ -2,19 +2,29 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
 	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
 )
 
 type contextKey string
 
 const (
-	UserIDKey   contextKey = "user_id"
-	UserRoleKey contextKey = "user_role"
+	UserIDKey    contextKey = "user_id"
+	UserRoleKey  contextKey = "user_role"
+	TokenTypeKey contextKey = "token_type"
+	SessionIDKey contextKey = "session_id"
+	TenantIDKey  contextKey = "tenant_id"
+	ClientIDKey  contextKey = "client_id"
 )
 
+const tokenWithoutTenant = `{"status": 401, "message": "Unauthorized: Token is not bound to an organization, please log in again"}`
+
 // AuthMiddleware validates JWT tokens and sets user context
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -28,21 +38,147 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		// Validate token
-		userID, role, err := utils.GetUserFromToken(tokenString)
+		claims, err := utils.ValidateToken(tokenString)
 		if err != nil {
 			http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid token"}`, http.StatusUnauthorized)
 			return
 		}
 
+		// Tokens issued before organizations existed cannot be scoped to one
+		if claims.TenantID == 0 {
+			http.Error(w, tokenWithoutTenant, http.StatusUnauthorized)
+			return
+		}
+
+		// Tokens of a logged out session are rejected before they expire
+		if status, message := checkSession(claims); status != http.StatusOK {
+			http.Error(w, message, status)
+			return
+		}
+
 		// Add user info to context
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		ctx = context.WithValue(ctx, UserRoleKey, role)
+		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+		ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
+		ctx = context.WithValue(ctx, TenantIDKey, claims.TenantID)
 
 		// Call next handler with updated context
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// MFAEnrollmentMiddleware accepts an access token or the enrollment token handed out
+// by Login when policy requires an admin to set up two-factor authentication first
+func MFAEnrollmentMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenString, err := utils.ExtractTokenFromHeader(r.Header.Get("Authorization"))
+		if err != nil {
+			http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid authorization header"}`, http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			claims, err = utils.ValidateMFAToken(tokenString, utils.TokenTypeMFAEnrollment)
+		}
+		if err != nil {
+			http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		if claims.TenantID == 0 {
+			http.Error(w, tokenWithoutTenant, http.StatusUnauthorized)
+			return
+		}
+
+		if status, message := checkSession(claims); status != http.StatusOK {
+			http.Error(w, message, status)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+		ctx = context.WithValue(ctx, TokenTypeKey, claims.TokenType)
+		ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
+		ctx = context.WithValue(ctx, TenantIDKey, claims.TenantID)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// VendorOnboardingMiddleware accepts an access token or the onboarding token handed
+// out by Login to vendors that are not approved yet, so they can complete their profile
+func VendorOnboardingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenString, err := utils.ExtractTokenFromHeader(r.Header.Get("Authorization"))
+		if err != nil {
+			http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid authorization header"}`, http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			claims, err = utils.ValidateOnboardingToken(tokenString)
+			if err == nil {
+				// Onboarding tokens have no session to revoke, so they stop working
+				// as soon as the application leaves review
+				if status, message := checkOnboarding(claims.UserID, claims.TenantID); status != http.StatusOK {
+					http.Error(w, message, status)
+					return
+				}
+			}
+		}
+		if err != nil {
+			http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		if claims.TenantID == 0 {
+			http.Error(w, tokenWithoutTenant, http.StatusUnauthorized)
+			return
+		}
+
+		if status, message := checkSession(claims); status != http.StatusOK {
+			http.Error(w, message, status)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+		ctx = context.WithValue(ctx, TokenTypeKey, claims.TokenType)
+		ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
+		ctx = context.WithValue(ctx, TenantIDKey, claims.TenantID)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ServiceAuthMiddleware only lets internal services through that present a client
+// credentials token granted the given scope. User access tokens are rejected.
+func ServiceAuthMiddleware(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString, err := utils.ExtractTokenFromHeader(r.Header.Get("Authorization"))
+			if err != nil {
+				http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid authorization header"}`, http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := utils.ValidateServiceToken(tokenString)
+			if err != nil {
+				http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid service token"}`, http.StatusUnauthorized)
+				return
+			}
+
+			if !claims.HasScope(scope) {
+				http.Error(w, `{"status": 403, "message": "Forbidden: Insufficient scope"}`, http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ClientIDKey, claims.Subject)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 // RequirePermission middleware checks if user has specific permission
 func RequirePermission(resource, action string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -53,8 +189,11 @@ func RequirePermission(resource, action string) func(http.Handler) http.Handler
 				return
 			}
 
-			// Check permission in database (you'll implement this)
-			hasPermission := checkUserPermission(userID, resource, action)
+			hasPermission, err := checkUserPermission(userID, resource, action)
+			if err != nil {
+				http.Error(w, `{"status": 500, "message": "Failed to check permissions"}`, http.StatusInternalServerError)
+				return
+			}
 			if !hasPermission {
 				http.Error(w, `{"status": 403, "message": "Forbidden: Insufficient permissions"}`, http.StatusForbidden)
 				return
@@ -94,11 +233,74 @@ func RequireRole(allowedRoles ...string) func(http.Handler) http.Handler {
 	}
 }
 
-// Helper function to check user permission (implement based on your needs)
-func checkUserPermission(userID uint, resource, action string) bool {
-	// This would query your database to check if user has the specific permission
-	// For now, returning true - you'll implement the actual logic
-	return true
+// checkSession verifies the session of a token is still active. Every access token
+// is bound to a session, one without a sid predates sessions and is refused. The
+// enrollment and onboarding tokens handed out before a session exists have none.
+func checkSession(claims *utils.Claims) (int, string) {
+	if claims.SessionID == "" {
+		if claims.TokenType == utils.TokenTypeAccess {
+			return http.StatusUnauthorized, `{"status": 401, "message": "Unauthorized: Token has no session, please log in again"}`
+		}
+		return http.StatusOK, ""
+	}
+
+	active, err := activeSessions.isActive(claims.SessionID)
+	if err != nil {
+		return http.StatusInternalServerError, `{"status": 500, "message": "Failed to check session"}`
+	}
+	if !active {
+		return http.StatusUnauthorized, `{"status": 401, "message": "Unauthorized: Session has been revoked"}`
+	}
+	return http.StatusOK, ""
+}
+
+// checkOnboarding makes sure the vendor of an onboarding token is still under
+// review by the organization the token was issued for
+func checkOnboarding(userID, tenantID uint) (int, string) {
+	var membership models.VendorOrganization
+	if err := database.DB.Select("id", "status").
+		Where("user_id = ? AND organization_id = ?", userID, tenantID).
+		First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return http.StatusUnauthorized, `{"status": 401, "message": "Unauthorized: Invalid token"}`
+		}
+		return http.StatusInternalServerError, `{"status": 500, "message": "Failed to check vendor status"}`
+	}
+	if membership.Status != models.VendorPending && membership.Status != models.VendorNeedsInfo {
+		return http.StatusForbidden, `{"status": 403, "message": "Forbidden: Vendor application is no longer under review"}`
+	}
+
+	var user models.User
+	if err := database.DB.Select("id", "is_active").First(&user, userID).Error; err != nil {
+		return http.StatusInternalServerError, `{"status": 500, "message": "Failed to check vendor status"}`
+	}
+	if !user.IsActive {
+		return http.StatusForbidden, `{"status": 403, "message": "Forbidden: Account is deactivated"}`
+	}
+	return http.StatusOK, ""
+}
+
+// checkUserPermission resolves the user's permissions (cached) and checks resource/action
+func checkUserPermission(userID uint, resource, action string) (bool, error) {
+	user, err := userPermissions.get(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if !user.IsActive {
+		return false, nil
+	}
+
+	return user.HasPermission(resource, action), nil
+}
+
+// UserHasPermission reports whether the user holds the permission, for handlers
+// that need more than the permission of their route
+func UserHasPermission(userID uint, resource, action string) (bool, error) {
+	return checkUserPermission(userID, resource, action)
 }
 
 // GetUserIDFromContext extracts user ID from request context
@@ -112,3 +314,30 @@ func GetUserRoleFromContext(r *http.Request) (string, bool) {
 	role, ok := r.Context().Value(UserRoleKey).(string)
 	return role, ok
 }
+
+// GetTenantIDFromContext returns the organization the request acts for
+func GetTenantIDFromContext(r *http.Request) (uint, bool) {
+	tenantID, ok := r.Context().Value(TenantIDKey).(uint)
+	return tenantID, ok && tenantID != 0
+}
+
+// GetClientIDFromContext returns the service client a request was authenticated as
+func GetClientIDFromContext(r *http.Request) (string, bool) {
+	clientID, ok := r.Context().Value(ClientIDKey).(string)
+	return clientID, ok
+}
+
+// GetTokenTypeFromContext returns the type of token the request was authenticated with
+func GetTokenTypeFromContext(r *http.Request) string {
+	tokenType, ok := r.Context().Value(TokenTypeKey).(string)
+	if !ok {
+		return utils.TokenTypeAccess
+	}
+	return tokenType
+}
+
+// GetSessionIDFromContext returns the session of the access token the request was made with
+func GetSessionIDFromContext(r *http.Request) string {
+	sessionID, _ := r.Context().Value(SessionIDKey).(string)
+	return sessionID
+}
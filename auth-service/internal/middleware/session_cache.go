@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+)
+
+// sessionCacheTTL bounds how long a session is trusted to still be active,
+// which is how long a revoked session's access tokens keep working at most
+const sessionCacheTTL = 30 * time.Second
+
+type sessionCacheEntry struct {
+	active    bool
+	expiresAt time.Time
+}
+
+// sessionCache remembers whether sessions are active so AuthMiddleware does not
+// query the database on every request
+type sessionCache struct {
+	mu      sync.RWMutex
+	entries map[string]sessionCacheEntry
+}
+
+var activeSessions = &sessionCache{
+	entries: make(map[string]sessionCacheEntry),
+}
+
+// isActive reports whether the session exists and has not been revoked
+// and marks it as used
+func (c *sessionCache) isActive(sessionID string) (bool, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[sessionID]
+	c.mu.RUnlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.active, nil
+	}
+
+	// Checking the session records its use too, so last_used_at trails the last
+	// request by at most sessionCacheTTL
+	result := database.DB.Model(&models.UserSession{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionID).
+		UpdateColumn("last_used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	active := result.RowsAffected > 0
+
+	c.mu.Lock()
+	c.entries[sessionID] = sessionCacheEntry{active: active, expiresAt: time.Now().Add(sessionCacheTTL)}
+	// Expired entries are dropped lazily so the map does not grow with every session ever seen
+	if len(c.entries) > 10000 {
+		now := time.Now()
+		for id, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	return active, nil
+}
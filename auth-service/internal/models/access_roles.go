@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Default permission bundles granted at registration
+const (
+	DefaultAdminAccessRole  = "admin_default"
+	DefaultVendorAccessRole = "vendor_default"
+)
+
+// Permission audit actions
+const (
+	AuditActionGrant      = "grant"
+	AuditActionRevoke     = "revoke"
+	AuditActionRoleCreate = "role_create"
+	AuditActionRoleUpdate = "role_update"
+	AuditActionRoleDelete = "role_delete"
+	AuditActionRoleAssign = "role_assign"
+)
+
+// AccessRole is a named bundle of permissions that can be granted in one go.
+// It is a template: assigning it grants its permissions one by one, changing
+// or deleting it later does not touch the permissions already granted.
+// Not to be confused with Role, which is the user type (admin/vendor).
+type AccessRole struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"uniqueIndex;not null;size:100"`
+	Description string       `json:"description" gorm:"size:255"`
+	CreatedBy   *uint        `json:"created_by"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:access_role_permissions;"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// PermissionAuditLog records every change to user permissions and access roles
+type PermissionAuditLog struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ActorID      *uint     `json:"actor_id" gorm:"index"` // nil for system changes e.g. registration defaults
+	TargetUserID *uint     `json:"target_user_id" gorm:"index"`
+	Action       string    `json:"action" gorm:"not null;size:50"`
+	PermissionID *uint     `json:"permission_id"`
+	AccessRoleID *uint     `json:"access_role_id"`
+	Details      string    `json:"details" gorm:"type:text"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
+
+func (AccessRole) TableName() string {
+	return "access_roles"
+}
+
+func (PermissionAuditLog) TableName() string {
+	return "permission_audit_logs"
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+type InviteStatus string
+
+const (
+	InviteStatusPending  InviteStatus = "pending"
+	InviteStatusAccepted InviteStatus = "accepted"
+	InviteStatusRevoked  InviteStatus = "revoked"
+	InviteStatusExpired  InviteStatus = "expired"
+)
+
+// AdminInvite is a single-use, expiring invitation to register as admin
+type AdminInvite struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	Email          string     `json:"email" gorm:"not null;index;size:255"`
+	OrganizationID *uint      `json:"organization_id"` // organization the invited admin joins
+	TokenHash      string     `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	InvitedBy      *uint      `json:"invited_by"` // nil when created by the bootstrap command
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	AcceptedUserID *uint      `json:"accepted_user_id"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (AdminInvite) TableName() string {
+	return "admin_invites"
+}
+
+func (i *AdminInvite) Status() InviteStatus {
+	switch {
+	case i.AcceptedAt != nil:
+		return InviteStatusAccepted
+	case i.RevokedAt != nil:
+		return InviteStatusRevoked
+	case time.Now().After(i.ExpiresAt):
+		return InviteStatusExpired
+	default:
+		return InviteStatusPending
+	}
+}
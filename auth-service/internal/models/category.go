@@ -0,0 +1,14 @@
+package models
+
+// Category is owned and migrated by category-service. It lives in the shared
+// database and is only read here to check the category a vendor picks.
+type Category struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	TenantID uint   `json:"tenant_id"`
+	Name     string `json:"name"`
+	IsActive bool   `json:"is_active"`
+}
+
+func (Category) TableName() string {
+	return "categories"
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// LoginThrottle counts failed logins for one key, either an account
+// ("account:<email>") or a client address ("ip:<addr>")
+type LoginThrottle struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	Key            string     `json:"key" gorm:"uniqueIndex;not null;size:320"`
+	FailedAttempts int        `json:"failed_attempts" gorm:"not null;default:0"`
+	LockoutCount   int        `json:"lockout_count" gorm:"not null;default:0"` // consecutive lockouts, drives the backoff
+	LockedUntil    *time.Time `json:"locked_until"`
+	LastFailedAt   *time.Time `json:"last_failed_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
+
+func (t *LoginThrottle) IsLocked() bool {
+	return t.LockedUntil != nil && time.Now().Before(*t.LockedUntil)
+}
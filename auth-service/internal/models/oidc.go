@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// OIDCIdentity links a user to their account at the corporate identity provider
+type OIDCIdentity struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"index;not null"`
+	Issuer      string    `json:"issuer" gorm:"not null;size:255;uniqueIndex:idx_oidc_identity_subject"`
+	Subject     string    `json:"subject" gorm:"not null;size:255;uniqueIndex:idx_oidc_identity_subject"`
+	Email       string    `json:"email" gorm:"size:255"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+func (OIDCIdentity) TableName() string {
+	return "oidc_identities"
+}
+
+// OIDCLoginState is a pending single sign-on attempt between the redirect to the
+// identity provider and the callback. It is consumed by the callback.
+type OIDCLoginState struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	StateHash    string    `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	Nonce        string    `json:"-" gorm:"not null;size:64"`
+	CodeVerifier string    `json:"-" gorm:"not null;size:128"` // PKCE
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// DefaultOrganizationSlug is the organization existing data is moved into
+// when organizations are introduced
+const DefaultOrganizationSlug = "default"
+
+// Organization is a buyer organization (tenant). Admins belong to exactly one,
+// RFPs and categories are owned by one, vendors can join several.
+type Organization struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null;size:150"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex;not null;size:100"`
+	IsActive  bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// VendorOrganization is the membership of a vendor in a buyer organization.
+// Every organization approves its vendors on its own, so the approval
+// lifecycle lives here rather than on VendorDetails.
+type VendorOrganization struct {
+	ID             uint          `json:"id" gorm:"primaryKey"`
+	UserID         uint          `json:"user_id" gorm:"not null;uniqueIndex:idx_vendor_organization"`
+	OrganizationID uint          `json:"organization_id" gorm:"not null;uniqueIndex:idx_vendor_organization;index"`
+	Status         VendorStatus  `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	ApprovedBy     *uint         `json:"approved_by"`
+	ApprovedAt     *time.Time    `json:"approved_at"`
+	ApprovalNotes  string        `json:"approval_notes" gorm:"type:text"`
+	Organization   *Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+	User           *User         `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+func (VendorOrganization) TableName() string {
+	return "vendor_organizations"
+}
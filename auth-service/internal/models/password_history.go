@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PasswordHistory keeps the hashes of passwords a user has set so they cannot be reused
+type PasswordHistory struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"index;not null"`
+	PasswordHash string    `json:"-" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_histories"
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// RefreshToken tracks every refresh token that was issued.
+// Tokens rotated from the same login share a FamilyID, so presenting an
+// already rotated token revokes the whole family.
+type RefreshToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	TokenID    string     `json:"-" gorm:"uniqueIndex;not null;size:64"` // jti of the refresh JWT
+	FamilyID   string     `json:"family_id" gorm:"not null;index;size:64"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	ReplacedBy *uint      `json:"replaced_by"` // set when the token was rotated
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+func (t *RefreshToken) IsRotated() bool {
+	return t.ReplacedBy != nil
+}
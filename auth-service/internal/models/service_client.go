@@ -0,0 +1,25 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ServiceClient is an internal service that authenticates with client credentials.
+// Only the hash of the secret is stored.
+type ServiceClient struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Name       string     `json:"name" gorm:"not null;size:100"`
+	ClientID   string     `json:"client_id" gorm:"uniqueIndex;not null;size:64"`
+	SecretHash string     `json:"-" gorm:"not null;size:64"`
+	Scopes     string     `json:"scopes" gorm:"not null"` // space separated
+	CreatedBy  *uint      `json:"created_by"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ScopeList returns the granted scopes
+func (sc *ServiceClient) ScopeList() []string {
+	return strings.Fields(sc.Scopes)
+}
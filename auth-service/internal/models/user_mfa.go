@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// UserMFA holds a user's TOTP secret, encrypted so only MFAService can read it.
+// The row exists from the start of enrollment, Enabled is only set once a code
+// from the app was confirmed.
+type UserMFA struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	Secret          string     `json:"-" gorm:"not null;size:255"` // base32, sealed with MFA_SECRET_KEY
+	Enabled         bool       `json:"enabled" gorm:"default:false"`
+	EnabledAt       *time.Time `json:"enabled_at"`
+	LastUsedCounter int64      `json:"-" gorm:"not null;default:0"` // time step of the last accepted code, blocks replays
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// MFARecoveryCode is a single-use code for when the authenticator is lost
+type MFARecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	CodeHash  string     `json:"-" gorm:"not null"` // bcrypt
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// UsedMFAChallenge is the jti of a challenge token that completed a login, so
+// the token cannot start another one while it is still valid
+type UsedMFAChallenge struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TokenID   string    `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+func (UsedMFAChallenge) TableName() string {
+	return "used_mfa_challenges"
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// UserSession is one login of a user on a device. Its SessionID is the family of
+// the refresh tokens issued for that login and is carried as the sid claim of
+// access tokens, so every service can reject tokens of a revoked session.
+type UserSession struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	SessionID string `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	UserID    uint   `json:"user_id" gorm:"index;not null"`
+	// Organization the session acts for, carried as the tenant_id claim
+	OrganizationID *uint      `json:"organization_id"`
+	IPAddress      string     `json:"ip_address" gorm:"size:64"`
+	UserAgent      string     `json:"user_agent" gorm:"size:255"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     time.Time  `json:"last_used_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+}
+
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
@@ -26,14 +26,29 @@ type User struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// Organization an admin works for, vendors join organizations through VendorOrganization
+	OrganizationID *uint         `json:"organization_id" gorm:"index"`
+	Organization   *Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+
 	// Vendor specific details - only populated if role is vendor
 	// JSON handling: With omitempty, nil pointers are excluded from JSON output
 	VendorDetails *VendorDetails `json:"vendor_details,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 
 	// User permissions
 	UserPermissions []UserPermission `json:"permissions,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+
+	// PermissionsVersion goes up whenever the permissions or the active flag of the
+	// user change. Every service caching permissions compares it on each lookup.
+	PermissionsVersion uint `json:"-" gorm:"not null;default:0"`
 }
 
+// UserSearchDocument is the full-text document of a user: their name and email with
+// punctuation turned into spaces, so "acme" finds jane@acme.com. The GIN index
+// idx_users_search is built on this exact expression, queries must repeat it as is.
+const UserSearchDocument = `to_tsvector('simple', regexp_replace(users.first_name || ' ' || users.last_name || ' ' || users.email, '[^[:alnum:]]+', ' ', 'g'))`
+
 // BeforeCreate hook - hash password before creating user
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.Password != "" {
@@ -70,15 +85,14 @@ type VendorDetails struct {
 	GSTNo        string    `json:"gst_no" gorm:"size:25"`
 	PANNo        string    `json:"pan_no" gorm:"size:10"`
 	PhoneNo      string    `json:"phone_no" gorm:"size:15"`
-	CategoryID   *uint     `json:"category_id"` // Foreign key to category service
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 
-	// Add approval fields here
-	IsApproved    bool       `json:"is_approved" gorm:"default:false"`
-	ApprovedBy    *uint      `json:"approved_by"`
-	ApprovedAt    *time.Time `json:"approved_at"`
-	ApprovalNotes string     `json:"approval_notes" gorm:"type:text"`
+	// Categories served by the vendor, see VendorCategory
+	Categories []VendorCategory `json:"categories,omitempty" gorm:"foreignKey:UserID;references:UserID"`
+
+	// Buyer organizations the vendor belongs to, each with its own approval status
+	Organizations []VendorOrganization `json:"organizations,omitempty" gorm:"foreignKey:UserID;references:UserID"`
 }
 
 type Permission struct {
@@ -106,7 +120,7 @@ type UserPermission struct {
 type PasswordResetOTP struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	Email     string    `json:"email" gorm:"not null;index"`
-	OTP       string    `json:"otp" gorm:"not null"`
+	OTPHash   string    `json:"-" gorm:"not null"` // bcrypt, the OTP itself is only ever emailed
 	Attempts  int       `json:"attempts" gorm:"default:0"`
 	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
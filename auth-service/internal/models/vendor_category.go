@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type VendorCategoryStatus string
+
+const (
+	VendorCategoryPending  VendorCategoryStatus = "pending"
+	VendorCategoryApproved VendorCategoryStatus = "approved"
+	VendorCategoryRejected VendorCategoryStatus = "rejected"
+)
+
+// VendorCategory links a vendor to a category they serve. Categories picked while
+// the vendor is under review are approved together with the vendor, ones added
+// later are reviewed on their own.
+type VendorCategory struct {
+	ID          uint                 `json:"id" gorm:"primaryKey"`
+	UserID      uint                 `json:"user_id" gorm:"not null;uniqueIndex:idx_vendor_category"`
+	CategoryID  uint                 `json:"category_id" gorm:"not null;uniqueIndex:idx_vendor_category;index"`
+	Status      VendorCategoryStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	ReviewedBy  *uint                `json:"reviewed_by"`
+	ReviewedAt  *time.Time           `json:"reviewed_at"`
+	ReviewNotes string               `json:"review_notes" gorm:"type:text"`
+	Category    *Category            `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	User        *User                `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+func (VendorCategory) TableName() string {
+	return "vendor_categories"
+}
@@ -0,0 +1,103 @@
+package models
+
+import "time"
+
+type ContactRole string
+
+const (
+	ContactSales     ContactRole = "sales"
+	ContactAccounts  ContactRole = "accounts"
+	ContactTechnical ContactRole = "technical"
+	ContactOther     ContactRole = "other"
+)
+
+// ContactRoles lists the roles a vendor contact can have
+var ContactRoles = []ContactRole{
+	ContactSales,
+	ContactAccounts,
+	ContactTechnical,
+	ContactOther,
+}
+
+// VendorContact is a person to reach at a vendor besides the login email. Contacts
+// subscribed to a category are mailed the RFPs of that category. A vendor with
+// contacts has exactly one primary contact.
+type VendorContact struct {
+	ID         uint                    `json:"id" gorm:"primaryKey"`
+	UserID     uint                    `json:"user_id" gorm:"not null;index;uniqueIndex:idx_vendor_contacts_primary,where:is_primary"`
+	Name       string                  `json:"name" gorm:"not null;size:150"`
+	Email      string                  `json:"email" gorm:"not null;size:255"`
+	Phone      string                  `json:"phone" gorm:"size:20"`
+	Role       ContactRole             `json:"role" gorm:"not null;type:varchar(20)"`
+	IsPrimary  bool                    `json:"is_primary" gorm:"not null;default:false"`
+	Categories []VendorContactCategory `json:"categories" gorm:"foreignKey:ContactID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time               `json:"created_at"`
+	UpdatedAt  time.Time               `json:"updated_at"`
+}
+
+// VendorContactCategory subscribes a contact to the RFPs of a category
+type VendorContactCategory struct {
+	ContactID  uint      `json:"-" gorm:"primaryKey"`
+	CategoryID uint      `json:"category_id" gorm:"primaryKey;index"`
+	Category   *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+}
+
+func (VendorContact) TableName() string {
+	return "vendor_contacts"
+}
+
+func (VendorContactCategory) TableName() string {
+	return "vendor_contact_categories"
+}
+
+// IsValidContactRole reports whether r is one of ContactRoles
+func IsValidContactRole(r ContactRole) bool {
+	for _, role := range ContactRoles {
+		if role == r {
+			return true
+		}
+	}
+	return false
+}
+
+type AddressType string
+
+const (
+	AddressBilling  AddressType = "billing"
+	AddressShipping AddressType = "shipping"
+)
+
+// AddressTypes lists the types of vendor addresses
+var AddressTypes = []AddressType{AddressBilling, AddressShipping}
+
+// VendorAddress is a billing or shipping address of a vendor. A vendor with
+// addresses of a type has exactly one primary address of that type.
+type VendorAddress struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	UserID     uint        `json:"user_id" gorm:"not null;index;uniqueIndex:idx_vendor_addresses_primary,priority:1,where:is_primary"`
+	Type       AddressType `json:"type" gorm:"not null;type:varchar(20);uniqueIndex:idx_vendor_addresses_primary,priority:2"`
+	Label      string      `json:"label" gorm:"size:100"`
+	Line1      string      `json:"line1" gorm:"not null;size:255"`
+	Line2      string      `json:"line2" gorm:"size:255"`
+	City       string      `json:"city" gorm:"not null;size:100"`
+	State      string      `json:"state" gorm:"size:100"`
+	PostalCode string      `json:"postal_code" gorm:"size:20"`
+	Country    string      `json:"country" gorm:"not null;size:100"`
+	IsPrimary  bool        `json:"is_primary" gorm:"not null;default:false"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+func (VendorAddress) TableName() string {
+	return "vendor_addresses"
+}
+
+// IsValidAddressType reports whether t is one of AddressTypes
+func IsValidAddressType(t AddressType) bool {
+	for _, addressType := range AddressTypes {
+		if addressType == t {
+			return true
+		}
+	}
+	return false
+}
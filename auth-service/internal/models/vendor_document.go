@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+type DocumentType string
+
+const (
+	DocumentRegistrationCertificate DocumentType = "registration_certificate"
+	DocumentGSTCertificate          DocumentType = "gst_certificate"
+	DocumentPANCard                 DocumentType = "pan_card"
+	DocumentBankProof               DocumentType = "bank_proof"
+	DocumentOther                   DocumentType = "other"
+)
+
+// DocumentTypes lists the types a vendor can upload
+var DocumentTypes = []DocumentType{
+	DocumentRegistrationCertificate,
+	DocumentGSTCertificate,
+	DocumentPANCard,
+	DocumentBankProof,
+	DocumentOther,
+}
+
+type DocumentStatus string
+
+const (
+	DocumentPending  DocumentStatus = "pending"
+	DocumentApproved DocumentStatus = "approved"
+	DocumentRejected DocumentStatus = "rejected"
+)
+
+// VendorDocument is a KYC document uploaded by a vendor. The file itself is kept
+// in the document storage under StorageKey.
+type VendorDocument struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	UserID      uint           `json:"user_id" gorm:"index;not null"`
+	Type        DocumentType   `json:"type" gorm:"not null;type:varchar(40)"`
+	FileName    string         `json:"file_name" gorm:"not null;size:255"`
+	ContentType string         `json:"content_type" gorm:"not null;size:100"`
+	Size        int64          `json:"size"`
+	SHA256      string         `json:"sha256" gorm:"size:64"`
+	StorageKey  string         `json:"-" gorm:"not null;size:255"`
+	Status      DocumentStatus `json:"status" gorm:"not null;type:varchar(20);default:'pending';index"`
+	ReviewedBy  *uint          `json:"reviewed_by"`
+	ReviewedAt  *time.Time     `json:"reviewed_at"`
+	ReviewNotes string         `json:"review_notes" gorm:"type:text"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+func (VendorDocument) TableName() string {
+	return "vendor_documents"
+}
+
+// IsValidDocumentType reports whether t is one of DocumentTypes
+func IsValidDocumentType(t DocumentType) bool {
+	for _, documentType := range DocumentTypes {
+		if documentType == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type ProfileChangeStatus string
+
+const (
+	ProfileChangePending   ProfileChangeStatus = "pending"
+	ProfileChangeApproved  ProfileChangeStatus = "approved"
+	ProfileChangeRejected  ProfileChangeStatus = "rejected"
+	ProfileChangeCancelled ProfileChangeStatus = "cancelled"
+)
+
+// VendorProfileChange is a change of regulated vendor details (GST/PAN) waiting for
+// an admin to verify it. The vendor keeps working with the verified values until then.
+type VendorProfileChange struct {
+	ID            uint                `json:"id" gorm:"primaryKey"`
+	UserID        uint                `json:"user_id" gorm:"index;not null"`
+	GSTNo         string              `json:"gst_no" gorm:"size:25"`
+	PANNo         string              `json:"pan_no" gorm:"size:10"`
+	PreviousGSTNo string              `json:"previous_gst_no" gorm:"size:25"`
+	PreviousPANNo string              `json:"previous_pan_no" gorm:"size:10"`
+	Status        ProfileChangeStatus `json:"status" gorm:"not null;type:varchar(20);default:'pending';index"`
+	ReviewedBy    *uint               `json:"reviewed_by"`
+	ReviewedAt    *time.Time          `json:"reviewed_at"`
+	ReviewNotes   string              `json:"review_notes" gorm:"type:text"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (VendorProfileChange) TableName() string {
+	return "vendor_profile_changes"
+}
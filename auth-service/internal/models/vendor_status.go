@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+type VendorStatus string
+
+const (
+	VendorPending     VendorStatus = "pending"
+	VendorNeedsInfo   VendorStatus = "needs_info"
+	VendorApproved    VendorStatus = "approved"
+	VendorRejected    VendorStatus = "rejected"
+	VendorSuspended   VendorStatus = "suspended"
+	VendorBlacklisted VendorStatus = "blacklisted"
+)
+
+// VendorStatuses lists every state of the vendor approval lifecycle
+var VendorStatuses = []VendorStatus{
+	VendorPending,
+	VendorNeedsInfo,
+	VendorApproved,
+	VendorRejected,
+	VendorSuspended,
+	VendorBlacklisted,
+}
+
+// vendorTransitions lists the states a vendor can move to from each state.
+// Blacklisting is final.
+var vendorTransitions = map[VendorStatus][]VendorStatus{
+	VendorPending:     {VendorNeedsInfo, VendorApproved, VendorRejected, VendorBlacklisted},
+	VendorNeedsInfo:   {VendorPending, VendorApproved, VendorRejected, VendorBlacklisted},
+	VendorApproved:    {VendorSuspended, VendorBlacklisted},
+	VendorRejected:    {VendorPending, VendorBlacklisted},
+	VendorSuspended:   {VendorApproved, VendorBlacklisted},
+	VendorBlacklisted: {},
+}
+
+// IsValidVendorStatus reports whether s is one of VendorStatuses
+func IsValidVendorStatus(s VendorStatus) bool {
+	_, ok := vendorTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a vendor in status s may be moved to next
+func (s VendorStatus) CanTransitionTo(next VendorStatus) bool {
+	for _, allowed := range vendorTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedTransitions returns the states a vendor in status s may be moved to
+func (s VendorStatus) AllowedTransitions() []VendorStatus {
+	return vendorTransitions[s]
+}
+
+// IsBlocked reports whether the vendor is shut out of the platform
+func (s VendorStatus) IsBlocked() bool {
+	return s == VendorSuspended || s == VendorBlacklisted
+}
+
+// VendorStatusHistory records every transition of a vendor's approval status in an organization
+type VendorStatusHistory struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	UserID         uint         `json:"user_id" gorm:"index;not null"`
+	OrganizationID uint         `json:"organization_id" gorm:"index;not null;default:0"`
+	FromStatus     VendorStatus `json:"from_status" gorm:"type:varchar(20);not null"`
+	ToStatus       VendorStatus `json:"to_status" gorm:"type:varchar(20);not null"`
+	Reason         string       `json:"reason" gorm:"type:text"`
+	ActorID        *uint        `json:"actor_id"` // nil for changes made by the system
+	Actor          *User        `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	CreatedAt      time.Time    `json:"created_at" gorm:"index"`
+}
+
+func (VendorStatusHistory) TableName() string {
+	return "vendor_status_history"
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Verification token purposes
+const (
+	VerificationPurposeEmail       = "email_verification"
+	VerificationPurposeSetPassword = "set_password" // invitation of a vendor created by an admin
+)
+
+// VerificationToken is a single-use token sent by email, e.g. in an email
+// verification link. Only the SHA-256 of the token is stored.
+type VerificationToken struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	Purpose   string    `json:"purpose" gorm:"not null;size:50;index"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex;not null;size:64"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (VerificationToken) TableName() string {
+	return "verification_tokens"
+}
+
+// RateLimit is a fixed window counter shared by every instance of the service
+type RateLimit struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Key         string    `json:"key" gorm:"uniqueIndex;not null;size:320"`
+	Count       int       `json:"count" gorm:"not null;default:0"`
+	WindowStart time.Time `json:"window_start"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (RateLimit) TableName() string {
+	return "rate_limits"
+}
@@ -1,9 +1,12 @@
 package routes
 
 import (
+	"net/http"
+
 	"github.com/gorilla/mux"
 	"github.com/karan-bishtt/auth-service/internal/controllers"
 	"github.com/karan-bishtt/auth-service/internal/middleware"
+	"github.com/karan-bishtt/auth-service/internal/utils"
 )
 
 func SetupAuthRoutes() *mux.Router {
@@ -11,29 +14,171 @@ func SetupAuthRoutes() *mux.Router {
 
 	// Controllers
 	authController := controllers.NewAuthController()
+	permissionController := controllers.NewPermissionController()
+	inviteController := controllers.NewInviteController()
+	mfaController := controllers.NewMFAController()
+	sessionController := controllers.NewSessionController()
+	oidcController := controllers.NewOIDCController()
+	serviceClientController := controllers.NewServiceClientController()
+	vendorProfileController := controllers.NewVendorProfileController()
+	vendorDocumentController := controllers.NewVendorDocumentController()
+	vendorApprovalController := controllers.NewVendorApprovalController()
+	vendorCategoryController := controllers.NewVendorCategoryController()
+	vendorImportController := controllers.NewVendorImportController()
+	userController := controllers.NewUserController()
+	organizationController := controllers.NewOrganizationController()
+	vendorSearchController := controllers.NewVendorSearchController()
+	vendorContactController := controllers.NewVendorContactController()
+
+	// Public keys for downstream services to verify tokens
+	router.HandleFunc("/.well-known/jwks.json", authController.JWKS).Methods("GET")
 
 	// API routes
 	api := router.PathPrefix("/api/v1").Subrouter()
 
+	// Public details of a buyer organization for the registration and login pages
+	api.HandleFunc("/organizations/{slug}", organizationController.GetOrganization).Methods("GET")
+
 	// Public Auth routes (No authentication required)
 	authRoutes := api.PathPrefix("/auth").Subrouter()
 	authRoutes.HandleFunc("/register-vendor", authController.RegisterVendor).Methods("POST")
-	authRoutes.HandleFunc("/register-admin", authController.RegisterAdmin).Methods("POST")
+	authRoutes.HandleFunc("/register-admin", authController.RegisterAdmin).Methods("POST") // requires an invite token
 	authRoutes.HandleFunc("/login", authController.Login).Methods("POST")
-	authRoutes.HandleFunc("/users/{id:[0-9]+}", authController.GetVendorById).Methods("GET")
+	authRoutes.HandleFunc("/verify-email", authController.VerifyEmail).Methods("POST")
+	authRoutes.HandleFunc("/resend-verification", authController.ResendVerification).Methods("POST")
 	authRoutes.HandleFunc("/forgot-password", authController.ForgotPassword).Methods("POST")
 	authRoutes.HandleFunc("/reset-password", authController.ResetPassword).Methods("POST")
+	authRoutes.HandleFunc("/set-password", authController.SetPassword).Methods("POST") // invitation of imported vendors
+	authRoutes.HandleFunc("/refresh", authController.RefreshToken).Methods("POST")
+	authRoutes.HandleFunc("/token", serviceClientController.Token).Methods("POST") // client credentials for internal services
+	authRoutes.HandleFunc("/logout", authController.Logout).Methods("POST")
+	authRoutes.Handle("/logout-all", middleware.AuthMiddleware(http.HandlerFunc(authController.LogoutAll))).Methods("POST")
+	authRoutes.Handle("/change-password", middleware.AuthMiddleware(http.HandlerFunc(authController.ChangePassword))).Methods("POST")
+	// Sessions of the current user
+	authRoutes.Handle("/sessions", middleware.AuthMiddleware(http.HandlerFunc(sessionController.ListSessions))).Methods("GET")
+	authRoutes.Handle("/sessions/{id:[0-9]+}", middleware.AuthMiddleware(http.HandlerFunc(sessionController.RevokeSession))).Methods("DELETE")
+
+	// Single sign-on for admins through the corporate identity provider
+	authRoutes.HandleFunc("/oidc/login", oidcController.Login).Methods("GET")
+	authRoutes.HandleFunc("/oidc/callback", oidcController.Callback).Methods("POST")
+
+	// Two-factor authentication
+	authRoutes.HandleFunc("/mfa/verify", mfaController.VerifyLogin).Methods("POST")
+	authRoutes.Handle("/mfa", middleware.AuthMiddleware(http.HandlerFunc(mfaController.GetStatus))).Methods("GET")
+	authRoutes.Handle("/mfa/setup", middleware.MFAEnrollmentMiddleware(http.HandlerFunc(mfaController.Setup))).Methods("POST")
+	authRoutes.Handle("/mfa/enable", middleware.MFAEnrollmentMiddleware(http.HandlerFunc(mfaController.Enable))).Methods("POST")
+	authRoutes.Handle("/mfa/disable", middleware.AuthMiddleware(http.HandlerFunc(mfaController.Disable))).Methods("POST")
+	authRoutes.Handle("/mfa/recovery-codes", middleware.AuthMiddleware(http.HandlerFunc(mfaController.RegenerateRecoveryCodes))).Methods("POST")
 	// Add these routes to your router
 
 	// Apply auth middleware to protected routes (not for /auth)
 
+	// Vendor self-service (Require 'vendor' role). Vendors awaiting approval get in
+	// with their onboarding token to complete their profile and documents.
+	vendorRoutes := api.PathPrefix("/vendor").Subrouter()
+	vendorRoutes.Use(middleware.VendorOnboardingMiddleware)
+	vendorRoutes.Use(middleware.RequireRole("vendor"))
+	vendorRoutes.HandleFunc("/profile", vendorProfileController.GetProfile).Methods("GET")
+	vendorRoutes.HandleFunc("/profile", vendorProfileController.UpdateProfile).Methods("PUT")
+	vendorRoutes.HandleFunc("/profile/pending-change", vendorProfileController.CancelPendingChange).Methods("DELETE")
+	vendorRoutes.HandleFunc("/status", vendorApprovalController.GetStatus).Methods("GET")
+	vendorRoutes.HandleFunc("/resubmit", vendorApprovalController.Resubmit).Methods("POST")
+	vendorRoutes.HandleFunc("/documents", vendorDocumentController.ListDocuments).Methods("GET")
+	vendorRoutes.HandleFunc("/documents", vendorDocumentController.UploadDocument).Methods("POST")
+	vendorRoutes.HandleFunc("/documents/{id:[0-9]+}/file", vendorDocumentController.DownloadDocument).Methods("GET")
+	vendorRoutes.HandleFunc("/documents/{id:[0-9]+}", vendorDocumentController.DeleteDocument).Methods("DELETE")
+	vendorRoutes.HandleFunc("/organizations", organizationController.ListMemberships).Methods("GET")
+	vendorRoutes.HandleFunc("/organizations", organizationController.JoinOrganization).Methods("POST")
+	vendorRoutes.HandleFunc("/contacts", vendorContactController.ListContacts).Methods("GET")
+	vendorRoutes.HandleFunc("/contacts", vendorContactController.CreateContact).Methods("POST")
+	vendorRoutes.HandleFunc("/contacts/{id:[0-9]+}", vendorContactController.UpdateContact).Methods("PUT")
+	vendorRoutes.HandleFunc("/contacts/{id:[0-9]+}", vendorContactController.DeleteContact).Methods("DELETE")
+	vendorRoutes.HandleFunc("/addresses", vendorContactController.ListAddresses).Methods("GET")
+	vendorRoutes.HandleFunc("/addresses", vendorContactController.CreateAddress).Methods("POST")
+	vendorRoutes.HandleFunc("/addresses/{id:[0-9]+}", vendorContactController.UpdateAddress).Methods("PUT")
+	vendorRoutes.HandleFunc("/addresses/{id:[0-9]+}", vendorContactController.DeleteAddress).Methods("DELETE")
+
+	// Internal routes, only for other services of the platform with a service token
+	internalRoutes := api.PathPrefix("/internal").Subrouter()
+	internalRoutes.Handle("/users/{id:[0-9]+}", middleware.ServiceAuthMiddleware(utils.ScopeUsersRead)(http.HandlerFunc(authController.GetVendorById))).Methods("GET")
+	// Vendors of the organization given by the tenant_id query parameter
+	internalRoutes.Handle("/vendors", middleware.ServiceAuthMiddleware(utils.ScopeUsersRead)(http.HandlerFunc(authController.GetTenantVendors))).Methods("GET")
+	internalRoutes.Handle("/vendors/search", middleware.ServiceAuthMiddleware(utils.ScopeUsersRead)(http.HandlerFunc(vendorSearchController.SearchTenantVendors))).Methods("GET")
+	internalRoutes.Handle("/categories/{id:[0-9]+}/vendors", middleware.ServiceAuthMiddleware(utils.ScopeUsersRead)(http.HandlerFunc(vendorCategoryController.GetCategoryVendors))).Methods("GET")
+
 	// Admin routes (Require 'admin' role)
 	adminRoutes := api.PathPrefix("/admin").Subrouter()
 	adminRoutes.Use(middleware.AuthMiddleware)
 	adminRoutes.Use(middleware.RequireRole("admin"))
+	adminRoutes.Use(middleware.RequirePermission("user", "manage"))
+
+	// Platform wide resources shared by every organization
+	platform := middleware.RequirePermission("organization", "manage")
 	adminRoutes.HandleFunc("/get-vendors", authController.GetVendors).Methods("GET")
 	adminRoutes.HandleFunc("/get-vendors/{id:[0-9]+}", authController.GetVendorsByCategory).Methods("GET")
-	adminRoutes.HandleFunc("/approve-vendors", authController.ApproveVendor).Methods("POST")
+	adminRoutes.HandleFunc("/vendors/search", vendorSearchController.SearchVendors).Methods("GET")
+	adminRoutes.HandleFunc("/approve-vendors", vendorApprovalController.ApproveVendor).Methods("POST")
+	adminRoutes.HandleFunc("/vendors/{id:[0-9]+}/status", vendorApprovalController.ChangeVendorStatus).Methods("POST")
+	adminRoutes.HandleFunc("/vendors/{id:[0-9]+}/history", vendorApprovalController.GetVendorHistory).Methods("GET")
+	adminRoutes.HandleFunc("/vendors/import", vendorImportController.ImportVendors).Methods("POST") // dry run unless commit=true
+	adminRoutes.HandleFunc("/users", userController.ListUsers).Methods("GET")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}", userController.GetUser).Methods("GET")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/deactivate", userController.DeactivateUser).Methods("POST")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/reactivate", userController.ReactivateUser).Methods("POST")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/force-password-reset", userController.ForcePasswordReset).Methods("POST")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/role", userController.ChangeRole).Methods("PUT")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/unlock", authController.UnlockUser).Methods("POST")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/mfa", mfaController.ResetUserMFA).Methods("DELETE")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/sessions", sessionController.ListUserSessions).Methods("GET")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/sessions", sessionController.RevokeUserSessions).Methods("DELETE")
+
+	// Permission management
+	adminRoutes.HandleFunc("/permissions", permissionController.ListPermissions).Methods("GET")
+	adminRoutes.HandleFunc("/permission-audit", permissionController.GetPermissionAuditLog).Methods("GET")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/permissions", permissionController.GetUserPermissions).Methods("GET")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/permissions", permissionController.GrantUserPermission).Methods("POST")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/permissions/{permission_id:[0-9]+}", permissionController.RevokeUserPermission).Methods("DELETE")
+	adminRoutes.HandleFunc("/users/{id:[0-9]+}/roles", permissionController.AssignAccessRole).Methods("POST")
+	adminRoutes.HandleFunc("/roles", permissionController.ListAccessRoles).Methods("GET")
+	adminRoutes.Handle("/roles", platform(http.HandlerFunc(permissionController.CreateAccessRole))).Methods("POST")
+	adminRoutes.Handle("/roles/{id:[0-9]+}", platform(http.HandlerFunc(permissionController.UpdateAccessRole))).Methods("PUT")
+	adminRoutes.Handle("/roles/{id:[0-9]+}", platform(http.HandlerFunc(permissionController.DeleteAccessRole))).Methods("DELETE")
+
+	// Admin invites
+	adminRoutes.HandleFunc("/invites", inviteController.ListInvites).Methods("GET")
+	adminRoutes.HandleFunc("/invites", inviteController.CreateInvite).Methods("POST")
+	adminRoutes.HandleFunc("/invites/{id:[0-9]+}", inviteController.RevokeInvite).Methods("DELETE")
+
+	// Verification of vendor GST/PAN changes
+	adminRoutes.HandleFunc("/vendor-profile-changes", vendorProfileController.ListProfileChanges).Methods("GET")
+	adminRoutes.HandleFunc("/vendor-profile-changes/{id:[0-9]+}/approve", vendorProfileController.ApproveProfileChange).Methods("POST")
+	adminRoutes.HandleFunc("/vendor-profile-changes/{id:[0-9]+}/reject", vendorProfileController.RejectProfileChange).Methods("POST")
+
+	// Review of categories added by approved vendors
+	adminRoutes.HandleFunc("/vendor-categories", vendorCategoryController.ListVendorCategories).Methods("GET")
+	adminRoutes.HandleFunc("/vendors/{id:[0-9]+}/categories/{category_id:[0-9]+}/approve", vendorCategoryController.ApproveVendorCategory).Methods("POST")
+	adminRoutes.HandleFunc("/vendors/{id:[0-9]+}/categories/{category_id:[0-9]+}/reject", vendorCategoryController.RejectVendorCategory).Methods("POST")
+
+	// Review of vendor KYC documents
+	adminRoutes.HandleFunc("/documents", vendorDocumentController.ListReviewQueue).Methods("GET")
+	adminRoutes.HandleFunc("/documents/{id:[0-9]+}/file", vendorDocumentController.DownloadVendorDocument).Methods("GET")
+	adminRoutes.HandleFunc("/documents/{id:[0-9]+}/approve", vendorDocumentController.ApproveDocument).Methods("POST")
+	adminRoutes.HandleFunc("/documents/{id:[0-9]+}/reject", vendorDocumentController.RejectDocument).Methods("POST")
+	adminRoutes.HandleFunc("/vendors/{id:[0-9]+}/documents", vendorDocumentController.ListVendorDocuments).Methods("GET")
+
+	// Contacts and addresses of vendors
+	adminRoutes.HandleFunc("/vendors/{id:[0-9]+}/contacts", vendorContactController.ListVendorContacts).Methods("GET")
+	adminRoutes.HandleFunc("/vendors/{id:[0-9]+}/addresses", vendorContactController.ListVendorAddresses).Methods("GET")
+
+	// Buyer organizations (tenants)
+	adminRoutes.Handle("/organizations", platform(http.HandlerFunc(organizationController.ListOrganizations))).Methods("GET")
+	adminRoutes.Handle("/organizations", platform(http.HandlerFunc(organizationController.CreateOrganization))).Methods("POST")
+	adminRoutes.Handle("/organizations/{id:[0-9]+}", platform(http.HandlerFunc(organizationController.UpdateOrganization))).Methods("PUT")
+
+	// Credentials of internal services
+	adminRoutes.Handle("/service-clients", platform(http.HandlerFunc(serviceClientController.ListServiceClients))).Methods("GET")
+	adminRoutes.Handle("/service-clients", platform(http.HandlerFunc(serviceClientController.CreateServiceClient))).Methods("POST")
+	adminRoutes.Handle("/service-clients/{id:[0-9]+}", platform(http.HandlerFunc(serviceClientController.RevokeServiceClient))).Methods("DELETE")
 
 	return router
 }
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+)
+
+// rateLimitRetention keeps rate limit windows around long enough for the
+// longest window in use
+const rateLimitRetention = 24 * time.Hour
+
+// StartCleanupJob purges expired single-use rows every interval. It blocks, run it in a goroutine.
+func StartCleanupJob(interval time.Duration) {
+	if interval <= 0 {
+		log.Printf("Cleanup job disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purgeExpired()
+		<-ticker.C
+	}
+}
+
+func purgeExpired() {
+	now := time.Now()
+
+	jobs := []struct {
+		name  string
+		model interface{}
+		where string
+		arg   time.Time
+	}{
+		{"password reset OTPs", &models.PasswordResetOTP{}, "expires_at < ?", now},
+		{"verification tokens", &models.VerificationToken{}, "expires_at < ?", now},
+		{"single sign-on states", &models.OIDCLoginState{}, "expires_at < ?", now},
+		{"used MFA challenges", &models.UsedMFAChallenge{}, "expires_at < ?", now},
+		{"rate limits", &models.RateLimit{}, "window_start < ?", now.Add(-rateLimitRetention)},
+		{"refresh tokens", &models.RefreshToken{}, "expires_at < ?", now},
+		{"sessions", &models.UserSession{}, "last_used_at < ?", now.Add(-utils.RefreshTokenDuration)},
+	}
+
+	for _, job := range jobs {
+		result := database.DB.Where(job.where, job.arg).Delete(job.model)
+		if result.Error != nil {
+			log.Printf("Cleanup of expired %s failed: %v", job.name, result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Purged %d expired %s", result.RowsAffected, job.name)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/karan-bishtt/auth-service/config"
+)
+
+var ErrDocumentNotStored = errors.New("document file not found")
+
+// DocumentStorage keeps the files of vendor documents. Keys are relative paths
+// like "vendors/12/3f9c....pdf", so an object store can use them as object keys.
+type DocumentStorage interface {
+	Save(key string, content io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// documentStorage is the storage selected at startup by InitDocumentStorage
+var documentStorage DocumentStorage
+
+// InitDocumentStorage sets up the storage selected by DOCUMENT_STORAGE
+func InitDocumentStorage(cfg *config.Config) error {
+	switch cfg.DocumentStorage {
+	case "local":
+		if err := os.MkdirAll(cfg.DocumentStorageDir, 0750); err != nil {
+			return fmt.Errorf("failed to create document directory: %w", err)
+		}
+		documentStorage = &LocalDocumentStorage{root: cfg.DocumentStorageDir}
+		return nil
+	default:
+		return fmt.Errorf("unknown document storage %q", cfg.DocumentStorage)
+	}
+}
+
+// LocalDocumentStorage keeps documents on the local disk below root
+type LocalDocumentStorage struct {
+	root string
+}
+
+func (ls *LocalDocumentStorage) Save(key string, content io.Reader) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create document directory: %w", err)
+	}
+
+	// Written under a temporary name so a failed upload never leaves a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create document: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write document: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write document: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (ls *LocalDocumentStorage) Open(key string) (io.ReadCloser, error) {
+	path, err := ls.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrDocumentNotStored
+	}
+	return file, err
+}
+
+func (ls *LocalDocumentStorage) Delete(key string) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path maps a key below root, refusing keys that would escape it
+func (ls *LocalDocumentStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid document key %q", key)
+	}
+	return filepath.Join(ls.root, clean), nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/config"
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidInvite       = errors.New("invalid or expired invite")
+	ErrInviteEmailMismatch = errors.New("invite was issued for a different email")
+	ErrAdminAlreadyExists  = errors.New("an admin already exists")
+)
+
+// InviteService creates and redeems admin invites
+type InviteService struct {
+	ttl         time.Duration
+	frontendURL string
+}
+
+func NewInviteService() *InviteService {
+	cfg := config.Load()
+	return &InviteService{
+		ttl:         time.Duration(cfg.AdminInviteTTLHours) * time.Hour,
+		frontendURL: strings.TrimSuffix(cfg.FrontendURL, "/"),
+	}
+}
+
+// CreateInvite stores a new invite for email to become an admin of the organization
+// orgID and returns it with the plain token. Only the token hash is stored, so the
+// token cannot be shown again later.
+func (is *InviteService) CreateInvite(email string, orgID uint, invitedBy *uint) (*models.AdminInvite, string, error) {
+	token, err := utils.NewSecureToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	invite := models.AdminInvite{
+		Email:          strings.ToLower(strings.TrimSpace(email)),
+		OrganizationID: &orgID,
+		TokenHash:      utils.HashToken(token),
+		InvitedBy:      invitedBy,
+		ExpiresAt:      time.Now().Add(is.ttl),
+	}
+	if err := database.DB.Create(&invite).Error; err != nil {
+		return nil, "", err
+	}
+
+	return &invite, token, nil
+}
+
+// CreateBootstrapInvite creates the invite of the first admin, who joins the default
+// organization. It is refused once any admin exists.
+func (is *InviteService) CreateBootstrapInvite(email string) (*models.AdminInvite, string, error) {
+	var count int64
+	if err := database.DB.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&count).Error; err != nil {
+		return nil, "", err
+	}
+	if count > 0 {
+		return nil, "", ErrAdminAlreadyExists
+	}
+
+	var org models.Organization
+	if err := database.DB.Where("slug = ?", models.DefaultOrganizationSlug).First(&org).Error; err != nil {
+		return nil, "", err
+	}
+
+	return is.CreateInvite(email, org.ID, nil)
+}
+
+// InviteLink is the frontend URL the invitee uses to register
+func (is *InviteService) InviteLink(token string) string {
+	return is.frontendURL + "/register-admin?invite=" + token
+}
+
+// ValidateInvite checks that token is a pending invite for email without redeeming it
+func (is *InviteService) ValidateInvite(token, email string) (*models.AdminInvite, error) {
+	return findPendingInvite(database.DB, token, email)
+}
+
+// AcceptInvite marks the invite as used by userID inside tx. It fails unless the
+// invite is pending and was issued for email, and only one caller can ever accept it.
+func (is *InviteService) AcceptInvite(tx *gorm.DB, token, email string, userID uint) error {
+	invite, err := findPendingInvite(tx, token, email)
+	if err != nil {
+		return err
+	}
+
+	// Conditional update so two concurrent registrations cannot both redeem the invite
+	result := tx.Model(&models.AdminInvite{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invite.ID).
+		Updates(map[string]interface{}{
+			"accepted_at":      time.Now(),
+			"accepted_user_id": userID,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidInvite
+	}
+
+	return nil
+}
+
+func findPendingInvite(db *gorm.DB, token, email string) (*models.AdminInvite, error) {
+	var invite models.AdminInvite
+	if err := db.Where("token_hash = ?", utils.HashToken(token)).First(&invite).Error; err != nil {
+		return nil, ErrInvalidInvite
+	}
+	if invite.Status() != models.InviteStatusPending {
+		return nil, ErrInvalidInvite
+	}
+	if !strings.EqualFold(invite.Email, strings.TrimSpace(email)) {
+		return nil, ErrInviteEmailMismatch
+	}
+	return &invite, nil
+}
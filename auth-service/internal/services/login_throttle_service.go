@@ -0,0 +1,152 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/config"
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginThrottleService counts failed logins per account and per client address
+// and locks them out with exponential backoff once the limit is reached
+type LoginThrottleService struct {
+	maxAttempts      int
+	maxAttemptsPerIP int
+	window           time.Duration
+	lockoutBase      time.Duration
+	lockoutMax       time.Duration
+}
+
+// LoginFailure is the outcome of recording a failed login
+type LoginFailure struct {
+	AccountLocked bool // the account has just been locked by this failure
+	LockedUntil   time.Time
+}
+
+func NewLoginThrottleService() *LoginThrottleService {
+	cfg := config.Load()
+	return &LoginThrottleService{
+		maxAttempts:      cfg.LoginMaxAttempts,
+		maxAttemptsPerIP: cfg.LoginMaxAttemptsPerIP,
+		window:           time.Duration(cfg.LoginAttemptWindowMins) * time.Minute,
+		lockoutBase:      time.Duration(cfg.LoginLockoutBaseMins) * time.Minute,
+		lockoutMax:       time.Duration(cfg.LoginLockoutMaxMins) * time.Minute,
+	}
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LockedUntil reports whether the account or the client address is locked out and until when
+func (ls *LoginThrottleService) LockedUntil(email, ip string) (time.Time, bool, error) {
+	var throttles []models.LoginThrottle
+	if err := database.DB.Where("key IN ?", []string{accountKey(email), ipKey(ip)}).Find(&throttles).Error; err != nil {
+		return time.Time{}, false, err
+	}
+
+	var until time.Time
+	for _, throttle := range throttles {
+		if throttle.IsLocked() && throttle.LockedUntil.After(until) {
+			until = *throttle.LockedUntil
+		}
+	}
+	return until, !until.IsZero(), nil
+}
+
+// RecordFailure counts a failed login against both the account and the client address.
+// Unknown emails are counted too so lockouts do not reveal which accounts exist.
+func (ls *LoginThrottleService) RecordFailure(email, ip string) (LoginFailure, error) {
+	var failure LoginFailure
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		locked, until, err := ls.recordFailure(tx, accountKey(email), ls.maxAttempts)
+		if err != nil {
+			return err
+		}
+		failure = LoginFailure{AccountLocked: locked, LockedUntil: until}
+
+		_, _, err = ls.recordFailure(tx, ipKey(ip), ls.maxAttemptsPerIP)
+		return err
+	})
+	return failure, err
+}
+
+// RecordSuccess clears the failed attempts of the account. The client address
+// keeps its count so one valid login cannot reset a spraying attack.
+func (ls *LoginThrottleService) RecordSuccess(email string) error {
+	return database.DB.Where("key = ?", accountKey(email)).Delete(&models.LoginThrottle{}).Error
+}
+
+// Unlock lifts a lockout of the account and resets its backoff.
+// It reports whether the account had any failed attempts recorded.
+func (ls *LoginThrottleService) Unlock(email string) (bool, error) {
+	result := database.DB.Where("key = ?", accountKey(email)).Delete(&models.LoginThrottle{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (ls *LoginThrottleService) recordFailure(tx *gorm.DB, key string, maxAttempts int) (bool, time.Time, error) {
+	// Make sure the row exists so it can be locked while counting
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginThrottle{Key: key}).Error; err != nil {
+		return false, time.Time{}, err
+	}
+
+	var throttle models.LoginThrottle
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&throttle).Error; err != nil {
+		return false, time.Time{}, err
+	}
+
+	if throttle.IsLocked() {
+		// Attempts during a lockout are rejected before the password is checked
+		return false, *throttle.LockedUntil, nil
+	}
+
+	now := time.Now()
+	if throttle.LastFailedAt != nil && now.Sub(*throttle.LastFailedAt) > ls.window {
+		throttle.FailedAttempts = 0
+	}
+	// The backoff starts over after a quiet period as long as the longest lockout
+	if throttle.LockedUntil != nil && now.Sub(*throttle.LockedUntil) > ls.lockoutMax {
+		throttle.LockoutCount = 0
+	}
+
+	throttle.FailedAttempts++
+	throttle.LastFailedAt = &now
+
+	locked := false
+	if throttle.FailedAttempts >= maxAttempts {
+		throttle.LockoutCount++
+		until := now.Add(ls.lockoutDuration(throttle.LockoutCount))
+		throttle.LockedUntil = &until
+		throttle.FailedAttempts = 0
+		locked = true
+	}
+
+	if err := tx.Save(&throttle).Error; err != nil {
+		return false, time.Time{}, err
+	}
+
+	if locked {
+		return true, *throttle.LockedUntil, nil
+	}
+	return false, time.Time{}, nil
+}
+
+// lockoutDuration doubles the base lockout for every consecutive lockout, up to lockoutMax
+func (ls *LoginThrottleService) lockoutDuration(lockoutCount int) time.Duration {
+	duration := ls.lockoutBase
+	for i := 1; i < lockoutCount && duration < ls.lockoutMax; i++ {
+		duration *= 2
+	}
+	if duration > ls.lockoutMax {
+		duration = ls.lockoutMax
+	}
+	return duration
+}
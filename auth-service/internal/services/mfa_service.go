@@ -0,0 +1,361 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/config"
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotStarted     = errors.New("two-factor enrollment has not been started")
+	ErrInvalidMFACode    = errors.New("invalid verification code")
+	ErrMFAChallengeUsed  = errors.New("challenge token has already been used")
+)
+
+// mfaSecretKey encrypts the stored TOTP secrets, set at startup by InitMFASecrets
+var mfaSecretKey []byte
+
+// InitMFASecrets loads the key of MFA_SECRET_KEY and encrypts the secrets stored
+// before it was introduced. Without a key a temporary one is generated, which is
+// only allowed outside production since enrollments then stop working on restart.
+func InitMFASecrets(cfg *config.Config) error {
+	var key []byte
+	if cfg.MFASecretKey == "" {
+		if cfg.Environment == "production" {
+			return errors.New("MFA_SECRET_KEY is not set")
+		}
+		log.Printf("⚠️  MFA_SECRET_KEY is not set, generating a temporary key")
+		generated, err := utils.NewSecretKey()
+		if err != nil {
+			return err
+		}
+		key = generated
+	} else {
+		parsed, err := utils.ParseSecretKey(cfg.MFASecretKey)
+		if err != nil {
+			return fmt.Errorf("invalid MFA_SECRET_KEY: %w", err)
+		}
+		key = parsed
+	}
+	mfaSecretKey = key
+
+	return sealPlaintextSecrets(key)
+}
+
+// sealPlaintextSecrets encrypts the TOTP secrets that are still stored in plaintext
+func sealPlaintextSecrets(key []byte) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var rows []models.UserMFA
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("secret NOT LIKE ?", "v1:%").
+			Find(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, mfa := range rows {
+			sealed, err := utils.SealSecret(key, mfa.Secret, mfaSecretContext(mfa.UserID))
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&mfa).UpdateColumn("secret", sealed).Error; err != nil {
+				return err
+			}
+		}
+		if len(rows) > 0 {
+			log.Printf("Encrypted %d stored TOTP secrets", len(rows))
+		}
+		return nil
+	})
+}
+
+// mfaSecretContext ties a sealed secret to its user, so it cannot be copied to another row
+func mfaSecretContext(userID uint) string {
+	return fmt.Sprintf("user_mfa:%d", userID)
+}
+
+// MFAService manages TOTP enrollment and verification. It is the only reader of
+// the decrypted TOTP secrets.
+type MFAService struct {
+	issuer           string
+	requiredForAdmin bool
+	secretKey        []byte
+	totp             utils.TOTPConfig
+	now              func() time.Time
+}
+
+func NewMFAService() *MFAService {
+	cfg := config.Load()
+	return &MFAService{
+		issuer:           cfg.MFAIssuer,
+		requiredForAdmin: cfg.MFARequiredForAdmins,
+		secretKey:        mfaSecretKey,
+		totp:             utils.DefaultTOTPConfig,
+		now:              time.Now,
+	}
+}
+
+// IsRequired reports whether policy forces the user to use two-factor authentication
+func (ms *MFAService) IsRequired(user *models.User) bool {
+	return ms.requiredForAdmin && user.Role == models.RoleAdmin
+}
+
+// Get returns the user's MFA row, or nil when enrollment was never started
+func (ms *MFAService) Get(userID uint) (*models.UserMFA, error) {
+	var mfa models.UserMFA
+	if err := database.DB.Where("user_id = ?", userID).First(&mfa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mfa, nil
+}
+
+// IsEnabled reports whether the user has confirmed TOTP enrollment
+func (ms *MFAService) IsEnabled(userID uint) (bool, error) {
+	mfa, err := ms.Get(userID)
+	if err != nil {
+		return false, err
+	}
+	return mfa != nil && mfa.Enabled, nil
+}
+
+// RemainingRecoveryCodes counts the unused recovery codes of the user
+func (ms *MFAService) RemainingRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// BeginEnrollment generates a new secret for the user and returns it with the
+// provisioning URI. Starting over replaces a secret that was never confirmed.
+func (ms *MFAService) BeginEnrollment(user *models.User) (secret, uri string, err error) {
+	secret, err = utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	sealed, err := utils.SealSecret(ms.secretKey, secret, mfaSecretContext(user.ID))
+	if err != nil {
+		return "", "", err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var mfa models.UserMFA
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", user.ID).First(&mfa).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(&models.UserMFA{UserID: user.ID, Secret: sealed}).Error
+		}
+		if err != nil {
+			return err
+		}
+		if mfa.Enabled {
+			return ErrMFAAlreadyEnabled
+		}
+		return tx.Model(&mfa).Updates(map[string]interface{}{
+			"secret":            sealed,
+			"last_used_counter": 0,
+		}).Error
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, utils.TOTPProvisioningURI(secret, ms.issuer, user.Email, ms.totp), nil
+}
+
+// ConfirmEnrollment enables MFA once the user proves the app produces valid codes
+// and returns the recovery codes. They are only ever shown this once.
+func (ms *MFAService) ConfirmEnrollment(userID uint, code string) ([]string, error) {
+	var recoveryCodes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var mfa models.UserMFA
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&mfa).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMFANotStarted
+			}
+			return err
+		}
+		if mfa.Enabled {
+			return ErrMFAAlreadyEnabled
+		}
+
+		counter, err := ms.checkCode(&mfa, code)
+		if err != nil {
+			return err
+		}
+
+		now := ms.now()
+		if err := tx.Model(&mfa).Updates(map[string]interface{}{
+			"enabled":           true,
+			"enabled_at":        &now,
+			"last_used_counter": counter,
+		}).Error; err != nil {
+			return err
+		}
+
+		recoveryCodes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return recoveryCodes, err
+}
+
+// Verify checks a TOTP code or, failing that, a recovery code. A recovery code is used up.
+func (ms *MFAService) Verify(userID uint, code string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		return ms.verify(tx, userID, code)
+	})
+}
+
+// VerifyChallenge checks the code of the second login step like Verify and uses
+// up the challenge token challengeID, so it cannot complete another login. After
+// a wrong code the challenge stays usable for another try.
+func (ms *MFAService) VerifyChallenge(userID uint, challengeID string, expiresAt time.Time, code string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		// The unique jti makes a concurrent use of the same challenge wait for this one
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UsedMFAChallenge{
+			TokenID:   challengeID,
+			UserID:    userID,
+			ExpiresAt: expiresAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMFAChallengeUsed
+		}
+
+		return ms.verify(tx, userID, code)
+	})
+}
+
+func (ms *MFAService) verify(tx *gorm.DB, userID uint, code string) error {
+	code = strings.TrimSpace(code)
+
+	var mfa models.UserMFA
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&mfa).Error; err != nil || !mfa.Enabled {
+		return ErrMFANotEnabled
+	}
+
+	if counter, err := ms.checkCode(&mfa, code); err == nil {
+		return tx.Model(&mfa).Update("last_used_counter", counter).Error
+	}
+
+	return useRecoveryCode(tx, userID, code)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user
+func (ms *MFAService) RegenerateRecoveryCodes(userID uint) ([]string, error) {
+	var recoveryCodes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		recoveryCodes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return recoveryCodes, err
+}
+
+// Disable removes the secret and the recovery codes of the user
+func (ms *MFAService) Disable(userID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error
+	})
+}
+
+// checkCode validates a TOTP code and rejects codes from a time step already used
+func (ms *MFAService) checkCode(mfa *models.UserMFA, code string) (int64, error) {
+	encoded, err := utils.OpenSecret(ms.secretKey, mfa.Secret, mfaSecretContext(mfa.UserID))
+	if err != nil {
+		return 0, err
+	}
+	secret, err := utils.DecodeTOTPSecret(encoded)
+	if err != nil {
+		return 0, err
+	}
+
+	counter, ok := utils.ValidateTOTP(secret, code, ms.now(), ms.totp)
+	if !ok || int64(counter) <= mfa.LastUsedCounter {
+		return 0, ErrInvalidMFACode
+	}
+	return int64(counter), nil
+}
+
+func useRecoveryCode(tx *gorm.DB, userID uint, code string) error {
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return ErrInvalidMFACode
+	}
+
+	var recoveryCodes []models.MFARecoveryCode
+	if err := tx.Where("user_id = ? AND used_at IS NULL", userID).Find(&recoveryCodes).Error; err != nil {
+		return err
+	}
+
+	for _, recoveryCode := range recoveryCodes {
+		if utils.CheckPasswordHash(code, recoveryCode.CodeHash) {
+			return tx.Model(&recoveryCode).Update("used_at", time.Now()).Error
+		}
+	}
+	return ErrInvalidMFACode
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := utils.HashPassword(normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&models.MFARecoveryCode{UserID: userID, CodeHash: hash}).Error; err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// newRecoveryCode returns a code like "k3j9a-x2m4q"
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode lets users type recovery codes without the dash or in upper case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) != 10 {
+		return ""
+	}
+	return code
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+)
+
+// newTestMFAService returns an MFAService with a fresh secret key whose clock stands at now
+func newTestMFAService(t *testing.T, now time.Time) *MFAService {
+	t.Helper()
+	key, err := utils.NewSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &MFAService{secretKey: key, totp: utils.DefaultTOTPConfig, now: func() time.Time { return now }}
+}
+
+func sealTestSecret(t *testing.T, ms *MFAService, userID uint, secret string) string {
+	t.Helper()
+	sealed, err := utils.SealSecret(ms.secretKey, secret, mfaSecretContext(userID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sealed
+}
+
+func TestMFACheckCodeRefusesReplay(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := utils.DecodeTOTPSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	ms := newTestMFAService(t, now)
+	mfa := &models.UserMFA{UserID: 1, Secret: sealTestSecret(t, ms, 1, secret), Enabled: true}
+	code := utils.TOTP(raw, now, utils.DefaultTOTPConfig)
+
+	counter, err := ms.checkCode(mfa, code)
+	if err != nil {
+		t.Fatalf("first use of the code: %v", err)
+	}
+	if want := int64(utils.TOTPCounter(now, utils.DefaultTOTPConfig)); counter != want {
+		t.Fatalf("counter = %d, want %d", counter, want)
+	}
+
+	// Verify stores the counter, the same code must not work again
+	mfa.LastUsedCounter = counter
+	if _, err := ms.checkCode(mfa, code); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("replayed code: err = %v, want ErrInvalidMFACode", err)
+	}
+
+	// Neither must the code of the previous step, still inside the skew window
+	previous := utils.TOTP(raw, now.Add(-utils.DefaultTOTPConfig.Period), utils.DefaultTOTPConfig)
+	if _, err := ms.checkCode(mfa, previous); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("code of an earlier step: err = %v, want ErrInvalidMFACode", err)
+	}
+
+	// The code of the next step is still accepted
+	next := utils.TOTP(raw, now.Add(utils.DefaultTOTPConfig.Period), utils.DefaultTOTPConfig)
+	if _, err := ms.checkCode(mfa, next); err != nil {
+		t.Fatalf("code of the next step: %v", err)
+	}
+}
+
+func TestVerifyChallengeIsSingleUse(t *testing.T) {
+	setupTestDB(t, &models.User{}, &models.UserMFA{}, &models.MFARecoveryCode{}, &models.UsedMFAChallenge{})
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := utils.DecodeTOTPSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := createTestUser(t, models.RoleAdmin, "admin@acme.test")
+	now := time.Unix(1700000000, 0)
+	ms := newTestMFAService(t, now)
+	if err := database.DB.Create(&models.UserMFA{UserID: user.ID, Secret: sealTestSecret(t, ms, user.ID, secret), Enabled: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	expires := now.Add(utils.MFATokenDuration)
+
+	// A mistyped code does not use up the challenge
+	if err := ms.VerifyChallenge(user.ID, "challenge-1", expires, "000000"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("wrong code: err = %v, want ErrInvalidMFACode", err)
+	}
+	if err := ms.VerifyChallenge(user.ID, "challenge-1", expires, utils.TOTP(raw, now, utils.DefaultTOTPConfig)); err != nil {
+		t.Fatalf("right code after a wrong one: %v", err)
+	}
+
+	// A fresh, valid code cannot bring the used challenge back to life
+	next := utils.TOTP(raw, now.Add(utils.DefaultTOTPConfig.Period), utils.DefaultTOTPConfig)
+	if err := ms.VerifyChallenge(user.ID, "challenge-1", expires, next); !errors.Is(err, ErrMFAChallengeUsed) {
+		t.Fatalf("replayed challenge: err = %v, want ErrMFAChallengeUsed", err)
+	}
+
+	// The rejected replay did not burn the code either
+	if err := ms.VerifyChallenge(user.ID, "challenge-2", expires, next); err != nil {
+		t.Fatalf("new challenge: %v", err)
+	}
+}
+
+func TestTOTPSecretsAreStoredEncrypted(t *testing.T) {
+	setupTestDB(t, &models.User{}, &models.UserMFA{})
+
+	now := time.Unix(1700000000, 0)
+	ms := newTestMFAService(t, now)
+
+	user := createTestUser(t, models.RoleAdmin, "admin@acme.test")
+	secret, _, err := ms.BeginEnrollment(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stored models.UserMFA
+	if err := database.DB.Where("user_id = ?", user.ID).First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !utils.IsSealedSecret(stored.Secret) || strings.Contains(stored.Secret, secret) {
+		t.Fatalf("stored secret = %q, want it encrypted", stored.Secret)
+	}
+
+	// The app set up with the returned secret produces codes the service accepts
+	raw, err := utils.DecodeTOTPSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.checkCode(&stored, utils.TOTP(raw, now, utils.DefaultTOTPConfig)); err != nil {
+		t.Fatalf("code of the enrolled app: %v", err)
+	}
+
+	// Secrets stored before encryption are sealed at startup and keep working
+	other := createTestUser(t, models.RoleAdmin, "other@acme.test")
+	legacy, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.DB.Create(&models.UserMFA{UserID: other.ID, Secret: legacy, Enabled: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := sealPlaintextSecrets(ms.secretKey); err != nil {
+		t.Fatal(err)
+	}
+
+	var migrated models.UserMFA
+	if err := database.DB.Where("user_id = ?", other.ID).First(&migrated).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !utils.IsSealedSecret(migrated.Secret) {
+		t.Fatalf("legacy secret = %q, want it encrypted", migrated.Secret)
+	}
+	legacyRaw, err := utils.DecodeTOTPSecret(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.checkCode(&migrated, utils.TOTP(legacyRaw, now, utils.DefaultTOTPConfig)); err != nil {
+		t.Fatalf("code of a legacy secret: %v", err)
+	}
+
+	// Already sealed secrets are left alone
+	if err := sealPlaintextSecrets(ms.secretKey); err != nil {
+		t.Fatal(err)
+	}
+	var again models.UserMFA
+	if err := database.DB.Where("user_id = ?", user.ID).First(&again).Error; err != nil {
+		t.Fatal(err)
+	}
+	if again.Secret != stored.Secret {
+		t.Fatal("sealed secret was sealed again")
+	}
+}
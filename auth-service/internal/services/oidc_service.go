@@ -0,0 +1,510 @@
+package services
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/karan-bishtt/auth-service/config"
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// oidcStateTTL is how long the user has to sign in at the identity provider
+	oidcStateTTL = 10 * time.Minute
+	// oidcCacheTTL is how long the discovery document and keys are trusted before refetching
+	oidcCacheTTL = time.Hour
+	// oidcMinRefreshInterval limits key refetches triggered by unknown kids
+	oidcMinRefreshInterval = 30 * time.Second
+)
+
+var (
+	ErrOIDCDisabled     = errors.New("single sign-on is not configured")
+	ErrInvalidOIDCState = errors.New("invalid or expired sign-on attempt")
+	ErrInvalidIDToken   = errors.New("invalid ID token")
+	ErrOIDCEmailMissing = errors.New("identity provider did not return a verified email")
+)
+
+// OIDCClaims is the identity asserted by the ID token. EmailVerified is only set
+// when the provider explicitly asserts email_verified.
+type OIDCClaims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+	Groups        []string
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCService signs admins in through the corporate identity provider using the
+// authorization code flow with PKCE
+type OIDCService struct {
+	issuerURL    string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       string
+	groupsClaim  string
+	roleMapping  map[string]models.Role
+	client       *http.Client
+
+	mu            sync.RWMutex
+	discovery     *oidcDiscovery
+	discoveredAt  time.Time
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewOIDCService() *OIDCService {
+	cfg := config.Load()
+	return &OIDCService{
+		issuerURL:    strings.TrimSuffix(cfg.OIDCIssuerURL, "/"),
+		clientID:     cfg.OIDCClientID,
+		clientSecret: cfg.OIDCClientSecret,
+		redirectURL:  cfg.OIDCRedirectURL,
+		scopes:       cfg.OIDCScopes,
+		groupsClaim:  cfg.OIDCGroupsClaim,
+		roleMapping:  parseGroupRoleMapping(cfg.OIDCGroupRoleMapping),
+		client:       &http.Client{Timeout: 10 * time.Second},
+		keys:         make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Enabled reports whether an identity provider is configured
+func (oc *OIDCService) Enabled() bool {
+	return oc.issuerURL != "" && oc.clientID != ""
+}
+
+// AuthorizationURL starts a sign-on attempt and returns where to send the browser.
+// The state, nonce and PKCE verifier are kept until the callback comes back.
+func (oc *OIDCService) AuthorizationURL() (string, error) {
+	if !oc.Enabled() {
+		return "", ErrOIDCDisabled
+	}
+
+	discovery, err := oc.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	state, err := utils.NewSecureToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := utils.NewSecureToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := utils.NewSecureToken()
+	if err != nil {
+		return "", err
+	}
+
+	loginState := models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}
+	if err := database.DB.Create(&loginState).Error; err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {oc.clientID},
+		"redirect_uri":          {oc.redirectURL},
+		"scope":                 {oc.scopes},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange completes a sign-on attempt: it redeems the code at the token endpoint
+// and returns the identity from the verified ID token. A state can only be used once.
+func (oc *OIDCService) Exchange(code, state string) (*OIDCClaims, error) {
+	if !oc.Enabled() {
+		return nil, ErrOIDCDisabled
+	}
+
+	loginState, err := consumeOIDCState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	discovery, err := oc.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {oc.redirectURL},
+		"client_id":     {oc.clientID},
+		"code_verifier": {loginState.CodeVerifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if oc.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(oc.clientID), url.QueryEscape(oc.clientSecret))
+	}
+
+	resp, err := oc.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach token endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tokens.IDToken == "" {
+		log.Printf("OIDC token endpoint returned status %d: %s %s", resp.StatusCode, tokens.Error, tokens.ErrorDescription)
+		return nil, ErrInvalidIDToken
+	}
+
+	return oc.verifyIDToken(tokens.IDToken, discovery.Issuer, loginState.Nonce)
+}
+
+// RoleFor maps the groups of the user to a local role. Only admins sign on
+// through the identity provider, so admin is the only role that can be granted.
+func (oc *OIDCService) RoleFor(groups []string) (models.Role, bool) {
+	for _, group := range groups {
+		if role, ok := oc.roleMapping[group]; ok {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+// FindUser returns the user linked to the identity, falling back to a user with
+// the same email when the provider verified it. It returns nil when no local
+// account exists yet.
+func (oc *OIDCService) FindUser(identity *OIDCClaims) (*models.User, error) {
+	var link models.OIDCIdentity
+	err := database.DB.Where("issuer = ? AND subject = ?", identity.Issuer, identity.Subject).First(&link).Error
+	if err == nil {
+		var user models.User
+		if err := database.DB.First(&user, link.UserID).Error; err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Anyone can claim any email at a provider that does not verify it, only
+	// (issuer, subject) identifies such an identity
+	if !identity.EmailVerified {
+		return nil, nil
+	}
+
+	var user models.User
+	if err := database.DB.Where("LOWER(email) = ?", identity.Email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// LinkIdentity records that the identity signs in as userID
+func (oc *OIDCService) LinkIdentity(tx *gorm.DB, userID uint, identity *OIDCClaims) error {
+	now := time.Now()
+	link := models.OIDCIdentity{
+		UserID:      userID,
+		Issuer:      identity.Issuer,
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		LastLoginAt: now,
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "issuer"}, {Name: "subject"}},
+		DoUpdates: clause.AssignmentColumns([]string{"email", "last_login_at"}),
+	}).Create(&link).Error
+}
+
+// consumeOIDCState deletes the pending attempt for state and returns it
+func consumeOIDCState(state string) (*models.OIDCLoginState, error) {
+	var loginState models.OIDCLoginState
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("state_hash = ?", utils.HashToken(state)).
+			First(&loginState).Error; err != nil {
+			return ErrInvalidOIDCState
+		}
+		return tx.Delete(&loginState).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(loginState.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+	return &loginState, nil
+}
+
+func (oc *OIDCService) verifyIDToken(idToken, issuer, nonce string) (*OIDCClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return oc.publicKey(kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(oc.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		log.Printf("OIDC ID token rejected: %v", err)
+		return nil, ErrInvalidIDToken
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, ErrInvalidIDToken
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	email, _ := claims["email"].(string)
+	if email == "" {
+		return nil, ErrOIDCEmailMissing
+	}
+
+	// An email the provider does not assert as verified must not match, take over
+	// or create a local account
+	verified, _ := claims["email_verified"].(bool)
+
+	identity := &OIDCClaims{
+		Issuer:        issuer,
+		Subject:       subject,
+		Email:         strings.ToLower(email),
+		EmailVerified: verified,
+		Groups:        stringList(claims[oc.groupsClaim]),
+	}
+	identity.FirstName, _ = claims["given_name"].(string)
+	identity.LastName, _ = claims["family_name"].(string)
+	if identity.FirstName == "" {
+		name, _ := claims["name"].(string)
+		identity.FirstName, identity.LastName, _ = strings.Cut(strings.TrimSpace(name), " ")
+	}
+	if identity.FirstName == "" {
+		identity.FirstName, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	return identity, nil
+}
+
+// getDiscovery returns the provider metadata from /.well-known/openid-configuration
+func (oc *OIDCService) getDiscovery() (*oidcDiscovery, error) {
+	oc.mu.RLock()
+	discovery := oc.discovery
+	age := time.Since(oc.discoveredAt)
+	oc.mu.RUnlock()
+
+	if discovery != nil && age < oidcCacheTTL {
+		return discovery, nil
+	}
+
+	var fetched oidcDiscovery
+	if err := oc.getJSON(oc.issuerURL+"/.well-known/openid-configuration", &fetched); err != nil {
+		// Keep using the known metadata if the provider is briefly unreachable
+		if discovery != nil {
+			return discovery, nil
+		}
+		return nil, err
+	}
+
+	if strings.TrimSuffix(fetched.Issuer, "/") != oc.issuerURL {
+		return nil, fmt.Errorf("discovery document issuer %q does not match %q", fetched.Issuer, oc.issuerURL)
+	}
+	if fetched.AuthorizationEndpoint == "" || fetched.TokenEndpoint == "" || fetched.JWKSURI == "" {
+		return nil, errors.New("discovery document is incomplete")
+	}
+
+	oc.mu.Lock()
+	oc.discovery = &fetched
+	oc.discoveredAt = time.Now()
+	oc.mu.Unlock()
+
+	return &fetched, nil
+}
+
+// publicKey returns the provider key for kid, refetching the JWKS when the cache
+// expired or the kid is unknown (e.g. right after the provider rotated its keys)
+func (oc *OIDCService) publicKey(kid string) (*rsa.PublicKey, error) {
+	oc.mu.RLock()
+	key, ok := oc.keys[kid]
+	age := time.Since(oc.keysFetchedAt)
+	oc.mu.RUnlock()
+
+	if ok && age < oidcCacheTTL {
+		return key, nil
+	}
+	if !ok && age < oidcMinRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if err := oc.refreshKeys(); err != nil {
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	oc.mu.RLock()
+	defer oc.mu.RUnlock()
+	if key, ok := oc.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (oc *OIDCService) refreshKeys() error {
+	discovery, err := oc.getDiscovery()
+	if err != nil {
+		return err
+	}
+
+	var jwks utils.JWKS
+	if err := oc.getJSON(discovery.JWKSURI, &jwks); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.KTY != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := parseRSAPublicKey(k)
+		if err != nil {
+			continue
+		}
+		keys[k.KID] = key
+	}
+
+	oc.mu.Lock()
+	oc.keys = keys
+	oc.keysFetchedAt = time.Now()
+	oc.mu.Unlock()
+
+	return nil
+}
+
+func (oc *OIDCService) getJSON(endpoint string, v interface{}) error {
+	resp, err := oc.client.Get(endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status: %d", endpoint, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+func parseRSAPublicKey(k utils.JWK) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// parseGroupRoleMapping reads "group=role,other-group=role". Entries mapping to
+// anything but admin are ignored, vendors cannot sign on through the provider.
+func parseGroupRoleMapping(mapping string) map[string]models.Role {
+	roles := make(map[string]models.Role)
+	for _, entry := range strings.Split(mapping, ",") {
+		group, role, found := strings.Cut(strings.TrimSpace(entry), "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !found || group == "" {
+			continue
+		}
+		if models.Role(role) != models.RoleAdmin {
+			log.Printf("Ignoring OIDC group mapping %q: only the admin role can be granted", entry)
+			continue
+		}
+		roles[group] = models.RoleAdmin
+	}
+	return roles
+}
+
+// stringList accepts a claim holding either a list of strings or a single string
+func stringList(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
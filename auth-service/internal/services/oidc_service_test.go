@@ -0,0 +1,367 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+)
+
+const testOIDCClientID = "rfp-client"
+
+// fakeIdP is an identity provider serving discovery, JWKS and a token endpoint that
+// enforces PKCE. Authorizations are registered by the test instead of a browser.
+type fakeIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]fakeAuthorization
+	nextID int
+}
+
+type fakeAuthorization struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdP{key: key, codes: make(map[string]fakeAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                idp.server.URL,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JWKSURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(utils.JWKS{Keys: []utils.JWK{{
+			KTY: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			KID: "idp-key",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", idp.token)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != testOIDCClientID {
+		tokenError(w, "invalid_request")
+		return
+	}
+
+	idp.mu.Lock()
+	authorization, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+	if !ok {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(verifier[:]) != authorization.challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, authorization.claims)
+	token.Header["kid"] = "idp-key"
+	idToken, err := token.SignedString(idp.key)
+	if err != nil {
+		tokenError(w, "server_error")
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "token_type": "Bearer"})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+// authorize plays the browser round trip: it starts a sign-on attempt and registers
+// a code for it at the provider. edit changes the claims of the ID token the code
+// redeems for. It returns the code and state the provider redirects back with.
+func (idp *fakeIdP) authorize(t *testing.T, oc *OIDCService, edit func(jwt.MapClaims)) (code, state string) {
+	t.Helper()
+
+	authorizationURL, err := oc.AuthorizationURL()
+	if err != nil {
+		t.Fatalf("AuthorizationURL: %v", err)
+	}
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("code_challenge_method = %q, want S256", query.Get("code_challenge_method"))
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            "idp-user-1",
+		"aud":            testOIDCClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          query.Get("nonce"),
+		"email":          "Admin@Example.com",
+		"email_verified": true,
+		"given_name":     "Ada",
+		"family_name":    "Admin",
+		"groups":         []string{"staff", "rfp-admins"},
+	}
+	if edit != nil {
+		edit(claims)
+	}
+
+	idp.mu.Lock()
+	idp.nextID++
+	code = fmt.Sprintf("code-%d", idp.nextID)
+	idp.codes[code] = fakeAuthorization{challenge: query.Get("code_challenge"), claims: claims}
+	idp.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+func newTestOIDCService(idp *fakeIdP) *OIDCService {
+	return &OIDCService{
+		issuerURL:   idp.server.URL,
+		clientID:    testOIDCClientID,
+		redirectURL: "http://localhost/auth/callback",
+		scopes:      "openid email profile",
+		groupsClaim: "groups",
+		roleMapping: parseGroupRoleMapping("rfp-admins=admin"),
+		client:      idp.server.Client(),
+		keys:        make(map[string]*rsa.PublicKey),
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	setupTestDB(t, &models.OIDCLoginState{})
+	idp := newFakeIdP(t)
+	oc := newTestOIDCService(idp)
+
+	code, state := idp.authorize(t, oc, nil)
+	identity, err := oc.Exchange(code, state)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	if identity.Issuer != idp.server.URL || identity.Subject != "idp-user-1" {
+		t.Errorf("identity = %s %s, want %s idp-user-1", identity.Issuer, identity.Subject, idp.server.URL)
+	}
+	if identity.Email != "admin@example.com" || !identity.EmailVerified {
+		t.Errorf("email = %q verified %v, want admin@example.com verified", identity.Email, identity.EmailVerified)
+	}
+	if identity.FirstName != "Ada" || identity.LastName != "Admin" {
+		t.Errorf("name = %q %q, want Ada Admin", identity.FirstName, identity.LastName)
+	}
+	if role, ok := oc.RoleFor(identity.Groups); !ok || role != models.RoleAdmin {
+		t.Errorf("RoleFor(%v) = %q %v, want admin", identity.Groups, role, ok)
+	}
+
+	// The state was used up by the first callback
+	if _, err := oc.Exchange(code, state); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("replayed state: err = %v, want ErrInvalidOIDCState", err)
+	}
+}
+
+func TestOIDCExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(jwt.MapClaims)
+	}{
+		{"nonce mismatch", func(c jwt.MapClaims) { c["nonce"] = "another-nonce" }},
+		{"missing nonce", func(c jwt.MapClaims) { delete(c, "nonce") }},
+		{"expired", func(c jwt.MapClaims) {
+			c["iat"] = time.Now().Add(-time.Hour).Unix()
+			c["exp"] = time.Now().Add(-10 * time.Minute).Unix()
+		}},
+		{"missing expiry", func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"missing subject", func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t, &models.OIDCLoginState{})
+			idp := newFakeIdP(t)
+			oc := newTestOIDCService(idp)
+
+			code, state := idp.authorize(t, oc, tt.edit)
+			if _, err := oc.Exchange(code, state); !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("err = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestOIDCExchangeRejectsUnknownState(t *testing.T) {
+	setupTestDB(t, &models.OIDCLoginState{})
+	idp := newFakeIdP(t)
+	oc := newTestOIDCService(idp)
+
+	code, _ := idp.authorize(t, oc, nil)
+	if _, err := oc.Exchange(code, "forged-state"); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("err = %v, want ErrInvalidOIDCState", err)
+	}
+}
+
+func TestOIDCExchangeRejectsExpiredState(t *testing.T) {
+	setupTestDB(t, &models.OIDCLoginState{})
+	idp := newFakeIdP(t)
+	oc := newTestOIDCService(idp)
+
+	code, state := idp.authorize(t, oc, nil)
+	if err := database.DB.Model(&models.OIDCLoginState{}).Where("1 = 1").
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oc.Exchange(code, state); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("err = %v, want ErrInvalidOIDCState", err)
+	}
+}
+
+func TestOIDCExchangeRejectsPKCEMismatch(t *testing.T) {
+	setupTestDB(t, &models.OIDCLoginState{})
+	idp := newFakeIdP(t)
+	oc := newTestOIDCService(idp)
+
+	// A code issued to one attempt injected into the callback of another is
+	// redeemed with the wrong verifier
+	code, _ := idp.authorize(t, oc, nil)
+	_, otherState := idp.authorize(t, oc, nil)
+	if _, err := oc.Exchange(code, otherState); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("err = %v, want ErrInvalidIDToken", err)
+	}
+}
+
+func TestOIDCExchangeRequiresEmail(t *testing.T) {
+	setupTestDB(t, &models.OIDCLoginState{})
+	idp := newFakeIdP(t)
+	oc := newTestOIDCService(idp)
+
+	code, state := idp.authorize(t, oc, func(c jwt.MapClaims) { delete(c, "email") })
+	if _, err := oc.Exchange(code, state); !errors.Is(err, ErrOIDCEmailMissing) {
+		t.Errorf("err = %v, want ErrOIDCEmailMissing", err)
+	}
+}
+
+func TestOIDCFindUserRequiresVerifiedEmail(t *testing.T) {
+	setupTestDB(t, &models.OIDCLoginState{}, &models.OIDCIdentity{}, &models.User{})
+	idp := newFakeIdP(t)
+	oc := newTestOIDCService(idp)
+	local := createTestUser(t, models.RoleAdmin, "admin@example.com")
+
+	tests := []struct {
+		name     string
+		edit     func(jwt.MapClaims)
+		wantUser bool
+	}{
+		{"verified email", nil, true},
+		{"email_verified absent", func(c jwt.MapClaims) { delete(c, "email_verified") }, false},
+		{"email_verified false", func(c jwt.MapClaims) { c["email_verified"] = false }, false},
+		{"email_verified not a boolean", func(c jwt.MapClaims) { c["email_verified"] = "true" }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, state := idp.authorize(t, oc, tt.edit)
+			identity, err := oc.Exchange(code, state)
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+
+			user, err := oc.FindUser(identity)
+			if err != nil {
+				t.Fatalf("FindUser: %v", err)
+			}
+			if got := user != nil && user.ID == local.ID; got != tt.wantUser {
+				t.Errorf("matched the local account = %v, want %v", got, tt.wantUser)
+			}
+		})
+	}
+
+	// Once linked, the identity is found by issuer and subject alone
+	code, state := idp.authorize(t, oc, func(c jwt.MapClaims) { delete(c, "email_verified") })
+	identity, err := oc.Exchange(code, state)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if err := oc.LinkIdentity(database.DB, local.ID, identity); err != nil {
+		t.Fatalf("LinkIdentity: %v", err)
+	}
+	user, err := oc.FindUser(identity)
+	if err != nil || user == nil || user.ID != local.ID {
+		t.Errorf("FindUser of a linked identity = %v, %v, want user %d", user, err, local.ID)
+	}
+}
+
+func TestOIDCGroupRoleMapping(t *testing.T) {
+	oc := &OIDCService{
+		roleMapping: parseGroupRoleMapping(" rfp-admins = admin, procurement=admin,buyers=vendor,=admin,broken"),
+	}
+
+	tests := []struct {
+		groups   []string
+		wantRole models.Role
+		wantOK   bool
+	}{
+		{[]string{"rfp-admins"}, models.RoleAdmin, true},
+		{[]string{"staff", "procurement"}, models.RoleAdmin, true},
+		{[]string{"buyers"}, "", false}, // only admin can be granted
+		{[]string{"staff"}, "", false},
+		{nil, "", false},
+	}
+
+	for _, tt := range tests {
+		role, ok := oc.RoleFor(tt.groups)
+		if role != tt.wantRole || ok != tt.wantOK {
+			t.Errorf("RoleFor(%v) = %q %v, want %q %v", tt.groups, role, ok, tt.wantRole, tt.wantOK)
+		}
+	}
+
+	// Providers send a single group as a plain string
+	if groups := stringList("rfp-admins"); len(groups) != 1 || groups[0] != "rfp-admins" {
+		t.Errorf("stringList(string) = %v", groups)
+	}
+	if groups := stringList([]interface{}{"a", 1, "b"}); len(groups) != 2 || groups[0] != "a" || groups[1] != "b" {
+		t.Errorf("stringList(list) = %v", groups)
+	}
+}
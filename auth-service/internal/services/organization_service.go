@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrOrganizationExists    = errors.New("an organization with this slug already exists")
+	ErrInvalidSlug           = errors.New("slug may only contain lowercase letters, digits and dashes")
+	ErrNotOrganizationMember = errors.New("user does not belong to this organization")
+	ErrNoOrganization        = errors.New("user does not belong to any active organization")
+	ErrAlreadyMember         = errors.New("vendor already belongs to this organization")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// OrganizationService manages buyer organizations and decides which one a user acts for
+type OrganizationService struct{}
+
+func NewOrganizationService() *OrganizationService {
+	return &OrganizationService{}
+}
+
+// List returns every organization, active or not
+func (ors *OrganizationService) List() ([]models.Organization, error) {
+	var orgs []models.Organization
+	err := database.DB.Order("name ASC, id ASC").Find(&orgs).Error
+	return orgs, err
+}
+
+// Get returns the organization with the given ID, active or not
+func (ors *OrganizationService) Get(id uint) (*models.Organization, error) {
+	var org models.Organization
+	if err := database.DB.First(&org, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+// GetActiveBySlug returns the active organization with the given slug
+func (ors *OrganizationService) GetActiveBySlug(slug string) (*models.Organization, error) {
+	var org models.Organization
+	if err := database.DB.Where("slug = ? AND is_active = ?", strings.ToLower(strings.TrimSpace(slug)), true).
+		First(&org).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+// Create adds a new active organization
+func (ors *OrganizationService) Create(name, slug string) (*models.Organization, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !slugPattern.MatchString(slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	org := models.Organization{Name: strings.TrimSpace(name), Slug: slug, IsActive: true}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&org)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrOrganizationExists
+	}
+	return &org, nil
+}
+
+// Update renames or (de)activates an organization. Users of an inactive
+// organization can no longer log in to it.
+func (ors *OrganizationService) Update(id uint, name *string, isActive *bool) (*models.Organization, error) {
+	org, err := ors.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if name != nil {
+		updates["name"] = strings.TrimSpace(*name)
+	}
+	if isActive != nil {
+		updates["is_active"] = *isActive
+	}
+	if len(updates) == 0 {
+		return org, nil
+	}
+	if err := database.DB.Model(org).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// Membership returns the membership of the vendor in an active organization
+func (ors *OrganizationService) Membership(db *gorm.DB, orgID, vendorID uint) (*models.VendorOrganization, error) {
+	var membership models.VendorOrganization
+	if err := db.Joins("JOIN organizations ON organizations.id = vendor_organizations.organization_id").
+		Where("vendor_organizations.organization_id = ? AND vendor_organizations.user_id = ?", orgID, vendorID).
+		Where("organizations.is_active = ?", true).
+		Preload("Organization").
+		First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotOrganizationMember
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// Memberships returns every organization the vendor belongs to
+func (ors *OrganizationService) Memberships(vendorID uint) ([]models.VendorOrganization, error) {
+	var memberships []models.VendorOrganization
+	err := database.DB.Where("user_id = ?", vendorID).
+		Preload("Organization").
+		Order("id ASC").
+		Find(&memberships).Error
+	return memberships, err
+}
+
+// Join adds a pending membership of the vendor to an organization, which then
+// reviews the vendor like a new registration
+func (ors *OrganizationService) Join(tx *gorm.DB, orgID, vendorID uint) (*models.VendorOrganization, error) {
+	membership := models.VendorOrganization{
+		UserID:         vendorID,
+		OrganizationID: orgID,
+		Status:         models.VendorPending,
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&membership)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrAlreadyMember
+	}
+	return &membership, nil
+}
+
+// HasMember reports whether the user is an admin of, or a vendor in, the organization
+func (ors *OrganizationService) HasMember(orgID, userID uint) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.User{}).
+		Where("id = ?", userID).
+		Where("organization_id = ? OR EXISTS (SELECT 1 FROM vendor_organizations WHERE vendor_organizations.user_id = users.id AND vendor_organizations.organization_id = ?)", orgID, orgID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ResolveTenant returns the organization the user acts for after logging in.
+// Admins always act for their own organization. Vendors act for requested when
+// given, otherwise for the first organization that approved them, or failing
+// that the first one they joined.
+func (ors *OrganizationService) ResolveTenant(db *gorm.DB, user *models.User, requested uint) (uint, error) {
+	if user.Role != models.RoleVendor {
+		if user.OrganizationID == nil {
+			return 0, ErrNoOrganization
+		}
+		if requested != 0 && requested != *user.OrganizationID {
+			return 0, ErrNotOrganizationMember
+		}
+		var count int64
+		if err := db.Model(&models.Organization{}).
+			Where("id = ? AND is_active = ?", *user.OrganizationID, true).
+			Count(&count).Error; err != nil {
+			return 0, err
+		}
+		if count == 0 {
+			return 0, ErrNoOrganization
+		}
+		return *user.OrganizationID, nil
+	}
+
+	if requested != 0 {
+		if _, err := ors.Membership(db, requested, user.ID); err != nil {
+			return 0, err
+		}
+		return requested, nil
+	}
+
+	var membership models.VendorOrganization
+	if err := db.Joins("JOIN organizations ON organizations.id = vendor_organizations.organization_id").
+		Where("vendor_organizations.user_id = ? AND organizations.is_active = ?", user.ID, true).
+		Order(clause.Expr{SQL: "CASE WHEN vendor_organizations.status = ? THEN 0 ELSE 1 END, vendor_organizations.id", Vars: []interface{}{models.VendorApproved}}).
+		First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrNoOrganization
+		}
+		return 0, err
+	}
+	return membership.OrganizationID, nil
+}
+
+// organizationVendors selects the IDs of the vendors belonging to the organization
+func organizationVendors(db *gorm.DB, orgID uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Model(&models.VendorOrganization{}).Select("user_id").Where("organization_id = ?", orgID)
+}
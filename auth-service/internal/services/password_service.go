@@ -0,0 +1,92 @@
+package services
+
+import (
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+// PasswordService applies the password policy and keeps the password history
+type PasswordService struct {
+	policy utils.PasswordPolicy
+}
+
+func NewPasswordService() *PasswordService {
+	return &PasswordService{
+		policy: utils.LoadPasswordPolicy(),
+	}
+}
+
+// Validate checks a new password against the policy. For an existing user it also
+// refuses the current password and the last HistorySize passwords.
+func (ps *PasswordService) Validate(db *gorm.DB, password string, user *models.User) ([]utils.PasswordViolation, error) {
+	violations := ps.policy.Check(password, user.Email, user.FirstName, user.LastName)
+
+	if user.ID == 0 || ps.policy.HistorySize <= 0 {
+		return violations, nil
+	}
+
+	reused := user.Password != "" && utils.CheckPasswordHash(password, user.Password)
+	if !reused {
+		var history []models.PasswordHistory
+		if err := db.Where("user_id = ?", user.ID).
+			Order("created_at DESC").
+			Limit(ps.policy.HistorySize).
+			Find(&history).Error; err != nil {
+			return nil, err
+		}
+		for _, entry := range history {
+			if utils.CheckPasswordHash(password, entry.PasswordHash) {
+				reused = true
+				break
+			}
+		}
+	}
+
+	if reused {
+		violations = append(violations, utils.PasswordViolation{
+			Rule:    utils.PasswordRuleHistory,
+			Message: "must not be one of your recent passwords",
+		})
+	}
+
+	return violations, nil
+}
+
+// RecordPassword adds the user's current password hash to the history and
+// drops entries beyond HistorySize
+func (ps *PasswordService) RecordPassword(tx *gorm.DB, userID uint, passwordHash string) error {
+	if ps.policy.HistorySize <= 0 {
+		return nil
+	}
+
+	if err := tx.Create(&models.PasswordHistory{UserID: userID, PasswordHash: passwordHash}).Error; err != nil {
+		return err
+	}
+
+	var keep []uint
+	if err := tx.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(ps.policy.HistorySize).
+		Pluck("id", &keep).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("user_id = ? AND id NOT IN ?", userID, keep).Delete(&models.PasswordHistory{}).Error
+}
+
+// SetPassword replaces the user's password and records it in the history.
+// Validate the password first.
+func (ps *PasswordService) SetPassword(tx *gorm.DB, user *models.User, password string) error {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(user).Update("password", hashedPassword).Error; err != nil {
+		return err
+	}
+
+	return ps.RecordPassword(tx, user.ID, hashedPassword)
+}
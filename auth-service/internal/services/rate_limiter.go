@@ -0,0 +1,50 @@
+package services
+
+import (
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RateLimiter counts requests per key in fixed windows stored in the database,
+// so limits hold across restarts and multiple instances
+type RateLimiter struct{}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{}
+}
+
+// Allow counts a request for key and reports whether it is within limit requests
+// per window. When it is not, retryAfter tells when the window resets.
+func (rl *RateLimiter) Allow(key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration, err error) {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RateLimit{Key: key, WindowStart: now}).Error; err != nil {
+			return err
+		}
+
+		var rateLimit models.RateLimit
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&rateLimit).Error; err != nil {
+			return err
+		}
+
+		if now.Sub(rateLimit.WindowStart) >= window {
+			rateLimit.WindowStart = now
+			rateLimit.Count = 0
+		}
+
+		if rateLimit.Count >= limit {
+			retryAfter = rateLimit.WindowStart.Add(window).Sub(now)
+			return nil
+		}
+
+		rateLimit.Count++
+		allowed = true
+		return tx.Save(&rateLimit).Error
+	})
+	return allowed, retryAfter, err
+}
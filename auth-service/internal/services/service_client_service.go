@@ -0,0 +1,143 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidClient         = errors.New("invalid client credentials")
+	ErrInvalidScope          = errors.New("invalid scope")
+	ErrServiceClientNotFound = errors.New("service client not found")
+)
+
+// ServiceClientService manages the credentials internal services use to call each other
+type ServiceClientService struct{}
+
+func NewServiceClientService() *ServiceClientService {
+	return &ServiceClientService{}
+}
+
+// Create registers a client with the given scopes and returns it with the plain secret.
+// Only the secret hash is stored, so the secret cannot be shown again later.
+func (ss *ServiceClientService) Create(name string, scopes []string, createdBy *uint) (*models.ServiceClient, string, error) {
+	scope, err := normalizeScopes(scopes, utils.ServiceScopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	clientID, err := utils.NewRandomID()
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := utils.NewSecureToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	client := models.ServiceClient{
+		Name:       strings.TrimSpace(name),
+		ClientID:   clientID,
+		SecretHash: utils.HashToken(secret),
+		Scopes:     scope,
+		CreatedBy:  createdBy,
+	}
+	if err := database.DB.Create(&client).Error; err != nil {
+		return nil, "", err
+	}
+
+	return &client, secret, nil
+}
+
+// Authenticate checks the credentials and returns the scopes granted for this
+// token: all scopes of the client, or the requested subset of them
+func (ss *ServiceClientService) Authenticate(clientID, secret, requestedScope string) (*models.ServiceClient, string, error) {
+	var client models.ServiceClient
+	if err := database.DB.Where("client_id = ? AND revoked_at IS NULL", clientID).First(&client).Error; err != nil {
+		return nil, "", ErrInvalidClient
+	}
+
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, "", ErrInvalidClient
+	}
+
+	scope := client.Scopes
+	if requested := strings.Fields(requestedScope); len(requested) > 0 {
+		var err error
+		if scope, err = normalizeScopes(requested, client.ScopeList()); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := database.DB.Model(&client).Update("last_used_at", time.Now()).Error; err != nil {
+		return nil, "", err
+	}
+
+	return &client, scope, nil
+}
+
+// List returns every client, newest first
+func (ss *ServiceClientService) List() ([]models.ServiceClient, error) {
+	var clients []models.ServiceClient
+	err := database.DB.Order("created_at DESC").Find(&clients).Error
+	return clients, err
+}
+
+// Revoke disables a client. Tokens already issued stay valid until they expire,
+// which is at most ServiceTokenDuration.
+func (ss *ServiceClientService) Revoke(id uint) (*models.ServiceClient, error) {
+	var client models.ServiceClient
+	if err := database.DB.First(&client, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrServiceClientNotFound
+		}
+		return nil, err
+	}
+
+	if client.RevokedAt == nil {
+		now := time.Now()
+		if err := database.DB.Model(&client).Update("revoked_at", &now).Error; err != nil {
+			return nil, err
+		}
+		client.RevokedAt = &now
+	}
+	return &client, nil
+}
+
+// normalizeScopes checks every scope is allowed and returns them space separated without duplicates
+func normalizeScopes(scopes, allowed []string) (string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if !containsString(allowed, scope) {
+			return "", fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		seen[scope] = true
+		result = append(result, scope)
+	}
+	if len(result) == 0 {
+		return "", fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	return strings.Join(result, " "), nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
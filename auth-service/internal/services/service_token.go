@@ -0,0 +1,45 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/config"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+)
+
+// serviceTokenRenewBefore renews cached tokens this long before they expire
+const serviceTokenRenewBefore = time.Minute
+
+type cachedServiceToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// serviceTokenCache holds the tokens auth-service signs for its own calls to other
+// services. It holds the signing keys, so it needs no client credentials of its own.
+type serviceTokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]cachedServiceToken
+}
+
+var ownServiceTokens = &serviceTokenCache{tokens: make(map[string]cachedServiceToken)}
+
+// get returns a token granted scope, signing a new one when the cached one is about to expire
+func (c *serviceTokenCache) get(scope string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.tokens[scope]; ok && time.Until(cached.expiresAt) > serviceTokenRenewBefore {
+		return cached.token, nil
+	}
+
+	cfg := config.Load()
+	token, err := utils.GenerateServiceToken(cfg.JWTIssuer, scope)
+	if err != nil {
+		return "", err
+	}
+	c.tokens[scope] = cachedServiceToken{token: token, expiresAt: time.Now().Add(utils.ServiceTokenDuration)}
+
+	return token, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupTestDB points database.DB at a fresh in-memory SQLite database holding the
+// tables of the given models. SQLite ignores FOR UPDATE, a single connection makes
+// transactions run one after the other instead, as row locks do on Postgres.
+func setupTestDB(t *testing.T, tables ...interface{}) {
+	t.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", name)), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		sqlDB.Close()
+	})
+}
+
+// createTestUser stores an active user with the given role and email
+func createTestUser(t *testing.T, role models.Role, email string) *models.User {
+	t.Helper()
+
+	user := models.User{
+		FirstName: "Test",
+		LastName:  "User",
+		Email:     email,
+		Password:  "Password1!",
+		Role:      role,
+		IsActive:  true,
+	}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return &user
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// ClientInfo describes the device a session was started or last used from
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+// TokenService issues token pairs and keeps track of sessions and their refresh tokens
+type TokenService struct {
+	organizationService *OrganizationService
+}
+
+func NewTokenService() *TokenService {
+	return &TokenService{
+		organizationService: NewOrganizationService(),
+	}
+}
+
+// IssueTokenPair issues tokens for a new login, starting a new session that acts
+// for the organization tenantID
+func (ts *TokenService) IssueTokenPair(userID uint, role string, tenantID uint, client ClientInfo) (refreshToken, accessToken string, err error) {
+	sessionID, err := utils.NewRandomID()
+	if err != nil {
+		return "", "", err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		session := models.UserSession{
+			SessionID:      sessionID,
+			UserID:         userID,
+			OrganizationID: &tenantID,
+			IPAddress:      client.IPAddress,
+			UserAgent:      truncate(client.UserAgent, 255),
+			LastUsedAt:     now,
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		refreshToken, accessToken, _, err = ts.issue(tx, userID, role, tenantID, sessionID)
+		return err
+	})
+	return refreshToken, accessToken, err
+}
+
+// RotateRefreshToken exchanges a refresh token for a new pair and invalidates it.
+// Presenting a token that was already rotated revokes its whole session.
+func (ts *TokenService) RotateRefreshToken(refreshTokenString string, client ClientInfo) (refreshToken, accessToken string, err error) {
+	claims, err := utils.ValidateRefreshToken(refreshTokenString)
+	if err != nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	reused := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_id = ?", claims.ID).
+			First(&stored).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		if stored.RevokedAt != nil {
+			if stored.IsRotated() {
+				reused = true
+			}
+			return ErrInvalidRefreshToken
+		}
+
+		if time.Now().After(stored.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		// Role and status come from the database, not from the old token
+		var user models.User
+		if err := tx.First(&user, stored.UserID).Error; err != nil || !user.IsActive {
+			return ErrInvalidRefreshToken
+		}
+
+		session, err := touchSession(tx, stored, client)
+		if err != nil {
+			return err
+		}
+
+		tenantID, err := ts.sessionTenant(tx, &user, session)
+		if err != nil {
+			return err
+		}
+
+		var newID uint
+		refreshToken, accessToken, newID, err = ts.issue(tx, user.ID, string(user.Role), tenantID, stored.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(&stored).Updates(map[string]interface{}{
+			"revoked_at":  &now,
+			"replaced_by": newID,
+		}).Error
+	})
+
+	if reused {
+		// Outside the rolled back transaction so the revocation sticks
+		if revokeErr := ts.revokeFamilyOf(claims.ID); revokeErr != nil {
+			return "", "", revokeErr
+		}
+		return "", "", ErrRefreshTokenReused
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return refreshToken, accessToken, nil
+}
+
+// RevokeRefreshToken logs out the session the refresh token belongs to
+func (ts *TokenService) RevokeRefreshToken(refreshTokenString string) error {
+	claims, err := utils.ValidateRefreshToken(refreshTokenString)
+	if err != nil {
+		return ErrInvalidRefreshToken
+	}
+
+	return ts.revokeFamilyOf(claims.ID)
+}
+
+// RevokeAllForUser logs out every session of the user
+func (ts *TokenService) RevokeAllForUser(userID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		return ts.RevokeAllForUserTx(tx, userID)
+	})
+}
+
+// RevokeAllForUserTx logs out every session of the user as part of tx, so a
+// password change and the revocation commit or fail together
+func (ts *TokenService) RevokeAllForUserTx(tx *gorm.DB, userID uint) error {
+	now := time.Now()
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+// RevokeForOrganization logs out the sessions the user started for one organization,
+// used when an organization blocks a vendor that other organizations still work with
+func (ts *TokenService) RevokeForOrganization(userID, orgID uint) error {
+	var familyIDs []string
+	if err := database.DB.Model(&models.UserSession{}).
+		Where("user_id = ? AND organization_id = ? AND revoked_at IS NULL", userID, orgID).
+		Pluck("session_id", &familyIDs).Error; err != nil {
+		return err
+	}
+
+	for _, familyID := range familyIDs {
+		if err := revokeFamily(familyID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListActiveSessions returns the sessions of the user that are neither revoked nor expired
+func (ts *TokenService) ListActiveSessions(userID uint) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	err := database.DB.Where("user_id = ? AND revoked_at IS NULL AND last_used_at > ?", userID, time.Now().Add(-utils.RefreshTokenDuration)).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession logs out one session of the user
+func (ts *TokenService) RevokeSession(userID, id uint) error {
+	var session models.UserSession
+	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	return revokeFamily(session.SessionID)
+}
+
+// revokeFamilyOf revokes the session of the given refresh token
+func (ts *TokenService) revokeFamilyOf(tokenID string) error {
+	var stored models.RefreshToken
+	if err := database.DB.Where("token_id = ?", tokenID).First(&stored).Error; err != nil {
+		return ErrInvalidRefreshToken
+	}
+
+	return revokeFamily(stored.FamilyID)
+}
+
+// revokeFamily revokes a session and every still valid refresh token issued for it
+func revokeFamily(familyID string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.UserSession{}).
+			Where("session_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// touchSession records the use of a session on refresh. Refresh tokens issued
+// before sessions were tracked get their session created here.
+func touchSession(tx *gorm.DB, stored models.RefreshToken, client ClientInfo) (*models.UserSession, error) {
+	now := time.Now()
+
+	var session models.UserSession
+	err := tx.Where("session_id = ?", stored.FamilyID).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		session = models.UserSession{
+			SessionID:  stored.FamilyID,
+			UserID:     stored.UserID,
+			IPAddress:  client.IPAddress,
+			UserAgent:  truncate(client.UserAgent, 255),
+			LastUsedAt: now,
+		}
+		return &session, tx.Create(&session).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if session.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return &session, tx.Model(&session).Updates(map[string]interface{}{
+		"ip_address":   client.IPAddress,
+		"user_agent":   truncate(client.UserAgent, 255),
+		"last_used_at": now,
+	}).Error
+}
+
+// sessionTenant returns the organization a refreshed session keeps acting for, after
+// checking the user still belongs to it and, for vendors, is not blocked there.
+// Sessions started before organizations existed are pinned to one here.
+func (ts *TokenService) sessionTenant(tx *gorm.DB, user *models.User, session *models.UserSession) (uint, error) {
+	var requested uint
+	if session.OrganizationID != nil {
+		requested = *session.OrganizationID
+	}
+
+	tenantID, err := ts.organizationService.ResolveTenant(tx, user, requested)
+	if err != nil {
+		if errors.Is(err, ErrNotOrganizationMember) || errors.Is(err, ErrNoOrganization) {
+			return 0, ErrInvalidRefreshToken
+		}
+		return 0, err
+	}
+
+	if user.Role == models.RoleVendor {
+		membership, err := ts.organizationService.Membership(tx, tenantID, user.ID)
+		if err != nil {
+			return 0, ErrInvalidRefreshToken
+		}
+		if membership.Status.IsBlocked() {
+			return 0, ErrInvalidRefreshToken
+		}
+	}
+
+	if session.OrganizationID == nil {
+		if err := tx.Model(session).Update("organization_id", tenantID).Error; err != nil {
+			return 0, err
+		}
+	}
+	return tenantID, nil
+}
+
+func (ts *TokenService) issue(tx *gorm.DB, userID uint, role string, tenantID uint, familyID string) (refreshToken, accessToken string, id uint, err error) {
+	tokenID, err := utils.NewRandomID()
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	refreshToken, accessToken, err = utils.GenerateTokenPair(userID, role, tenantID, tokenID, familyID)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	stored := models.RefreshToken{
+		UserID:    userID,
+		TokenID:   tokenID,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(utils.RefreshTokenDuration),
+	}
+	if err := tx.Create(&stored).Error; err != nil {
+		return "", "", 0, err
+	}
+
+	return refreshToken, accessToken, stored.ID, nil
+}
+
+// truncate shortens s to at most max bytes without splitting a UTF-8 sequence
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
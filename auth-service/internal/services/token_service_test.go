@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+var testClient = ClientInfo{IPAddress: "127.0.0.1", UserAgent: "go-test"}
+
+// setupTokenTest prepares a database and signing key and returns an admin of an
+// active organization with a fresh login
+func setupTokenTest(t *testing.T) (*TokenService, *models.User, string) {
+	t.Helper()
+
+	if err := utils.LoadSigningKeys(t.TempDir(), "", "test"); err != nil {
+		t.Fatal(err)
+	}
+	setupTestDB(t, &models.User{}, &models.Organization{}, &models.VendorOrganization{},
+		&models.RefreshToken{}, &models.UserSession{})
+
+	org := models.Organization{Name: "Acme", Slug: "acme", IsActive: true}
+	if err := database.DB.Create(&org).Error; err != nil {
+		t.Fatal(err)
+	}
+	user := createTestUser(t, models.RoleAdmin, "admin@acme.test")
+	if err := database.DB.Model(user).Update("organization_id", org.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ts := NewTokenService()
+	refresh, _, err := ts.IssueTokenPair(user.ID, string(user.Role), org.ID, testClient)
+	if err != nil {
+		t.Fatalf("IssueTokenPair: %v", err)
+	}
+	return ts, user, refresh
+}
+
+func storedRefreshToken(t *testing.T, refreshToken string) models.RefreshToken {
+	t.Helper()
+
+	claims, err := utils.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		t.Fatalf("ValidateRefreshToken: %v", err)
+	}
+	var stored models.RefreshToken
+	if err := database.DB.Where("token_id = ?", claims.ID).First(&stored).Error; err != nil {
+		t.Fatalf("refresh token not stored: %v", err)
+	}
+	return stored
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	ts, user, refresh := setupTokenTest(t)
+
+	rotated, access, err := ts.RotateRefreshToken(refresh, testClient)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+	if rotated == refresh {
+		t.Fatal("rotation returned the same refresh token")
+	}
+
+	claims, err := utils.ValidateToken(access)
+	if err != nil {
+		t.Fatalf("access token does not validate: %v", err)
+	}
+	if claims.UserID != user.ID {
+		t.Errorf("access token user = %d, want %d", claims.UserID, user.ID)
+	}
+
+	old := storedRefreshToken(t, refresh)
+	current := storedRefreshToken(t, rotated)
+	if old.RevokedAt == nil || !old.IsRotated() || *old.ReplacedBy != current.ID {
+		t.Errorf("old token revoked_at %v replaced_by %v, want revoked and replaced by %d", old.RevokedAt, old.ReplacedBy, current.ID)
+	}
+	if current.FamilyID != old.FamilyID {
+		t.Errorf("rotated token family %s, want %s", current.FamilyID, old.FamilyID)
+	}
+
+	// The new refresh token rotates in turn
+	if _, _, err := ts.RotateRefreshToken(rotated, testClient); err != nil {
+		t.Fatalf("second rotation: %v", err)
+	}
+}
+
+func TestRotateRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ts, user, refresh := setupTokenTest(t)
+
+	rotated, _, err := ts.RotateRefreshToken(refresh, testClient)
+	if err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+
+	// A stolen copy of the first token is presented after the owner rotated it
+	if _, _, err := ts.RotateRefreshToken(refresh, testClient); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reused token: err = %v, want ErrRefreshTokenReused", err)
+	}
+
+	// The legitimate token of the family is dead as well
+	if _, _, err := ts.RotateRefreshToken(rotated, testClient); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("token of a revoked family: err = %v, want ErrInvalidRefreshToken", err)
+	}
+
+	var active int64
+	database.DB.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&active)
+	if active != 0 {
+		t.Errorf("%d refresh tokens still active after reuse", active)
+	}
+	var session models.UserSession
+	if err := database.DB.Where("session_id = ?", storedRefreshToken(t, refresh).FamilyID).First(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	if session.RevokedAt == nil {
+		t.Error("session not revoked after reuse")
+	}
+}
+
+func TestRotateRefreshTokenRejectsExpired(t *testing.T) {
+	ts, _, refresh := setupTokenTest(t)
+
+	stored := storedRefreshToken(t, refresh)
+	if err := database.DB.Model(&stored).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := ts.RotateRefreshToken(refresh, testClient); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRotateRefreshTokenRejectsRevoked(t *testing.T) {
+	ts, _, refresh := setupTokenTest(t)
+
+	if err := ts.RevokeRefreshToken(refresh); err != nil {
+		t.Fatalf("RevokeRefreshToken: %v", err)
+	}
+
+	// A logged out token is invalid, but it was never rotated so it is no reuse
+	if _, _, err := ts.RotateRefreshToken(refresh, testClient); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRotateRefreshTokenRejectsForeignTokens(t *testing.T) {
+	ts, user, _ := setupTokenTest(t)
+
+	// Validly signed but never stored, e.g. issued before a database restore
+	unknown, access, err := utils.GenerateTokenPair(user.ID, string(user.Role), 1, "unknown-id", "unknown-family")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"garbage": "not-a-jwt", "unknown": unknown, "access token": access} {
+		if _, _, err := ts.RotateRefreshToken(token, testClient); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidRefreshToken", name, err)
+		}
+	}
+}
+
+func TestRotateRefreshTokenConcurrently(t *testing.T) {
+	ts, _, refresh := setupTokenTest(t)
+
+	const attempts = 8
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		successes int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := ts.RotateRefreshToken(refresh, testClient)
+			if err == nil {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+				return
+			}
+			if !errors.Is(err, ErrInvalidRefreshToken) && !errors.Is(err, ErrRefreshTokenReused) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if successes > 1 {
+		t.Fatalf("token rotated %d times, want at most once", successes)
+	}
+
+	var children int64
+	database.DB.Model(&models.RefreshToken{}).Where("family_id = ? AND replaced_by IS NULL AND revoked_at IS NULL", storedRefreshToken(t, refresh).FamilyID).Count(&children)
+	if children > 1 {
+		t.Errorf("%d live refresh tokens in the family, want at most 1", children)
+	}
+}
+
+func TestRevokeAllForUserTxRollsBack(t *testing.T) {
+	ts, user, refresh := setupTokenTest(t)
+
+	// The password change failed, so the sessions must survive as well
+	failed := errors.New("password update failed")
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ts.RevokeAllForUserTx(tx, user.ID); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("err = %v, want %v", err, failed)
+	}
+	if stored := storedRefreshToken(t, refresh); stored.RevokedAt != nil {
+		t.Fatal("refresh token revoked by a rolled back transaction")
+	}
+
+	if err := ts.RevokeAllForUser(user.ID); err != nil {
+		t.Fatalf("RevokeAllForUser: %v", err)
+	}
+	if stored := storedRefreshToken(t, refresh); stored.RevokedAt == nil {
+		t.Error("refresh token not revoked")
+	}
+	var active int64
+	database.DB.Model(&models.UserSession{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&active)
+	if active != 0 {
+		t.Errorf("%d sessions still active", active)
+	}
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrInvalidRole     = errors.New("role must be admin or vendor")
+	ErrUserUnchanged   = errors.New("user is already in the requested state")
+	ErrSelfManagement  = errors.New("admins cannot deactivate or change the role of their own account")
+	ErrLastActiveAdmin = errors.New("at least one active admin must remain")
+	ErrSharedVendor    = errors.New("vendor also works with other organizations, change their status in this organization instead")
+	ErrLastUserManager = errors.New("at least one active user of the organization must keep the user:manage permission")
+)
+
+// UserFilter narrows the users listed to admins. Zero values do not filter,
+// except for OrganizationID which is always applied.
+type UserFilter struct {
+	OrganizationID uint
+	Search         string // matched against name and email
+	Role           models.Role
+	IsActive       *bool
+}
+
+// UserService covers the account management done by admins
+type UserService struct {
+	tokenService *TokenService
+}
+
+func NewUserService() *UserService {
+	return &UserService{
+		tokenService: NewTokenService(),
+	}
+}
+
+// List returns a page of users matching the filter, newest first
+func (us *UserService) List(filter UserFilter, page, limit int) ([]models.User, int64, error) {
+	query := database.DB.Model(&models.User{}).Scopes(inOrganization(filter.OrganizationID))
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(search)) + "%"
+		query = query.Where("LOWER(first_name) LIKE ? OR LOWER(last_name) LIKE ? OR LOWER(email) LIKE ? OR LOWER(first_name || ' ' || last_name) LIKE ?",
+			pattern, pattern, pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	if err := query.Preload("VendorDetails").
+		Order("created_at DESC, id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// Get returns a user of the organization with their vendor details and permissions
+func (us *UserService) Get(orgID, userID uint) (*models.User, error) {
+	var user models.User
+	if err := database.DB.Scopes(inOrganization(orgID)).
+		Preload("VendorDetails.Categories", "category_id IN (?)", tenantCategories(database.DB, orgID)).
+		Preload("VendorDetails.Categories.Category").
+		Preload("VendorDetails.Organizations", "organization_id = ?", orgID).
+		Preload("UserPermissions.Permission").
+		First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetActive deactivates or reactivates an account of the organization. Deactivating
+// logs the user out everywhere; they cannot log in or refresh tokens until reactivated.
+func (us *UserService) SetActive(orgID, userID, actorID uint, active bool) (*models.User, error) {
+	if !active && userID == actorID {
+		return nil, ErrSelfManagement
+	}
+
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUser(tx, orgID, userID, &user); err != nil {
+			return err
+		}
+		if user.IsActive == active {
+			return ErrUserUnchanged
+		}
+		if !active && user.Role == models.RoleAdmin {
+			if err := ensureOtherActiveAdmin(tx, orgID, user.ID); err != nil {
+				return err
+			}
+		}
+
+		user.IsActive = active
+		if err := tx.Model(&user).Update("is_active", active).Error; err != nil {
+			return err
+		}
+		return BumpPermissionsVersion(tx, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !active {
+		us.RevokeSessions(user.ID)
+	}
+	return &user, nil
+}
+
+// BumpPermissionsVersion marks the cached permissions of the user as stale in
+// every service. Call it in the transaction that changes them.
+func BumpPermissionsVersion(tx *gorm.DB, userID uint) error {
+	return tx.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumn("permissions_version", gorm.Expr("permissions_version + 1")).Error
+}
+
+// ForcePasswordReset invalidates the password of the user and logs them out
+// everywhere. They regain access by setting a new password through the link
+// emailed by the caller, or through the forgot password flow.
+func (us *UserService) ForcePasswordReset(orgID, userID uint) (*models.User, error) {
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUser(tx, orgID, userID, &user); err != nil {
+			return err
+		}
+		// Skips the hook hashing the password, see unusablePassword
+		return tx.Model(&user).Session(&gorm.Session{SkipHooks: true}).Update("password", unusablePassword).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	us.RevokeSessions(user.ID)
+	return &user, nil
+}
+
+// ChangeRole switches a user of the organization between admin and vendor inside
+// tx. The caller replaces the permissions of the old role. Their tokens carry the
+// old role, so the user is logged out once tx commits, see RevokeSessions.
+func (us *UserService) ChangeRole(tx *gorm.DB, orgID, userID, actorID uint, role models.Role) (*models.User, models.Role, error) {
+	if role != models.RoleAdmin && role != models.RoleVendor {
+		return nil, "", ErrInvalidRole
+	}
+	if userID == actorID {
+		return nil, "", ErrSelfManagement
+	}
+
+	var user models.User
+	if err := lockUser(tx, orgID, userID, &user); err != nil {
+		return nil, "", err
+	}
+	previous := user.Role
+	if previous == role {
+		return nil, "", ErrUserUnchanged
+	}
+	if previous == models.RoleAdmin && user.IsActive {
+		if err := ensureOtherActiveAdmin(tx, orgID, user.ID); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Admins belong to the organization, vendors join it through a membership
+	var organizationID *uint
+	if role == models.RoleAdmin {
+		organizationID = &orgID
+	}
+	if err := tx.Model(&user).Updates(map[string]interface{}{
+		"role":            role,
+		"organization_id": organizationID,
+	}).Error; err != nil {
+		return nil, "", err
+	}
+	user.Role = role
+	user.OrganizationID = organizationID
+
+	// A new vendor goes through the same review as a registered one
+	if role == models.RoleVendor {
+		details := models.VendorDetails{UserID: user.ID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&details).Error; err != nil {
+			return nil, "", err
+		}
+		membership := models.VendorOrganization{UserID: user.ID, OrganizationID: orgID, Status: models.VendorPending}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&membership).Error; err != nil {
+			return nil, "", err
+		}
+	}
+
+	return &user, previous, nil
+}
+
+// RevokeSessions logs the user out everywhere after an account change. The change
+// itself already happened, so a failure is only logged and sessions expire on their own.
+func (us *UserService) RevokeSessions(userID uint) {
+	if err := us.tokenService.RevokeAllForUser(userID); err != nil {
+		log.Printf("Failed to revoke sessions of user %d: %v", userID, err)
+	}
+}
+
+// lockUser loads a user of the organization for update. Changes to the account
+// of a vendor also working with other organizations are refused, as they would
+// reach into those organizations.
+func lockUser(tx *gorm.DB, orgID, userID uint, user *models.User) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(inOrganization(orgID)).
+		First(user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if user.Role == models.RoleVendor {
+		var others int64
+		if err := tx.Model(&models.VendorOrganization{}).
+			Where("user_id = ? AND organization_id <> ?", user.ID, orgID).
+			Count(&others).Error; err != nil {
+			return err
+		}
+		if others > 0 {
+			return ErrSharedVendor
+		}
+	}
+	return nil
+}
+
+// inOrganization limits a users query to the admins of the organization and the
+// vendors that joined it
+func inOrganization(orgID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("users.organization_id = ? OR EXISTS (SELECT 1 FROM vendor_organizations WHERE vendor_organizations.user_id = users.id AND vendor_organizations.organization_id = ?)", orgID, orgID)
+	}
+}
+
+// ensureOtherActiveAdmin refuses to take away the last active admin of the organization
+func ensureOtherActiveAdmin(tx *gorm.DB, orgID, userID uint) error {
+	var ids []uint
+	// Locks the admins so two admins cannot remove each other at the same time
+	if err := tx.Model(&models.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ? AND is_active = ? AND organization_id = ? AND id <> ?", models.RoleAdmin, true, orgID, userID).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return ErrLastActiveAdmin
+	}
+	return nil
+}
+
+// EnsureOtherUserManager refuses to take user:manage away from the last active
+// user of the organization holding it, nobody could manage its users any more
+func EnsureOtherUserManager(tx *gorm.DB, orgID, userID uint) error {
+	holders := tx.Session(&gorm.Session{NewDB: true}).Model(&models.UserPermission{}).
+		Select("user_permissions.user_id").
+		Joins("JOIN permissions ON permissions.id = user_permissions.permission_id").
+		Where("permissions.resource = ? AND permissions.action = ?", "user", "manage")
+
+	var ids []uint
+	// Locks the holders so two admins cannot revoke it from each other at the same time
+	if err := tx.Model(&models.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("is_active = ? AND organization_id = ? AND id <> ? AND id IN (?)", true, orgID, userID, holders).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return ErrLastUserManager
+	}
+	return nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
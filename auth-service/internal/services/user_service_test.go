@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+)
+
+func TestGetOnlyShowsCategoriesOfTheOrganization(t *testing.T) {
+	setupTestDB(t, &models.User{}, &models.VendorDetails{}, &models.VendorCategory{}, &models.Category{},
+		&models.Organization{}, &models.VendorOrganization{}, &models.UserPermission{}, &models.Permission{})
+
+	// A vendor serving two organizations, each with its own category
+	vendor := createTestUser(t, models.RoleVendor, "vendor@supplier.test")
+	rows := []interface{}{
+		&models.Category{ID: 1, TenantID: 1, Name: "Stationery", IsActive: true},
+		&models.Category{ID: 2, TenantID: 2, Name: "Confidential", IsActive: true},
+		&models.VendorDetails{UserID: vendor.ID},
+		&models.VendorOrganization{UserID: vendor.ID, OrganizationID: 1, Status: models.VendorApproved},
+		&models.VendorOrganization{UserID: vendor.ID, OrganizationID: 2, Status: models.VendorApproved},
+		&models.VendorCategory{UserID: vendor.ID, CategoryID: 1, Status: models.VendorCategoryApproved},
+		&models.VendorCategory{UserID: vendor.ID, CategoryID: 2, Status: models.VendorCategoryApproved},
+	}
+	for _, row := range rows {
+		if err := database.DB.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	user, err := NewUserService().Get(1, vendor.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if user.VendorDetails == nil {
+		t.Fatal("vendor details not loaded")
+	}
+
+	categories := user.VendorDetails.Categories
+	if len(categories) != 1 || categories[0].CategoryID != 1 {
+		t.Fatalf("categories = %+v, want only category 1 of organization 1", categories)
+	}
+	if categories[0].Category == nil || categories[0].Category.Name != "Stationery" {
+		t.Errorf("category not preloaded: %+v", categories[0].Category)
+	}
+}
+
+func TestSetActiveBumpsPermissionsVersion(t *testing.T) {
+	setupTestDB(t, &models.User{}, &models.Organization{}, &models.VendorOrganization{},
+		&models.RefreshToken{}, &models.UserSession{})
+
+	org := models.Organization{Name: "Acme", Slug: "acme", IsActive: true}
+	if err := database.DB.Create(&org).Error; err != nil {
+		t.Fatal(err)
+	}
+	admin := createTestUser(t, models.RoleAdmin, "admin@acme.test")
+	other := createTestUser(t, models.RoleAdmin, "other@acme.test")
+	if err := database.DB.Model(&models.User{}).Where("id IN ?", []uint{admin.ID, other.ID}).
+		Update("organization_id", org.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	version := func() uint {
+		var user models.User
+		if err := database.DB.Select("permissions_version").First(&user, other.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return user.PermissionsVersion
+	}
+
+	// Other services keep serving cached permissions until the version moves
+	before := version()
+	if _, err := NewUserService().SetActive(org.ID, other.ID, admin.ID, false); err != nil {
+		t.Fatalf("SetActive: %v", err)
+	}
+	deactivated := version()
+	if deactivated == before {
+		t.Fatal("deactivating did not bump permissions_version")
+	}
+
+	if _, err := NewUserService().SetActive(org.ID, other.ID, admin.ID, true); err != nil {
+		t.Fatalf("SetActive: %v", err)
+	}
+	if version() == deactivated {
+		t.Error("reactivating did not bump permissions_version")
+	}
+}
+
+func TestEnsureOtherUserManager(t *testing.T) {
+	setupTestDB(t, &models.User{}, &models.Permission{}, &models.UserPermission{})
+
+	const orgID = 1
+	manage := models.Permission{Name: "manage_users", Resource: "user", Action: "manage"}
+	view := models.Permission{Name: "view_rfp", Resource: "rfp", Action: "view"}
+	for _, permission := range []*models.Permission{&manage, &view} {
+		if err := database.DB.Create(permission).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	admin := createTestUser(t, models.RoleAdmin, "admin@acme.test")
+	other := createTestUser(t, models.RoleAdmin, "other@acme.test")
+	outsider := createTestUser(t, models.RoleAdmin, "admin@other.test")
+	database.DB.Model(&models.User{}).Where("id IN ?", []uint{admin.ID, other.ID}).Update("organization_id", orgID)
+	database.DB.Model(outsider).Update("organization_id", orgID+1)
+
+	grant := func(user *models.User, permission models.Permission) {
+		t.Helper()
+		if err := database.DB.Create(&models.UserPermission{UserID: user.ID, PermissionID: permission.ID}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	grant(admin, manage)
+	grant(other, view)
+	grant(outsider, manage)
+
+	// Holders of other permissions or in other organizations do not count
+	if err := EnsureOtherUserManager(database.DB, orgID, admin.ID); !errors.Is(err, ErrLastUserManager) {
+		t.Fatalf("only holder: err = %v, want ErrLastUserManager", err)
+	}
+
+	grant(other, manage)
+	if err := EnsureOtherUserManager(database.DB, orgID, admin.ID); err != nil {
+		t.Fatalf("with another holder: %v", err)
+	}
+
+	// A deactivated holder cannot manage anyone
+	database.DB.Model(other).Update("is_active", false)
+	if err := EnsureOtherUserManager(database.DB, orgID, admin.ID); !errors.Is(err, ErrLastUserManager) {
+		t.Errorf("other holder inactive: err = %v, want ErrLastUserManager", err)
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrVendorNotFound          = errors.New("vendor not found")
+	ErrInvalidVendorStatus     = errors.New("invalid vendor status")
+	ErrVendorStatusTransition  = errors.New("vendor status cannot be changed to the requested status")
+	ErrVendorStatusUnchanged   = errors.New("vendor already has the requested status")
+	ErrTransitionReasonMissing = errors.New("a reason is required for this status change")
+)
+
+// VendorApprovalService moves vendors through the approval lifecycle of each
+// organization they belong to and keeps the history of every transition
+type VendorApprovalService struct {
+	tokenService          *TokenService
+	vendorCategoryService *VendorCategoryService
+}
+
+func NewVendorApprovalService() *VendorApprovalService {
+	return &VendorApprovalService{
+		tokenService:          NewTokenService(),
+		vendorCategoryService: NewVendorCategoryService(),
+	}
+}
+
+// Transition moves the vendor to the status next in the organization orgID. A nil
+// actorID records a change made by the system. Everything but approving and
+// resubmitting needs a reason so the vendor can be told why.
+func (vs *VendorApprovalService) Transition(orgID, vendorID uint, next models.VendorStatus, actorID *uint, reason string) (*models.VendorOrganization, *models.VendorStatusHistory, error) {
+	if !models.IsValidVendorStatus(next) {
+		return nil, nil, ErrInvalidVendorStatus
+	}
+	if reason == "" && next != models.VendorApproved && next != models.VendorPending {
+		return nil, nil, ErrTransitionReasonMissing
+	}
+
+	var membership models.VendorOrganization
+	var history models.VendorStatusHistory
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("organization_id = ? AND user_id = ?", orgID, vendorID).
+			First(&membership).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVendorNotFound
+			}
+			return err
+		}
+
+		current := membership.Status
+		if current == next {
+			return ErrVendorStatusUnchanged
+		}
+		if !current.CanTransitionTo(next) {
+			return ErrVendorStatusTransition
+		}
+
+		updates := map[string]interface{}{
+			"status":         next,
+			"approval_notes": reason,
+		}
+		if next == models.VendorApproved {
+			updates["approved_by"] = actorID
+			updates["approved_at"] = time.Now()
+		}
+		if err := tx.Model(&membership).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// The categories picked during the application are approved with the vendor
+		if next == models.VendorApproved && (current == models.VendorPending || current == models.VendorNeedsInfo) {
+			if err := vs.vendorCategoryService.ApprovePending(tx, orgID, vendorID, actorID); err != nil {
+				return err
+			}
+		}
+
+		history = models.VendorStatusHistory{
+			UserID:         vendorID,
+			OrganizationID: orgID,
+			FromStatus:     current,
+			ToStatus:       next,
+			Reason:         reason,
+			ActorID:        actorID,
+		}
+		return tx.Create(&history).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Blocked vendors are logged out of the organization right away. Login and
+	// refresh check the status as well, so a failure here is not fatal.
+	if next.IsBlocked() {
+		if err := vs.tokenService.RevokeForOrganization(vendorID, orgID); err != nil {
+			log.Printf("Failed to revoke sessions of %s vendor %d in organization %d: %v", next, vendorID, orgID, err)
+		}
+	}
+
+	return &membership, &history, nil
+}
+
+// History returns the status transitions of the vendor in the organization, oldest first
+func (vs *VendorApprovalService) History(orgID, vendorID uint) ([]models.VendorStatusHistory, error) {
+	var history []models.VendorStatusHistory
+	err := database.DB.Where("organization_id = ? AND user_id = ?", orgID, vendorID).
+		Preload("Actor", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "first_name", "last_name", "email", "role")
+		}).
+		Order("created_at ASC, id ASC").
+		Find(&history).Error
+	return history, err
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNoCategories                = errors.New("at least one category is required")
+	ErrVendorCategoryNotFound      = errors.New("vendor does not serve this category")
+	ErrVendorCategoryNotPending    = errors.New("vendor category was already reviewed")
+	ErrVendorCategoryLimitExceeded = errors.New("too many categories")
+)
+
+// MaxVendorCategories bounds how many categories a vendor can serve
+const MaxVendorCategories = 20
+
+// VendorCategoryService manages the categories vendors serve
+type VendorCategoryService struct{}
+
+func NewVendorCategoryService() *VendorCategoryService {
+	return &VendorCategoryService{}
+}
+
+// CheckCategories verifies every category exists, is active and belongs to the
+// organization orgID in category-service
+func (cs *VendorCategoryService) CheckCategories(orgID uint, categoryIDs []uint) error {
+	categoryIDs = uniqueIDs(categoryIDs)
+	if len(categoryIDs) == 0 {
+		return ErrNoCategories
+	}
+	if len(categoryIDs) > MaxVendorCategories {
+		return ErrVendorCategoryLimitExceeded
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.Category{}).
+		Where("id IN ? AND tenant_id = ? AND is_active = ?", categoryIDs, orgID, true).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count != int64(len(categoryIDs)) {
+		return ErrInvalidCategory
+	}
+	return nil
+}
+
+// SetCategories makes categoryIDs the categories the vendor serves for the
+// organization orgID, categories of other organizations are left alone.
+// Categories the vendor already serves keep their review status, new ones
+// start out pending.
+func (cs *VendorCategoryService) SetCategories(tx *gorm.DB, orgID, userID uint, categoryIDs []uint) error {
+	categoryIDs = uniqueIDs(categoryIDs)
+	if len(categoryIDs) == 0 {
+		return ErrNoCategories
+	}
+
+	if err := tx.Where("user_id = ? AND category_id NOT IN ?", userID, categoryIDs).
+		Where("category_id IN (?)", tenantCategories(tx, orgID)).
+		Delete(&models.VendorCategory{}).Error; err != nil {
+		return err
+	}
+
+	// Contacts stop getting the RFPs of categories the vendor no longer serves
+	if err := tx.Where("category_id NOT IN ?", categoryIDs).
+		Where("category_id IN (?)", tenantCategories(tx, orgID)).
+		Where("contact_id IN (?)", tx.Session(&gorm.Session{NewDB: true}).Model(&models.VendorContact{}).Select("id").Where("user_id = ?", userID)).
+		Delete(&models.VendorContactCategory{}).Error; err != nil {
+		return err
+	}
+
+	rows := make([]models.VendorCategory, 0, len(categoryIDs))
+	for _, categoryID := range categoryIDs {
+		rows = append(rows, models.VendorCategory{
+			UserID:     userID,
+			CategoryID: categoryID,
+			Status:     models.VendorCategoryPending,
+		})
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// ApprovePending approves the categories of the organization still waiting for
+// review, used when the organization approves the vendor itself
+func (cs *VendorCategoryService) ApprovePending(tx *gorm.DB, orgID, userID uint, reviewerID *uint) error {
+	return tx.Model(&models.VendorCategory{}).
+		Where("user_id = ? AND status = ?", userID, models.VendorCategoryPending).
+		Where("category_id IN (?)", tenantCategories(tx, orgID)).
+		Updates(map[string]interface{}{
+			"status":      models.VendorCategoryApproved,
+			"reviewed_by": reviewerID,
+			"reviewed_at": time.Now(),
+		}).Error
+}
+
+// Review approves or rejects a category of the organization orgID an approved vendor added
+func (cs *VendorCategoryService) Review(orgID, userID, categoryID, reviewerID uint, approve bool, notes string) (*models.VendorCategory, error) {
+	var vendorCategory models.VendorCategory
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND category_id = ?", userID, categoryID).
+			Where("category_id IN (?)", tenantCategories(tx, orgID)).
+			First(&vendorCategory).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVendorCategoryNotFound
+			}
+			return err
+		}
+		if vendorCategory.Status != models.VendorCategoryPending {
+			return ErrVendorCategoryNotPending
+		}
+
+		status := models.VendorCategoryRejected
+		if approve {
+			status = models.VendorCategoryApproved
+		}
+
+		now := time.Now()
+		return tx.Model(&vendorCategory).Updates(map[string]interface{}{
+			"status":       status,
+			"reviewed_by":  reviewerID,
+			"reviewed_at":  &now,
+			"review_notes": notes,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &vendorCategory, nil
+}
+
+// ApprovedVendors returns the vendors approved for the category by the
+// organization that owns it
+func (cs *VendorCategoryService) ApprovedVendors(categoryID uint) ([]models.User, error) {
+	var users []models.User
+	err := database.DB.
+		Joins("JOIN vendor_categories ON vendor_categories.user_id = users.id").
+		Joins("JOIN categories ON categories.id = vendor_categories.category_id").
+		Joins("JOIN vendor_organizations ON vendor_organizations.user_id = users.id AND vendor_organizations.organization_id = categories.tenant_id").
+		Where("users.role = ? AND users.is_active = ?", models.RoleVendor, true).
+		Where("vendor_organizations.status = ?", models.VendorApproved).
+		Where("vendor_categories.category_id = ? AND vendor_categories.status = ?", categoryID, models.VendorCategoryApproved).
+		Order("users.id").
+		Find(&users).Error
+	return users, err
+}
+
+// tenantCategories selects the IDs of the categories owned by the organization
+func tenantCategories(db *gorm.DB, orgID uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Model(&models.Category{}).Select("id").Where("tenant_id = ?", orgID)
+}
+
+// uniqueIDs drops zero and duplicate IDs, keeping the order
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
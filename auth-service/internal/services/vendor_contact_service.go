@@ -0,0 +1,474 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrContactNotFound      = errors.New("contact not found")
+	ErrAddressNotFound      = errors.New("address not found")
+	ErrInvalidContactRole   = errors.New("invalid contact role")
+	ErrInvalidAddressType   = errors.New("invalid address type")
+	ErrContactCategory      = errors.New("contacts can only subscribe to categories the vendor serves")
+	ErrContactLimitExceeded = errors.New("too many contacts")
+	ErrAddressLimitExceeded = errors.New("too many addresses")
+	ErrPrimaryRequired      = errors.New("cannot unset the primary flag, set another one as primary instead")
+)
+
+const (
+	// MaxVendorContacts bounds how many contacts a vendor can have
+	MaxVendorContacts = 20
+	// MaxVendorAddresses bounds how many addresses a vendor can have
+	MaxVendorAddresses = 10
+)
+
+// VendorContactInput is a contact as sent by the vendor. A nil IsPrimary keeps
+// the current flag on update.
+type VendorContactInput struct {
+	Name        string
+	Email       string
+	Phone       string
+	Role        models.ContactRole
+	IsPrimary   *bool
+	CategoryIDs []uint
+}
+
+// VendorAddressInput is an address as sent by the vendor. A nil IsPrimary keeps
+// the current flag on update.
+type VendorAddressInput struct {
+	Type       models.AddressType
+	Label      string
+	Line1      string
+	Line2      string
+	City       string
+	State      string
+	PostalCode string
+	Country    string
+	IsPrimary  *bool
+}
+
+// VendorContactService manages the contacts and addresses of vendors. The first
+// contact, and the first address of each type, becomes primary; making another
+// one primary takes the flag away from the previous one.
+type VendorContactService struct{}
+
+func NewVendorContactService() *VendorContactService {
+	return &VendorContactService{}
+}
+
+// ListContacts returns the contacts of a vendor, primary first, with the categories
+// they are subscribed to. A non-zero orgID only shows subscriptions to categories
+// of that organization.
+func (vs *VendorContactService) ListContacts(userID, orgID uint) ([]models.VendorContact, error) {
+	query := database.DB.Where("user_id = ?", userID)
+	if orgID != 0 {
+		query = query.Preload("Categories", "category_id IN (?)", tenantCategories(database.DB, orgID))
+	} else {
+		query = query.Preload("Categories")
+	}
+
+	var contacts []models.VendorContact
+	err := query.Preload("Categories.Category").
+		Order("is_primary DESC, created_at ASC, id ASC").
+		Find(&contacts).Error
+	return contacts, err
+}
+
+// CreateContact adds a contact to the vendor
+func (vs *VendorContactService) CreateContact(userID uint, input VendorContactInput) (*models.VendorContact, error) {
+	if !models.IsValidContactRole(input.Role) {
+		return nil, ErrInvalidContactRole
+	}
+
+	var contact models.VendorContact
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockVendor(tx, userID); err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.VendorContact{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= MaxVendorContacts {
+			return ErrContactLimitExceeded
+		}
+
+		categoryIDs, err := vs.checkSubscriptions(tx, userID, input.CategoryIDs)
+		if err != nil {
+			return err
+		}
+
+		primary := count == 0 || (input.IsPrimary != nil && *input.IsPrimary)
+		if primary {
+			if err := tx.Model(&models.VendorContact{}).
+				Where("user_id = ? AND is_primary = ?", userID, true).
+				Update("is_primary", false).Error; err != nil {
+				return err
+			}
+		}
+
+		contact = models.VendorContact{
+			UserID:    userID,
+			Name:      strings.TrimSpace(input.Name),
+			Email:     strings.ToLower(strings.TrimSpace(input.Email)),
+			Phone:     strings.TrimSpace(input.Phone),
+			Role:      input.Role,
+			IsPrimary: primary,
+		}
+		if err := tx.Create(&contact).Error; err != nil {
+			return err
+		}
+		return vs.setSubscriptions(tx, contact.ID, categoryIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vs.getContact(contact.ID)
+}
+
+// UpdateContact replaces a contact of the vendor, including its subscriptions
+func (vs *VendorContactService) UpdateContact(id, userID uint, input VendorContactInput) (*models.VendorContact, error) {
+	if !models.IsValidContactRole(input.Role) {
+		return nil, ErrInvalidContactRole
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockVendor(tx, userID); err != nil {
+			return err
+		}
+
+		var contact models.VendorContact
+		if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&contact).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrContactNotFound
+			}
+			return err
+		}
+
+		categoryIDs, err := vs.checkSubscriptions(tx, userID, input.CategoryIDs)
+		if err != nil {
+			return err
+		}
+
+		primary := contact.IsPrimary
+		if input.IsPrimary != nil {
+			if contact.IsPrimary && !*input.IsPrimary {
+				return ErrPrimaryRequired
+			}
+			primary = *input.IsPrimary
+		}
+		if primary && !contact.IsPrimary {
+			if err := tx.Model(&models.VendorContact{}).
+				Where("user_id = ? AND is_primary = ?", userID, true).
+				Update("is_primary", false).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&contact).Updates(map[string]interface{}{
+			"name":       strings.TrimSpace(input.Name),
+			"email":      strings.ToLower(strings.TrimSpace(input.Email)),
+			"phone":      strings.TrimSpace(input.Phone),
+			"role":       input.Role,
+			"is_primary": primary,
+		}).Error; err != nil {
+			return err
+		}
+		return vs.setSubscriptions(tx, contact.ID, categoryIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vs.getContact(id)
+}
+
+// DeleteContact removes a contact of the vendor. When it was the primary contact
+// the oldest remaining one takes its place.
+func (vs *VendorContactService) DeleteContact(id, userID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockVendor(tx, userID); err != nil {
+			return err
+		}
+
+		var contact models.VendorContact
+		if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&contact).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrContactNotFound
+			}
+			return err
+		}
+
+		if err := tx.Where("contact_id = ?", contact.ID).Delete(&models.VendorContactCategory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&contact).Error; err != nil {
+			return err
+		}
+		if contact.IsPrimary {
+			return promoteOldest(tx, &models.VendorContact{}, "user_id = ?", userID)
+		}
+		return nil
+	})
+}
+
+// SubscribedEmails returns the emails of the contacts subscribed to the category,
+// by vendor. Only contacts of the given vendors are looked at.
+func (vs *VendorContactService) SubscribedEmails(categoryID uint, userIDs []uint) (map[uint][]string, error) {
+	emails := make(map[uint][]string)
+	if len(userIDs) == 0 {
+		return emails, nil
+	}
+
+	var rows []struct {
+		UserID uint
+		Email  string
+	}
+	if err := database.DB.Model(&models.VendorContact{}).
+		Select("vendor_contacts.user_id, vendor_contacts.email").
+		Joins("JOIN vendor_contact_categories ON vendor_contact_categories.contact_id = vendor_contacts.id").
+		Where("vendor_contact_categories.category_id = ? AND vendor_contacts.user_id IN ?", categoryID, userIDs).
+		Order("vendor_contacts.is_primary DESC, vendor_contacts.id ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		emails[row.UserID] = append(emails[row.UserID], row.Email)
+	}
+	return emails, nil
+}
+
+// ListAddresses returns the addresses of a vendor by type, primary first
+func (vs *VendorContactService) ListAddresses(userID uint) ([]models.VendorAddress, error) {
+	var addresses []models.VendorAddress
+	err := database.DB.Where("user_id = ?", userID).
+		Order("type ASC, is_primary DESC, created_at ASC, id ASC").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+// CreateAddress adds an address to the vendor
+func (vs *VendorContactService) CreateAddress(userID uint, input VendorAddressInput) (*models.VendorAddress, error) {
+	if !models.IsValidAddressType(input.Type) {
+		return nil, ErrInvalidAddressType
+	}
+
+	var address models.VendorAddress
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockVendor(tx, userID); err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.VendorAddress{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= MaxVendorAddresses {
+			return ErrAddressLimitExceeded
+		}
+
+		var sameType int64
+		if err := tx.Model(&models.VendorAddress{}).
+			Where("user_id = ? AND type = ?", userID, input.Type).
+			Count(&sameType).Error; err != nil {
+			return err
+		}
+
+		primary := sameType == 0 || (input.IsPrimary != nil && *input.IsPrimary)
+		if primary {
+			if err := tx.Model(&models.VendorAddress{}).
+				Where("user_id = ? AND type = ? AND is_primary = ?", userID, input.Type, true).
+				Update("is_primary", false).Error; err != nil {
+				return err
+			}
+		}
+
+		address = models.VendorAddress{UserID: userID, IsPrimary: primary}
+		applyAddressInput(&address, input)
+		return tx.Create(&address).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+// UpdateAddress replaces an address of the vendor. Moving the primary address to
+// the other type makes the oldest remaining address of its old type primary.
+func (vs *VendorContactService) UpdateAddress(id, userID uint, input VendorAddressInput) (*models.VendorAddress, error) {
+	if !models.IsValidAddressType(input.Type) {
+		return nil, ErrInvalidAddressType
+	}
+
+	var address models.VendorAddress
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockVendor(tx, userID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&address).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAddressNotFound
+			}
+			return err
+		}
+
+		oldType, wasPrimary := address.Type, address.IsPrimary
+		typeChanged := input.Type != oldType
+
+		primary := wasPrimary && !typeChanged
+		if input.IsPrimary != nil {
+			if primary && !*input.IsPrimary {
+				return ErrPrimaryRequired
+			}
+			primary = *input.IsPrimary
+		}
+		if typeChanged {
+			var sameType int64
+			if err := tx.Model(&models.VendorAddress{}).
+				Where("user_id = ? AND type = ?", userID, input.Type).
+				Count(&sameType).Error; err != nil {
+				return err
+			}
+			if sameType == 0 {
+				primary = true
+			}
+		}
+		if primary {
+			if err := tx.Model(&models.VendorAddress{}).
+				Where("user_id = ? AND type = ? AND is_primary = ? AND id <> ?", userID, input.Type, true, address.ID).
+				Update("is_primary", false).Error; err != nil {
+				return err
+			}
+		}
+
+		applyAddressInput(&address, input)
+		address.IsPrimary = primary
+		if err := tx.Save(&address).Error; err != nil {
+			return err
+		}
+
+		if typeChanged && wasPrimary {
+			return promoteOldest(tx, &models.VendorAddress{}, "user_id = ? AND type = ?", userID, oldType)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+// DeleteAddress removes an address of the vendor. When it was the primary address
+// of its type the oldest remaining one of that type takes its place.
+func (vs *VendorContactService) DeleteAddress(id, userID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockVendor(tx, userID); err != nil {
+			return err
+		}
+
+		var address models.VendorAddress
+		if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&address).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAddressNotFound
+			}
+			return err
+		}
+
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+		if address.IsPrimary {
+			return promoteOldest(tx, &models.VendorAddress{}, "user_id = ? AND type = ?", userID, address.Type)
+		}
+		return nil
+	})
+}
+
+func (vs *VendorContactService) getContact(id uint) (*models.VendorContact, error) {
+	var contact models.VendorContact
+	if err := database.DB.Preload("Categories.Category").First(&contact, id).Error; err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// checkSubscriptions verifies the vendor serves every category, rejected ones aside
+func (vs *VendorContactService) checkSubscriptions(tx *gorm.DB, userID uint, categoryIDs []uint) ([]uint, error) {
+	categoryIDs = uniqueIDs(categoryIDs)
+	if len(categoryIDs) == 0 {
+		return categoryIDs, nil
+	}
+	if len(categoryIDs) > MaxVendorCategories {
+		return nil, ErrVendorCategoryLimitExceeded
+	}
+
+	var count int64
+	if err := tx.Model(&models.VendorCategory{}).
+		Where("user_id = ? AND category_id IN ? AND status <> ?", userID, categoryIDs, models.VendorCategoryRejected).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count != int64(len(categoryIDs)) {
+		return nil, ErrContactCategory
+	}
+	return categoryIDs, nil
+}
+
+// setSubscriptions makes categoryIDs the categories the contact is subscribed to
+func (vs *VendorContactService) setSubscriptions(tx *gorm.DB, contactID uint, categoryIDs []uint) error {
+	if err := tx.Where("contact_id = ?", contactID).Delete(&models.VendorContactCategory{}).Error; err != nil {
+		return err
+	}
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+
+	rows := make([]models.VendorContactCategory, 0, len(categoryIDs))
+	for _, categoryID := range categoryIDs {
+		rows = append(rows, models.VendorContactCategory{ContactID: contactID, CategoryID: categoryID})
+	}
+	return tx.Create(&rows).Error
+}
+
+// lockVendor serializes changes to the contacts and addresses of a vendor, so two
+// requests cannot both make a row primary or both pass the limit
+func lockVendor(tx *gorm.DB, userID uint) error {
+	var user models.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("id = ? AND role = ?", userID, models.RoleVendor).
+		First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// promoteOldest makes the oldest of the rows matching the conditions primary
+func promoteOldest(tx *gorm.DB, model interface{}, query string, args ...interface{}) error {
+	oldest := tx.Session(&gorm.Session{NewDB: true}).Model(model).Select("id").
+		Where(query, args...).Order("created_at ASC, id ASC").Limit(1)
+	return tx.Model(model).Where("id = (?)", oldest).Update("is_primary", true).Error
+}
+
+func applyAddressInput(address *models.VendorAddress, input VendorAddressInput) {
+	address.Type = input.Type
+	address.Label = strings.TrimSpace(input.Label)
+	address.Line1 = strings.TrimSpace(input.Line1)
+	address.Line2 = strings.TrimSpace(input.Line2)
+	address.City = strings.TrimSpace(input.City)
+	address.State = strings.TrimSpace(input.State)
+	address.PostalCode = strings.TrimSpace(input.PostalCode)
+	address.Country = strings.TrimSpace(input.Country)
+}
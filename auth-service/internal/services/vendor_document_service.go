@@ -0,0 +1,266 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/config"
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidDocumentType     = errors.New("invalid document type")
+	ErrDocumentTooLarge        = errors.New("document is too large")
+	ErrDocumentEmpty           = errors.New("document is empty")
+	ErrUnsupportedDocument     = errors.New("only PDF, JPEG and PNG documents are accepted")
+	ErrDocumentNotFound        = errors.New("document not found")
+	ErrDocumentNotPending      = errors.New("document was already reviewed")
+	ErrApprovedDocumentDeleted = errors.New("approved documents cannot be deleted")
+)
+
+// allowedDocumentTypes maps the sniffed content types to the extension files are stored with
+var allowedDocumentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// VendorDocumentService stores the KYC documents of vendors and tracks their review
+type VendorDocumentService struct {
+	storage DocumentStorage
+}
+
+func NewVendorDocumentService() *VendorDocumentService {
+	return &VendorDocumentService{storage: documentStorage}
+}
+
+// MaxDocumentSize is the largest accepted upload in bytes
+func (ds *VendorDocumentService) MaxDocumentSize() int64 {
+	return int64(config.Load().DocumentMaxSizeMB) << 20
+}
+
+// Upload stores a document of the vendor. The content type is sniffed from the
+// file itself, the one sent by the client is not trusted.
+func (ds *VendorDocumentService) Upload(userID uint, docType models.DocumentType, fileName string, content io.Reader) (*models.VendorDocument, error) {
+	if !models.IsValidDocumentType(docType) {
+		return nil, ErrInvalidDocumentType
+	}
+
+	reader := bufio.NewReaderSize(content, 512)
+	head, err := reader.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(head) == 0 {
+		return nil, ErrDocumentEmpty
+	}
+
+	contentType := http.DetectContentType(head)
+	ext, ok := allowedDocumentTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedDocument
+	}
+
+	randomID, err := utils.NewRandomID()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("vendors/%d/%s%s", userID, randomID, ext)
+
+	// One byte more than allowed is read so an oversized file can be told apart
+	// from one that is exactly at the limit
+	maxSize := ds.MaxDocumentSize()
+	hash := sha256.New()
+	counter := &countingWriter{}
+	limited := io.TeeReader(io.LimitReader(reader, maxSize+1), io.MultiWriter(hash, counter))
+
+	if err := ds.storage.Save(key, limited); err != nil {
+		return nil, err
+	}
+	if counter.n > maxSize {
+		_ = ds.storage.Delete(key)
+		return nil, ErrDocumentTooLarge
+	}
+
+	document := models.VendorDocument{
+		UserID:      userID,
+		Type:        docType,
+		FileName:    sanitizeFileName(fileName),
+		ContentType: contentType,
+		Size:        counter.n,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+		Status:      models.DocumentPending,
+	}
+	if err := database.DB.Create(&document).Error; err != nil {
+		_ = ds.storage.Delete(key)
+		return nil, err
+	}
+
+	return &document, nil
+}
+
+// List returns the documents of a vendor, newest first
+func (ds *VendorDocumentService) List(userID uint) ([]models.VendorDocument, error) {
+	var documents []models.VendorDocument
+	err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&documents).Error
+	return documents, err
+}
+
+// Get returns a document. A non-zero userID restricts the lookup to that vendor's documents.
+func (ds *VendorDocumentService) Get(id, userID uint) (*models.VendorDocument, error) {
+	query := database.DB.Where("id = ?", id)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var document models.VendorDocument
+	if err := query.First(&document).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, err
+	}
+	return &document, nil
+}
+
+// Open returns the file of a document
+func (ds *VendorDocumentService) Open(document *models.VendorDocument) (io.ReadCloser, error) {
+	return ds.storage.Open(document.StorageKey)
+}
+
+// Delete removes a document of the vendor that was not approved yet
+func (ds *VendorDocumentService) Delete(id, userID uint) error {
+	document, err := ds.Get(id, userID)
+	if err != nil {
+		return err
+	}
+	if document.Status == models.DocumentApproved {
+		return ErrApprovedDocumentDeleted
+	}
+
+	if err := database.DB.Delete(document).Error; err != nil {
+		return err
+	}
+	return ds.storage.Delete(document.StorageKey)
+}
+
+// Review approves or rejects a pending document of a vendor of the organization orgID
+func (ds *VendorDocumentService) Review(orgID, id, reviewerID uint, approve bool, notes string) (*models.VendorDocument, error) {
+	var document models.VendorDocument
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id IN (?)", organizationVendors(tx, orgID)).
+			First(&document, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDocumentNotFound
+			}
+			return err
+		}
+		if document.Status != models.DocumentPending {
+			return ErrDocumentNotPending
+		}
+
+		status := models.DocumentRejected
+		if approve {
+			status = models.DocumentApproved
+		}
+
+		now := time.Now()
+		return tx.Model(&document).Updates(map[string]interface{}{
+			"status":       status,
+			"reviewed_by":  reviewerID,
+			"reviewed_at":  &now,
+			"review_notes": notes,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &document, nil
+}
+
+// MissingForApproval returns the required document types the vendor has no approved
+// document for, and the number of documents still waiting for review. A vendor can
+// only be approved when both are empty.
+func (ds *VendorDocumentService) MissingForApproval(userID uint) ([]models.DocumentType, int64, error) {
+	var approved []models.DocumentType
+	if err := database.DB.Model(&models.VendorDocument{}).
+		Where("user_id = ? AND status = ?", userID, models.DocumentApproved).
+		Distinct().Pluck("type", &approved).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var pending int64
+	if err := database.DB.Model(&models.VendorDocument{}).
+		Where("user_id = ? AND status = ?", userID, models.DocumentPending).
+		Count(&pending).Error; err != nil {
+		return nil, 0, err
+	}
+
+	have := make(map[models.DocumentType]bool, len(approved))
+	for _, docType := range approved {
+		have[docType] = true
+	}
+
+	missing := []models.DocumentType{}
+	for _, docType := range RequiredDocumentTypes() {
+		if !have[docType] {
+			missing = append(missing, docType)
+		}
+	}
+	return missing, pending, nil
+}
+
+// RequiredDocumentTypes returns the document types configured in VENDOR_REQUIRED_DOCUMENTS
+func RequiredDocumentTypes() []models.DocumentType {
+	var required []models.DocumentType
+	for _, value := range strings.Split(config.Load().VendorRequiredDocuments, ",") {
+		docType := models.DocumentType(strings.TrimSpace(value))
+		if models.IsValidDocumentType(docType) {
+			required = append(required, docType)
+		}
+	}
+	return required
+}
+
+// sanitizeFileName keeps only the base name of an uploaded file, it is shown to
+// admins and sent back in Content-Disposition
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "document"
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnsupportedImportFile = errors.New("only CSV and XLSX files can be imported")
+	ErrImportFileEmpty       = errors.New("file contains no vendors")
+	ErrImportTooManyRows     = errors.New("file contains too many vendors")
+	ErrImportMissingColumns  = errors.New("file is missing required columns")
+	ErrInvalidCSV            = errors.New("file is not a valid CSV")
+)
+
+const (
+	// MaxImportRows bounds the vendors of a single import
+	MaxImportRows = 5000
+	// ImportBatchSize is how many vendors are created per transaction
+	ImportBatchSize = 100
+	// unusablePassword is stored for imported vendors until they set a password
+	// through their invitation link. It is not a bcrypt hash, so no password matches it.
+	unusablePassword = "!"
+)
+
+// importColumns maps the accepted header names to the column they fill
+var importColumns = map[string]string{
+	"firstname":       "firstname",
+	"first_name":      "firstname",
+	"lastname":        "lastname",
+	"last_name":       "lastname",
+	"email":           "email",
+	"mobile":          "mobile",
+	"phone":           "mobile",
+	"phone_no":        "mobile",
+	"gst_no":          "gst_no",
+	"gstin":           "gst_no",
+	"pancard_no":      "pancard_no",
+	"pan":             "pancard_no",
+	"pan_no":          "pancard_no",
+	"revenue":         "revenue",
+	"no_of_employees": "no_of_employees",
+	"employees":       "no_of_employees",
+	"categories":      "categories",
+	"category":        "categories",
+}
+
+var requiredImportColumns = []string{"firstname", "lastname", "email", "categories"}
+
+// importFieldColumns names the column behind each validated field in row errors
+var importFieldColumns = map[string]string{
+	"FirstName":     "firstname",
+	"LastName":      "lastname",
+	"Email":         "email",
+	"PhoneNo":       "mobile",
+	"GSTNo":         "gst_no",
+	"PANNo":         "pancard_no",
+	"Revenue":       "revenue",
+	"EmployeeCount": "no_of_employees",
+}
+
+// VendorImportRow is a vendor read from an import file. Row is the line in the
+// file, counting the header as line 1.
+type VendorImportRow struct {
+	Row           int     `json:"row"`
+	FirstName     string  `json:"firstname" validate:"required,max=100"`
+	LastName      string  `json:"lastname" validate:"required,max=100"`
+	Email         string  `json:"email" validate:"required,email,max=255"`
+	PhoneNo       string  `json:"mobile" validate:"max=15"`
+	GSTNo         string  `json:"gst_no" validate:"omitempty,gstin,gstin_pan=PANNo"`
+	PANNo         string  `json:"pancard_no" validate:"omitempty,pan"`
+	Revenue       float64 `json:"revenue" validate:"min=0"`
+	EmployeeCount int     `json:"no_of_employees" validate:"min=0"`
+	CategoryIDs   []uint  `json:"categories"`
+}
+
+// ImportRowError is a problem with one column of one row
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column"`
+	Message string `json:"message"`
+}
+
+// VendorImportReport is the outcome of validating, and possibly importing, a file
+type VendorImportReport struct {
+	DryRun    bool             `json:"dry_run"`
+	TotalRows int              `json:"total_rows"`
+	ValidRows int              `json:"valid_rows"`
+	Created   int              `json:"created"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+// VendorImportService reads vendor lists from CSV and XLSX files and checks them
+// before they are imported
+type VendorImportService struct {
+	vendorProfileService  *VendorProfileService
+	vendorCategoryService *VendorCategoryService
+}
+
+func NewVendorImportService() *VendorImportService {
+	return &VendorImportService{
+		vendorProfileService:  NewVendorProfileService(),
+		vendorCategoryService: NewVendorCategoryService(),
+	}
+}
+
+// Parse reads the vendors of a CSV or XLSX file. The format is taken from the
+// file content, the name only serves as a fallback. Categories are looked up among
+// those of the organization orgID. Row level problems are returned as errors
+// alongside the rows that could be read.
+func (is *VendorImportService) Parse(orgID uint, file io.ReaderAt, size int64, fileName string) ([]VendorImportRow, []ImportRowError, error) {
+	head := make([]byte, 4)
+	n, _ := file.ReadAt(head, 0)
+	head = head[:n]
+
+	var records [][]string
+	var err error
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		records, err = utils.ReadXLSXRows(file, size)
+	case strings.EqualFold(filepath.Ext(fileName), ".xlsx"):
+		return nil, nil, utils.ErrInvalidXLSX
+	case strings.EqualFold(filepath.Ext(fileName), ".csv") || filepath.Ext(fileName) == "":
+		records, err = readCSV(io.NewSectionReader(file, 0, size))
+	default:
+		return nil, nil, ErrUnsupportedImportFile
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Trailing blank lines are common in spreadsheets
+	for len(records) > 0 && isBlankRecord(records[len(records)-1]) {
+		records = records[:len(records)-1]
+	}
+	if len(records) < 2 {
+		return nil, nil, ErrImportFileEmpty
+	}
+	if len(records)-1 > MaxImportRows {
+		return nil, nil, fmt.Errorf("%w, at most %d are allowed", ErrImportTooManyRows, MaxImportRows)
+	}
+
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		name := strings.ToLower(strings.TrimSpace(header))
+		name = strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+		if column, ok := importColumns[name]; ok {
+			if _, seen := columns[column]; !seen {
+				columns[column] = i
+			}
+		}
+	}
+	var missing []string
+	for _, column := range requiredImportColumns {
+		if _, ok := columns[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrImportMissingColumns, strings.Join(missing, ", "))
+	}
+
+	categories, err := is.categoryLookup(orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([]VendorImportRow, 0, len(records)-1)
+	var rowErrors []ImportRowError
+	for i, record := range records[1:] {
+		if isBlankRecord(record) {
+			continue
+		}
+		line := i + 2
+		value := func(column string) string {
+			index, ok := columns[column]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		row := VendorImportRow{
+			Row:       line,
+			FirstName: value("firstname"),
+			LastName:  value("lastname"),
+			Email:     strings.ToLower(value("email")),
+			PhoneNo:   value("mobile"),
+			GSTNo:     utils.NormalizeTaxID(value("gst_no")),
+			PANNo:     utils.NormalizeTaxID(value("pancard_no")),
+		}
+
+		if revenue := value("revenue"); revenue != "" {
+			if row.Revenue, err = strconv.ParseFloat(strings.ReplaceAll(revenue, ",", ""), 64); err != nil {
+				rowErrors = append(rowErrors, ImportRowError{Row: line, Column: "revenue", Message: "not a number"})
+			}
+		}
+		if employees := value("no_of_employees"); employees != "" {
+			// Spreadsheets may store whole numbers as "25.0"
+			count, err := strconv.ParseFloat(employees, 64)
+			if err != nil || count != float64(int(count)) {
+				rowErrors = append(rowErrors, ImportRowError{Row: line, Column: "no_of_employees", Message: "not a whole number"})
+			}
+			row.EmployeeCount = int(count)
+		}
+
+		// Categories are given by ID or name, separated by ";" or "|"
+		for _, category := range strings.FieldsFunc(value("categories"), func(r rune) bool { return r == ';' || r == '|' }) {
+			category = strings.ToLower(strings.TrimSpace(category))
+			if category == "" {
+				continue
+			}
+			categoryID, ok := categories[category]
+			if !ok {
+				rowErrors = append(rowErrors, ImportRowError{Row: line, Column: "categories", Message: fmt.Sprintf("unknown or inactive category %q", category)})
+				continue
+			}
+			row.CategoryIDs = append(row.CategoryIDs, categoryID)
+		}
+		row.CategoryIDs = uniqueIDs(row.CategoryIDs)
+		if len(row.CategoryIDs) == 0 && value("categories") == "" {
+			rowErrors = append(rowErrors, ImportRowError{Row: line, Column: "categories", Message: ErrNoCategories.Error()})
+		}
+		if len(row.CategoryIDs) > MaxVendorCategories {
+			rowErrors = append(rowErrors, ImportRowError{Row: line, Column: "categories", Message: ErrVendorCategoryLimitExceeded.Error()})
+		}
+
+		for _, fieldError := range utils.ValidateStructFields(row) {
+			rowErrors = append(rowErrors, ImportRowError{Row: line, Column: importFieldColumns[fieldError.Field], Message: fieldError.Message})
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// CheckDuplicates reports emails and GSTINs that appear twice in the file or
+// already belong to a user
+func (is *VendorImportService) CheckDuplicates(rows []VendorImportRow) ([]ImportRowError, error) {
+	var rowErrors []ImportRowError
+
+	emailRows := make(map[string]int, len(rows))
+	gstRows := make(map[string]int, len(rows))
+	emails := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.Email != "" {
+			if first, ok := emailRows[row.Email]; ok {
+				rowErrors = append(rowErrors, ImportRowError{Row: row.Row, Column: "email", Message: fmt.Sprintf("duplicate of row %d", first)})
+			} else {
+				emailRows[row.Email] = row.Row
+				emails = append(emails, row.Email)
+			}
+		}
+		if row.GSTNo != "" {
+			if first, ok := gstRows[row.GSTNo]; ok {
+				rowErrors = append(rowErrors, ImportRowError{Row: row.Row, Column: "gst_no", Message: fmt.Sprintf("duplicate of row %d", first)})
+			} else {
+				gstRows[row.GSTNo] = row.Row
+			}
+		}
+	}
+
+	// Looked up in chunks to stay well below the bind parameter limit
+	for start := 0; start < len(emails); start += 1000 {
+		end := start + 1000
+		if end > len(emails) {
+			end = len(emails)
+		}
+		var existing []string
+		if err := database.DB.Model(&models.User{}).
+			Where("LOWER(email) IN ?", emails[start:end]).
+			Pluck("LOWER(email)", &existing).Error; err != nil {
+			return nil, err
+		}
+		for _, email := range existing {
+			rowErrors = append(rowErrors, ImportRowError{Row: emailRows[email], Column: "email", Message: "Email is already present"})
+		}
+	}
+
+	for gstNo, row := range gstRows {
+		if err := is.vendorProfileService.CheckGSTINAvailable(database.DB, gstNo, 0); err != nil {
+			if !errors.Is(err, ErrDuplicateGSTIN) {
+				return nil, err
+			}
+			rowErrors = append(rowErrors, ImportRowError{Row: row, Column: "gst_no", Message: err.Error()})
+		}
+	}
+
+	return rowErrors, nil
+}
+
+// CreateBatch creates the users, vendor details and categories of a batch of
+// vendors inside tx and makes them members of the organization orgID. The vendors
+// start pending like self registered ones and cannot log in until they set a password.
+func (is *VendorImportService) CreateBatch(tx *gorm.DB, orgID uint, rows []VendorImportRow) ([]models.User, error) {
+	users := make([]models.User, len(rows))
+	for i, row := range rows {
+		users[i] = models.User{
+			FirstName: row.FirstName,
+			LastName:  row.LastName,
+			Email:     row.Email,
+			Password:  unusablePassword,
+			Role:      models.RoleVendor,
+			IsActive:  true,
+		}
+	}
+
+	// Skips the hook hashing the password, which would hash the marker
+	if err := tx.Session(&gorm.Session{SkipHooks: true}).Create(&users).Error; err != nil {
+		return nil, err
+	}
+
+	details := make([]models.VendorDetails, len(rows))
+	for i, row := range rows {
+		details[i] = models.VendorDetails{
+			UserID:       users[i].ID,
+			Revenue:      row.Revenue,
+			NoOfEmployee: row.EmployeeCount,
+			GSTNo:        row.GSTNo,
+			PANNo:        row.PANNo,
+			PhoneNo:      row.PhoneNo,
+		}
+	}
+	if err := tx.Create(&details).Error; err != nil {
+		return nil, err
+	}
+
+	memberships := make([]models.VendorOrganization, len(rows))
+	for i := range rows {
+		memberships[i] = models.VendorOrganization{
+			UserID:         users[i].ID,
+			OrganizationID: orgID,
+			Status:         models.VendorPending,
+		}
+	}
+	if err := tx.Create(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	for i, row := range rows {
+		if err := is.vendorCategoryService.SetCategories(tx, orgID, users[i].ID, row.CategoryIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	return users, nil
+}
+
+// categoryLookup maps the IDs and lowercased names of the active categories of
+// the organization to their ID
+func (is *VendorImportService) categoryLookup(orgID uint) (map[string]uint, error) {
+	var categories []models.Category
+	if err := database.DB.Where("tenant_id = ? AND is_active = ?", orgID, true).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	lookup := make(map[string]uint, len(categories)*2)
+	for _, category := range categories {
+		lookup[strconv.FormatUint(uint64(category.ID), 10)] = category.ID
+		lookup[strings.ToLower(strings.TrimSpace(category.Name))] = category.ID
+	}
+	return lookup, nil
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+	// Excel writes a byte order mark in front of UTF-8 CSV files
+	if len(records) > 0 && len(records[0]) > 0 {
+		records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+	}
+	return records, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
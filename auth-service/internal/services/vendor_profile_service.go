@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidCategory         = errors.New("category not found")
+	ErrDuplicateGSTIN          = errors.New("GSTIN is already registered to another vendor")
+	ErrNoPendingProfileChange  = errors.New("no profile change is pending")
+	ErrProfileChangeNotFound   = errors.New("profile change not found")
+	ErrProfileChangeNotPending = errors.New("profile change was already reviewed")
+)
+
+// VendorProfileService handles vendor profile updates and the verification of
+// changes to regulated details
+type VendorProfileService struct{}
+
+func NewVendorProfileService() *VendorProfileService {
+	return &VendorProfileService{}
+}
+
+// CheckGSTINAvailable makes sure no other vendor uses the GSTIN or waits for it to be verified
+func (vs *VendorProfileService) CheckGSTINAvailable(db *gorm.DB, gstNo string, userID uint) error {
+	if gstNo == "" {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(&models.VendorDetails{}).
+		Where("UPPER(gst_no) = ? AND user_id <> ?", gstNo, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		if err := db.Model(&models.VendorProfileChange{}).
+			Where("gst_no = ? AND user_id <> ? AND status = ?", gstNo, userID, models.ProfileChangePending).
+			Count(&count).Error; err != nil {
+			return err
+		}
+	}
+	if count > 0 {
+		return ErrDuplicateGSTIN
+	}
+	return nil
+}
+
+// PendingChange returns the change of the vendor awaiting verification, or nil
+func (vs *VendorProfileService) PendingChange(userID uint) (*models.VendorProfileChange, error) {
+	var change models.VendorProfileChange
+	err := database.DB.Where("user_id = ? AND status = ?", userID, models.ProfileChangePending).First(&change).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// RequestChange records new GST/PAN values for verification. A vendor has at most
+// one pending change, asking again replaces the values of the earlier request.
+func (vs *VendorProfileService) RequestChange(tx *gorm.DB, details *models.VendorDetails, gstNo, panNo string) (*models.VendorProfileChange, error) {
+	// Serializes concurrent requests of the same vendor
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.VendorDetails{}, details.ID).Error; err != nil {
+		return nil, err
+	}
+
+	var change models.VendorProfileChange
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND status = ?", details.UserID, models.ProfileChangePending).
+		First(&change).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		change = models.VendorProfileChange{
+			UserID:        details.UserID,
+			GSTNo:         gstNo,
+			PANNo:         panNo,
+			PreviousGSTNo: details.GSTNo,
+			PreviousPANNo: details.PANNo,
+			Status:        models.ProfileChangePending,
+		}
+		if err := tx.Create(&change).Error; err != nil {
+			return nil, err
+		}
+		return &change, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&change).Updates(map[string]interface{}{
+		"gst_no": gstNo,
+		"pan_no": panNo,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// CancelPendingChange withdraws the pending change of the vendor
+func (vs *VendorProfileService) CancelPendingChange(userID uint) error {
+	result := database.DB.Model(&models.VendorProfileChange{}).
+		Where("user_id = ? AND status = ?", userID, models.ProfileChangePending).
+		Update("status", models.ProfileChangeCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNoPendingProfileChange
+	}
+	return nil
+}
+
+// Review approves or rejects a pending change of a vendor of the organization
+// orgID. Approving copies the new values into the vendor details.
+func (vs *VendorProfileService) Review(orgID, id, reviewerID uint, approve bool, notes string) (*models.VendorProfileChange, error) {
+	var change models.VendorProfileChange
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id IN (?)", organizationVendors(tx, orgID)).
+			First(&change, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProfileChangeNotFound
+			}
+			return err
+		}
+		if change.Status != models.ProfileChangePending {
+			return ErrProfileChangeNotPending
+		}
+
+		status := models.ProfileChangeRejected
+		if approve {
+			status = models.ProfileChangeApproved
+			if err := vs.CheckGSTINAvailable(tx, change.GSTNo, change.UserID); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.VendorDetails{}).
+				Where("user_id = ?", change.UserID).
+				Updates(map[string]interface{}{
+					"gst_no": change.GSTNo,
+					"pan_no": change.PANNo,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		return tx.Model(&change).Updates(map[string]interface{}{
+			"status":       status,
+			"reviewed_by":  reviewerID,
+			"reviewed_at":  &now,
+			"review_notes": notes,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidVendorSort = errors.New("sort must be one of relevance, newest, oldest, name, revenue, employees")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+)
+
+const (
+	DefaultVendorSearchLimit = 20
+	MaxVendorSearchLimit     = 100
+
+	// maxSearchTerms bounds the words of a search query
+	maxSearchTerms = 10
+)
+
+// VendorSearchFilter narrows the vendors of an organization. Zero values do not filter.
+type VendorSearchFilter struct {
+	Query          string // full-text, matched against name and email
+	CategoryIDs    []uint // vendors approved for any of the categories
+	Statuses       []models.VendorStatus
+	MinRevenue     *float64
+	MaxRevenue     *float64
+	MinEmployees   *int
+	MaxEmployees   *int
+	RegisteredFrom *time.Time
+	RegisteredTo   *time.Time // exclusive
+	Sort           string     // relevance by default when searching, newest otherwise
+	Cursor         string     // NextCursor of the previous page
+	Limit          int
+}
+
+// VendorSearchHit is a vendor found by a search with their status in the organization
+type VendorSearchHit struct {
+	ID           uint                    `json:"id"`
+	FirstName    string                  `json:"first_name"`
+	LastName     string                  `json:"last_name"`
+	Email        string                  `json:"email"`
+	IsActive     bool                    `json:"is_active"`
+	Status       models.VendorStatus     `json:"status"`
+	Revenue      float64                 `json:"revenue"`
+	NoOfEmployee int                     `json:"no_of_employee"`
+	RegisteredAt time.Time               `json:"registered_at"`
+	Rank         float64                 `json:"rank,omitempty"`
+	Categories   []models.VendorCategory `json:"categories" gorm:"-"`
+}
+
+// FacetCount is how many vendors match a value of a filter
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// RangeFacet is the range of values of the vendors matching the other filters
+type RangeFacet struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// VendorSearchFacets count the vendors per filter value. Each facet applies every
+// filter but its own, so the UI can show what selecting another value would give.
+type VendorSearchFacets struct {
+	Statuses   []FacetCount `json:"statuses"`
+	Categories []FacetCount `json:"categories"`
+	Revenue    RangeFacet   `json:"revenue"`
+	Employees  RangeFacet   `json:"employees"`
+}
+
+// VendorSearchResult is a page of vendors. Facets are only computed for the first page.
+type VendorSearchResult struct {
+	Vendors    []VendorSearchHit   `json:"vendors"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	Facets     *VendorSearchFacets `json:"facets,omitempty"`
+}
+
+// vendorSort orders vendors by an SQL expression, ties broken by user ID. The
+// expression may use the search query as its only parameter.
+type vendorSort struct {
+	expr      string
+	sqlType   string // type of expr, used to compare cursors
+	desc      bool
+	usesQuery bool
+}
+
+var vendorSorts = map[string]vendorSort{
+	"relevance": {expr: "CAST(ts_rank(" + models.UserSearchDocument + ", to_tsquery('simple', ?)) AS double precision)", sqlType: "double precision", desc: true, usesQuery: true},
+	"newest":    {expr: "users.created_at", sqlType: "timestamptz", desc: true},
+	"oldest":    {expr: "users.created_at", sqlType: "timestamptz"},
+	"name":      {expr: "LOWER(users.first_name || ' ' || users.last_name)", sqlType: "text"},
+	"revenue":   {expr: "COALESCE(vendor_details.revenue, 0)", sqlType: "numeric", desc: true},
+	"employees": {expr: "COALESCE(vendor_details.no_of_employee, 0)", sqlType: "bigint", desc: true},
+}
+
+// vendorCursor is where a page ended: the sort key and ID of its last vendor
+type vendorCursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   uint   `json:"id"`
+}
+
+// Filters a facet leaves out
+const (
+	facetNone      = ""
+	facetStatus    = "status"
+	facetCategory  = "category"
+	facetRevenue   = "revenue"
+	facetEmployees = "employees"
+)
+
+// VendorSearchService searches the vendors of an organization for admins choosing
+// who to invite to RFPs
+type VendorSearchService struct{}
+
+func NewVendorSearchService() *VendorSearchService {
+	return &VendorSearchService{}
+}
+
+// Search returns a page of the vendors of the organization orgID matching filter
+func (vs *VendorSearchService) Search(orgID uint, filter VendorSearchFilter) (*VendorSearchResult, error) {
+	tsQuery := prefixTSQuery(filter.Query)
+
+	sortName := filter.Sort
+	if sortName == "" {
+		sortName = "newest"
+		if tsQuery != "" {
+			sortName = "relevance"
+		}
+	}
+	sort, ok := vendorSorts[sortName]
+	if !ok {
+		return nil, ErrInvalidVendorSort
+	}
+	if sort.usesQuery && tsQuery == "" {
+		// Nothing to rank without a search, fall back to the listing order
+		sortName, sort = "newest", vendorSorts["newest"]
+	}
+	var sortVars []interface{}
+	if sort.usesQuery {
+		sortVars = []interface{}{tsQuery}
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultVendorSearchLimit
+	}
+	if limit > MaxVendorSearchLimit {
+		limit = MaxVendorSearchLimit
+	}
+
+	query := vs.filtered(orgID, filter, tsQuery, facetNone)
+
+	if filter.Cursor != "" {
+		cursor, err := decodeVendorCursor(filter.Cursor)
+		if err != nil || cursor.Sort != sortName {
+			return nil, ErrInvalidCursor
+		}
+		op := ">"
+		if sort.desc {
+			op = "<"
+		}
+		key := "CAST(? AS " + sort.sqlType + ")"
+		args := append(append(append(append([]interface{}{}, sortVars...), cursor.Key), sortVars...), cursor.Key, cursor.ID)
+		query = query.Where("("+sort.expr+" "+op+" "+key+" OR ("+sort.expr+" = "+key+" AND users.id "+op+" ?))", args...)
+	}
+
+	selects := "users.id, users.first_name, users.last_name, users.email, users.is_active, vendor_organizations.status, " +
+		"COALESCE(vendor_details.revenue, 0) AS revenue, COALESCE(vendor_details.no_of_employee, 0) AS no_of_employee, " +
+		"users.created_at AS registered_at, CAST(" + sort.expr + " AS text) AS sort_key"
+	selectVars := append([]interface{}{}, sortVars...)
+	if tsQuery != "" {
+		selects += ", ts_rank(" + models.UserSearchDocument + ", to_tsquery('simple', ?)) AS rank"
+		selectVars = append(selectVars, tsQuery)
+	}
+
+	direction := " ASC"
+	if sort.desc {
+		direction = " DESC"
+	}
+
+	var rows []struct {
+		VendorSearchHit
+		SortKey string
+	}
+	if err := query.Select(selects, selectVars...).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                sort.expr + direction + ", users.id" + direction,
+			Vars:               sortVars,
+			WithoutParentheses: true,
+		}}).
+		Limit(limit + 1).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := &VendorSearchResult{Vendors: make([]VendorSearchHit, 0, limit)}
+	for i, row := range rows {
+		if i == limit {
+			last := rows[limit-1]
+			result.NextCursor = encodeVendorCursor(vendorCursor{Sort: sortName, Key: last.SortKey, ID: last.ID})
+			break
+		}
+		result.Vendors = append(result.Vendors, row.VendorSearchHit)
+	}
+
+	if err := vs.attachCategories(orgID, result.Vendors); err != nil {
+		return nil, err
+	}
+
+	if filter.Cursor == "" {
+		facets, err := vs.facets(orgID, filter, tsQuery)
+		if err != nil {
+			return nil, err
+		}
+		result.Facets = facets
+	}
+
+	return result, nil
+}
+
+// filtered selects the vendors of the organization matching filter, leaving out
+// the filter of the facet except
+func (vs *VendorSearchService) filtered(orgID uint, filter VendorSearchFilter, tsQuery, except string) *gorm.DB {
+	query := database.DB.Model(&models.User{}).
+		Joins("JOIN vendor_organizations ON vendor_organizations.user_id = users.id AND vendor_organizations.organization_id = ?", orgID).
+		Joins("LEFT JOIN vendor_details ON vendor_details.user_id = users.id").
+		Where("users.role = ?", models.RoleVendor)
+
+	if tsQuery != "" {
+		query = query.Where(models.UserSearchDocument+" @@ to_tsquery('simple', ?)", tsQuery)
+	}
+	if except != facetCategory && len(filter.CategoryIDs) > 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM vendor_categories WHERE vendor_categories.user_id = users.id AND vendor_categories.status = ? AND vendor_categories.category_id IN ? AND vendor_categories.category_id IN (?))",
+			models.VendorCategoryApproved, filter.CategoryIDs, tenantCategories(database.DB, orgID))
+	}
+	if except != facetStatus && len(filter.Statuses) > 0 {
+		query = query.Where("vendor_organizations.status IN ?", filter.Statuses)
+	}
+	if except != facetRevenue {
+		if filter.MinRevenue != nil {
+			query = query.Where("COALESCE(vendor_details.revenue, 0) >= ?", *filter.MinRevenue)
+		}
+		if filter.MaxRevenue != nil {
+			query = query.Where("COALESCE(vendor_details.revenue, 0) <= ?", *filter.MaxRevenue)
+		}
+	}
+	if except != facetEmployees {
+		if filter.MinEmployees != nil {
+			query = query.Where("COALESCE(vendor_details.no_of_employee, 0) >= ?", *filter.MinEmployees)
+		}
+		if filter.MaxEmployees != nil {
+			query = query.Where("COALESCE(vendor_details.no_of_employee, 0) <= ?", *filter.MaxEmployees)
+		}
+	}
+	if filter.RegisteredFrom != nil {
+		query = query.Where("users.created_at >= ?", *filter.RegisteredFrom)
+	}
+	if filter.RegisteredTo != nil {
+		query = query.Where("users.created_at < ?", *filter.RegisteredTo)
+	}
+	return query
+}
+
+// facets counts the vendors per status and category and the range of their
+// revenue and employee count
+func (vs *VendorSearchService) facets(orgID uint, filter VendorSearchFilter, tsQuery string) (*VendorSearchFacets, error) {
+	facets := &VendorSearchFacets{
+		Statuses:   []FacetCount{},
+		Categories: []FacetCount{},
+	}
+
+	if err := vs.filtered(orgID, filter, tsQuery, facetStatus).
+		Select("vendor_organizations.status AS value, COUNT(*) AS count").
+		Group("vendor_organizations.status").
+		Order("vendor_organizations.status").
+		Scan(&facets.Statuses).Error; err != nil {
+		return nil, err
+	}
+
+	if err := vs.filtered(orgID, filter, tsQuery, facetCategory).
+		Joins("JOIN vendor_categories ON vendor_categories.user_id = users.id AND vendor_categories.status = ?", models.VendorCategoryApproved).
+		Joins("JOIN categories ON categories.id = vendor_categories.category_id AND categories.tenant_id = ?", orgID).
+		Select("CAST(categories.id AS text) AS value, categories.name AS label, COUNT(DISTINCT users.id) AS count").
+		Group("categories.id, categories.name").
+		Order("count DESC, categories.name").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, err
+	}
+
+	if err := vs.filtered(orgID, filter, tsQuery, facetRevenue).
+		Select("COALESCE(MIN(COALESCE(vendor_details.revenue, 0)), 0) AS min, COALESCE(MAX(COALESCE(vendor_details.revenue, 0)), 0) AS max").
+		Scan(&facets.Revenue).Error; err != nil {
+		return nil, err
+	}
+
+	if err := vs.filtered(orgID, filter, tsQuery, facetEmployees).
+		Select("COALESCE(MIN(COALESCE(vendor_details.no_of_employee, 0)), 0) AS min, COALESCE(MAX(COALESCE(vendor_details.no_of_employee, 0)), 0) AS max").
+		Scan(&facets.Employees).Error; err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// attachCategories loads the categories of the organization each vendor serves
+func (vs *VendorSearchService) attachCategories(orgID uint, hits []VendorSearchHit) error {
+	if len(hits) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	var vendorCategories []models.VendorCategory
+	if err := database.DB.Where("user_id IN ? AND category_id IN (?)", ids, tenantCategories(database.DB, orgID)).
+		Preload("Category").
+		Order("id").
+		Find(&vendorCategories).Error; err != nil {
+		return err
+	}
+
+	byVendor := make(map[uint][]models.VendorCategory, len(hits))
+	for _, vendorCategory := range vendorCategories {
+		byVendor[vendorCategory.UserID] = append(byVendor[vendorCategory.UserID], vendorCategory)
+	}
+	for i := range hits {
+		hits[i].Categories = byVendor[hits[i].ID]
+		if hits[i].Categories == nil {
+			hits[i].Categories = []models.VendorCategory{}
+		}
+	}
+	return nil
+}
+
+// prefixTSQuery turns a search into a to_tsquery expression matching every word
+// as a prefix. Words are split like UserSearchDocument splits names and emails.
+func prefixTSQuery(search string) string {
+	words := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+func encodeVendorCursor(cursor vendorCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeVendorCursor(encoded string) (vendorCursor, error) {
+	var cursor vendorCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, err
+	}
+	if cursor.ID == 0 {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/karan-bishtt/auth-service/internal/database"
+	"github.com/karan-bishtt/auth-service/internal/models"
+	"github.com/karan-bishtt/auth-service/internal/utils"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+// VerificationService issues and redeems single-use tokens sent by email
+type VerificationService struct{}
+
+func NewVerificationService() *VerificationService {
+	return &VerificationService{}
+}
+
+// Issue creates a token for the user and purpose, replacing any earlier one
+func (vs *VerificationService) Issue(userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, err := utils.NewSecureToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ?", userID, purpose).Delete(&models.VerificationToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.VerificationToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Lookup returns the user a valid token was issued to without redeeming it
+func (vs *VerificationService) Lookup(token, purpose string) (uint, error) {
+	var stored models.VerificationToken
+	if err := database.DB.Where("token_hash = ? AND purpose = ?", utils.HashToken(token), purpose).First(&stored).Error; err != nil {
+		return 0, ErrInvalidVerificationToken
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return 0, ErrInvalidVerificationToken
+	}
+	return stored.UserID, nil
+}
+
+// Consume redeems a token inside tx and returns the user it was issued to
+func (vs *VerificationService) Consume(tx *gorm.DB, token, purpose string) (uint, error) {
+	var stored models.VerificationToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", utils.HashToken(token), purpose).First(&stored).Error; err != nil {
+		return 0, ErrInvalidVerificationToken
+	}
+
+	// Deleting is what makes the token single-use, whoever deletes the row wins
+	result := tx.Delete(&stored)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(stored.ExpiresAt) {
+		return 0, ErrInvalidVerificationToken
+	}
+
+	return stored.UserID, nil
+}
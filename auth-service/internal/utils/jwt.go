@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/karan-bishtt/auth-service/config"
@@ -9,24 +12,79 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Token types, carried in the token_type claim so one kind of token
+// can never be used in place of another
+const (
+	TokenTypeAccess        = "access"
+	TokenTypeRefresh       = "refresh"
+	TokenTypeMFAChallenge  = "mfa_challenge"     // password checked, TOTP code still required
+	TokenTypeMFAEnrollment = "mfa_enrollment"    // password checked, TOTP must be set up first
+	TokenTypeService       = "service"           // client credentials of an internal service
+	TokenTypeOnboarding    = "vendor_onboarding" // vendor not approved yet, may only complete their profile
+)
+
+// Scopes that can be granted to service clients
+const (
+	ScopeUsersRead         = "users:read"         // look up users on auth-service
+	ScopeNotificationsSend = "notifications:send" // send email through notification-service
+)
+
+// ServiceScopes lists every scope a service client can be granted
+var ServiceScopes = []string{ScopeUsersRead, ScopeNotificationsSend}
+
+const (
+	AccessTokenDuration  = time.Minute * 24 * 1
+	RefreshTokenDuration = time.Hour * 24 * 7
+	MFATokenDuration     = time.Minute * 5
+	ServiceTokenDuration = time.Minute * 15
+	OnboardingDuration   = time.Hour
+)
+
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	UserID    uint   `json:"user_id"`
+	Role      string `json:"role"`
+	TokenType string `json:"token_type"`
+	SessionID string `json:"sid,omitempty"`
+	TenantID  uint   `json:"tenant_id,omitempty"` // organization the user acts for, user tokens only
+	Scope     string `json:"scope,omitempty"`     // space separated, service tokens only
 	jwt.RegisteredClaims
 }
 
-// GenerateTokenPair generates both refresh and access tokens
-func GenerateTokenPair(userID uint, role string) (refreshToken, accessToken string, err error) {
+// HasScope reports whether a service token was granted scope
+func (c *Claims) HasScope(scope string) bool {
+	for _, granted := range strings.Fields(c.Scope) {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateTokenPair generates both refresh and access tokens.
+// refreshTokenID becomes the jti of the refresh token so it can be tracked and revoked,
+// sessionID becomes the sid of the access token so services can check the session,
+// tenantID the organization every service scopes the user's requests to.
+func GenerateTokenPair(userID uint, role string, tenantID uint, refreshTokenID, sessionID string) (refreshToken, accessToken string, err error) {
 	cfg := config.Load()
 
-	// Generate access token (15 minutes)
-	accessToken, err = generateToken(userID, role, time.Minute*24*1, cfg.JWTSecret)
+	accessTokenID, err := NewRandomID()
 	if err != nil {
 		return "", "", err
 	}
 
-	// Generate refresh token (7 days)
-	refreshToken, err = generateToken(userID, role, time.Hour*24*7, cfg.JWTSecret)
+	// Access tokens are accepted by every service of the platform
+	accessClaims := newClaims(userID, role, TokenTypeAccess, accessTokenID, cfg.JWTAudience, AccessTokenDuration)
+	accessClaims.SessionID = sessionID
+	accessClaims.TenantID = tenantID
+	accessToken, err = generateToken(accessClaims)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Refresh tokens are only ever redeemed by auth-service itself
+	refreshClaims := newClaims(userID, role, TokenTypeRefresh, refreshTokenID, cfg.JWTIssuer, RefreshTokenDuration)
+	refreshClaims.TenantID = tenantID
+	refreshToken, err = generateToken(refreshClaims)
 	if err != nil {
 		return "", "", err
 	}
@@ -34,61 +92,152 @@ func GenerateTokenPair(userID uint, role string) (refreshToken, accessToken stri
 	return refreshToken, accessToken, nil
 }
 
-// generateToken creates a JWT token with specified duration
-func generateToken(userID uint, role string, duration time.Duration, secret string) (string, error) {
-	claims := &Claims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+// GenerateMFAToken issues a short-lived token for the second login step.
+// Like refresh tokens it is only accepted by auth-service. The tenant picked at
+// login is carried over to the tokens issued once the second step passes.
+func GenerateMFAToken(userID uint, role string, tenantID uint, tokenType string) (string, error) {
+	cfg := config.Load()
+
+	tokenID, err := NewRandomID()
+	if err != nil {
+		return "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	claims := newClaims(userID, role, tokenType, tokenID, cfg.JWTIssuer, MFATokenDuration)
+	claims.TenantID = tenantID
+	return generateToken(claims)
 }
 
-// ValidateToken validates a JWT token and returns claims
-func ValidateToken(tokenString string) (*Claims, error) {
+// GenerateOnboardingToken issues a token for a vendor awaiting approval by the
+// organization tenantID, which only gives access to their own profile and
+// documents on auth-service
+func GenerateOnboardingToken(userID, tenantID uint) (string, error) {
 	cfg := config.Load()
 
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return []byte(cfg.JWTSecret), nil
-	})
-
+	tokenID, err := NewRandomID()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims := newClaims(userID, "vendor", TokenTypeOnboarding, tokenID, cfg.JWTIssuer, OnboardingDuration)
+	claims.TenantID = tenantID
+	return generateToken(claims)
+}
+
+// GenerateServiceToken issues a client credentials token for an internal service.
+// The client ID becomes the subject and the granted scopes the scope claim.
+func GenerateServiceToken(clientID, scope string) (string, error) {
+	cfg := config.Load()
+
+	tokenID, err := NewRandomID()
+	if err != nil {
+		return "", err
 	}
 
-	return nil, errors.New("invalid token")
+	claims := newClaims(0, TokenTypeService, TokenTypeService, tokenID, cfg.JWTAudience, ServiceTokenDuration)
+	claims.Subject = clientID
+	claims.Scope = scope
+	return generateToken(claims)
 }
 
-// RefreshAccessToken generates a new access token from a valid refresh token
-func RefreshAccessToken(refreshTokenString string) (newAccessToken string, err error) {
-	claims, err := ValidateToken(refreshTokenString)
+func newClaims(userID uint, role, tokenType, tokenID, audience string, duration time.Duration) *Claims {
+	cfg := config.Load()
+	now := time.Now()
+
+	return &Claims{
+		UserID:    userID,
+		Role:      role,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    cfg.JWTIssuer,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+}
+
+// generateToken signs the claims with the active signing key
+func generateToken(claims *Claims) (string, error) {
+	key, err := activeSigningKey()
 	if err != nil {
-		return "", errors.New("invalid refresh token")
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.PrivateKey)
+}
+
+// NewRandomID returns a random hex identifier used for token IDs and families
+func NewRandomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
+	return hex.EncodeToString(b), nil
+}
 
+// ValidateToken validates an access token and returns claims
+func ValidateToken(tokenString string) (*Claims, error) {
 	cfg := config.Load()
+	return parseToken(tokenString, TokenTypeAccess, cfg.JWTAudience)
+}
+
+// ValidateOnboardingToken validates the token of a vendor awaiting approval
+func ValidateOnboardingToken(tokenString string) (*Claims, error) {
+	cfg := config.Load()
+	return parseToken(tokenString, TokenTypeOnboarding, cfg.JWTIssuer)
+}
+
+// ValidateServiceToken validates a client credentials token and returns claims
+func ValidateServiceToken(tokenString string) (*Claims, error) {
+	cfg := config.Load()
+	return parseToken(tokenString, TokenTypeService, cfg.JWTAudience)
+}
+
+// ValidateRefreshToken validates a refresh token and returns claims
+func ValidateRefreshToken(tokenString string) (*Claims, error) {
+	cfg := config.Load()
+	return parseToken(tokenString, TokenTypeRefresh, cfg.JWTIssuer)
+}
+
+// ValidateMFAToken validates a challenge or enrollment token and returns claims
+func ValidateMFAToken(tokenString, tokenType string) (*Claims, error) {
+	cfg := config.Load()
+	return parseToken(tokenString, tokenType, cfg.JWTIssuer)
+}
+
+// parseToken verifies signature, expiry, issuer and audience as well as the token type
+func parseToken(tokenString, tokenType, audience string) (*Claims, error) {
+	cfg := config.Load()
+
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return verificationKey(kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(cfg.JWTIssuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+	)
 
-	// Generate new access token
-	newAccessToken, err = generateToken(claims.UserID, claims.Role, time.Minute*15, cfg.JWTSecret)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.TokenType != tokenType || claims.ID == "" {
+		return nil, errors.New("invalid token type")
 	}
 
-	return newAccessToken, nil
+	return claims, nil
 }
 
 // ExtractTokenFromHeader extracts token from Authorization header
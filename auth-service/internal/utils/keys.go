@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SigningKey is an RSA key identified by its kid
+type SigningKey struct {
+	KID        string
+	PrivateKey *rsa.PrivateKey
+}
+
+// keyStore holds every key found in JWT_KEYS_DIR. Only the active key signs,
+// the others are still published in the JWKS so tokens they signed keep
+// verifying until the key file is removed.
+type keyStore struct {
+	mu     sync.RWMutex
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+var signingKeys = &keyStore{keys: make(map[string]*SigningKey)}
+
+// JWK is the public part of a signing key as published on /.well-known/jwks.json
+type JWK struct {
+	KTY string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	KID string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadSigningKeys reads every <kid>.pem file of keysDir. The key named activeKID
+// signs new tokens, when activeKID is empty the lexically greatest kid is used,
+// so naming keys by date (e.g. 2025-01-31.pem) rotates them naturally.
+// Without keys a temporary key is generated, which is only allowed outside production.
+func LoadSigningKeys(keysDir, activeKID, environment string) error {
+	keys := make(map[string]*SigningKey)
+
+	files, err := filepath.Glob(filepath.Join(keysDir, "*.pem"))
+	if err != nil {
+		return fmt.Errorf("failed to list signing keys: %w", err)
+	}
+
+	for _, file := range files {
+		privateKey, err := readRSAPrivateKey(file)
+		if err != nil {
+			return fmt.Errorf("failed to load signing key %s: %w", file, err)
+		}
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		keys[kid] = &SigningKey{KID: kid, PrivateKey: privateKey}
+	}
+
+	if len(keys) == 0 {
+		if environment == "production" {
+			return fmt.Errorf("no signing keys found in %s", keysDir)
+		}
+		log.Printf("⚠️  No signing keys found in %s, generating a temporary key", keysDir)
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		kid, err := NewRandomID()
+		if err != nil {
+			return err
+		}
+		keys[kid] = &SigningKey{KID: kid, PrivateKey: privateKey}
+	}
+
+	if activeKID == "" {
+		kids := make([]string, 0, len(keys))
+		for kid := range keys {
+			kids = append(kids, kid)
+		}
+		sort.Strings(kids)
+		activeKID = kids[len(kids)-1]
+	}
+
+	active, ok := keys[activeKID]
+	if !ok {
+		return fmt.Errorf("active signing key %s not found in %s", activeKID, keysDir)
+	}
+
+	signingKeys.mu.Lock()
+	signingKeys.keys = keys
+	signingKeys.active = active
+	signingKeys.mu.Unlock()
+
+	log.Printf("Loaded %d signing keys, active kid %s", len(keys), activeKID)
+	return nil
+}
+
+// activeSigningKey returns the key new tokens are signed with
+func activeSigningKey() (*SigningKey, error) {
+	signingKeys.mu.RLock()
+	defer signingKeys.mu.RUnlock()
+
+	if signingKeys.active == nil {
+		return nil, errors.New("signing keys are not loaded")
+	}
+	return signingKeys.active, nil
+}
+
+// verificationKey returns the public key for a kid
+func verificationKey(kid string) (*rsa.PublicKey, error) {
+	signingKeys.mu.RLock()
+	defer signingKeys.mu.RUnlock()
+
+	key, ok := signingKeys.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return &key.PrivateKey.PublicKey, nil
+}
+
+// PublicJWKS returns the public keys of every loaded signing key
+func PublicJWKS() JWKS {
+	signingKeys.mu.RLock()
+	defer signingKeys.mu.RUnlock()
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(signingKeys.keys))}
+	for kid, key := range signingKeys.keys {
+		publicKey := key.PrivateKey.PublicKey
+		jwks.Keys = append(jwks.Keys, JWK{
+			KTY: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			KID: kid,
+			N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		})
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].KID < jwks.Keys[j].KID })
+
+	return jwks
+}
+
+// readRSAPrivateKey accepts PKCS#1 ("RSA PRIVATE KEY") and PKCS#8 ("PRIVATE KEY") PEM files
+func readRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("key is not an RSA private key")
+		}
+		return rsaKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/karan-bishtt/auth-service/config"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords is the embedded deny list, lower cased
+var commonPasswords = parseDenyList(commonPasswordsFile)
+
+// Password policy rules, reported in PasswordViolation.Rule
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleUppercase    = "uppercase"
+	PasswordRuleLowercase    = "lowercase"
+	PasswordRuleDigit        = "digit"
+	PasswordRuleSymbol       = "symbol"
+	PasswordRuleCommon       = "common_password"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleHistory      = "history"
+)
+
+// bcrypt ignores everything after 72 bytes, so longer passwords are refused
+const bcryptMaxLength = 72
+
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	HistorySize   int // previous passwords that may not be reused
+}
+
+// PasswordViolation is one failed rule of the password policy
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// LoadPasswordPolicy reads the policy from the PASSWORD_* settings
+func LoadPasswordPolicy() PasswordPolicy {
+	cfg := config.Load()
+
+	maxLength := cfg.PasswordMaxLength
+	if maxLength <= 0 || maxLength > bcryptMaxLength {
+		maxLength = bcryptMaxLength
+	}
+
+	return PasswordPolicy{
+		MinLength:     cfg.PasswordMinLength,
+		MaxLength:     maxLength,
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+		HistorySize:   cfg.PasswordHistorySize,
+	}
+}
+
+// Check returns every rule the password breaks. personalInfo holds values such as
+// the email and names of the user that must not appear in the password.
+func (p PasswordPolicy) Check(password string, personalInfo ...string) []PasswordViolation {
+	var violations []PasswordViolation
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, PasswordViolation{PasswordRuleMinLength, fmt.Sprintf("must be at least %d characters long", p.MinLength)})
+	}
+	if len(password) > p.MaxLength {
+		violations = append(violations, PasswordViolation{PasswordRuleMaxLength, fmt.Sprintf("must be at most %d bytes long", p.MaxLength)})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c) || unicode.IsSpace(c):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, PasswordViolation{PasswordRuleUppercase, "must contain an uppercase letter"})
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, PasswordViolation{PasswordRuleLowercase, "must contain a lowercase letter"})
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, PasswordViolation{PasswordRuleDigit, "must contain a digit"})
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, PasswordViolation{PasswordRuleSymbol, "must contain a symbol"})
+	}
+
+	lower := strings.ToLower(password)
+	if _, found := commonPasswords[lower]; found {
+		violations = append(violations, PasswordViolation{PasswordRuleCommon, "is too common, choose a less predictable password"})
+	}
+
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if at := strings.Index(info, "@"); at >= 0 {
+			info = info[:at]
+		}
+		if len(info) >= 3 && strings.Contains(lower, info) {
+			violations = append(violations, PasswordViolation{PasswordRulePersonalInfo, "must not contain your name or email"})
+			break
+		}
+	}
+
+	return violations
+}
+
+func parseDenyList(file string) map[string]struct{} {
+	denyList := make(map[string]struct{})
+	for _, line := range strings.Split(file, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		denyList[strings.ToLower(line)] = struct{}{}
+	}
+	return denyList
+}
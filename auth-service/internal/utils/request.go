@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/karan-bishtt/auth-service/config"
+)
+
+// ClientIP returns the address of the caller. X-Real-IP and X-Forwarded-For are
+// only honoured with TRUST_PROXY_HEADERS=true, otherwise any client could spoof them.
+func ClientIP(r *http.Request) string {
+	if config.Load().TrustProxyHeaders {
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip
+		}
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
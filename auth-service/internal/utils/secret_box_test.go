@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSealSecretRoundTrip(t *testing.T) {
+	key, err := NewSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := SealSecret(key, "JBSWY3DPEHPK3PXP", "user_mfa:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealedSecret(sealed) || strings.Contains(sealed, "JBSWY3DPEHPK3PXP") {
+		t.Fatalf("sealed = %q, want an opaque sealed value", sealed)
+	}
+
+	opened, err := OpenSecret(key, sealed, "user_mfa:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("opened = %q, want the plaintext", opened)
+	}
+
+	// A value copied to another user's row does not open there
+	if _, err := OpenSecret(key, sealed, "user_mfa:2"); !errors.Is(err, ErrInvalidSealedSecret) {
+		t.Fatalf("other context: err = %v, want ErrInvalidSealedSecret", err)
+	}
+
+	otherKey, err := NewSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenSecret(otherKey, sealed, "user_mfa:1"); !errors.Is(err, ErrInvalidSealedSecret) {
+		t.Fatalf("other key: err = %v, want ErrInvalidSealedSecret", err)
+	}
+
+	if _, err := OpenSecret(key, "JBSWY3DPEHPK3PXP", "user_mfa:1"); !errors.Is(err, ErrInvalidSealedSecret) {
+		t.Fatalf("plaintext: err = %v, want ErrInvalidSealedSecret", err)
+	}
+}
+
+func TestParseSecretKey(t *testing.T) {
+	if _, err := ParseSecretKey("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="); err != nil {
+		t.Fatalf("32 byte key: %v", err)
+	}
+	if _, err := ParseSecretKey("c2hvcnQ="); err == nil {
+		t.Fatal("short key was accepted")
+	}
+	if _, err := ParseSecretKey("not base64!"); err == nil {
+		t.Fatal("invalid base64 was accepted")
+	}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PAN: five letters, four digits and a letter. The fourth letter is the holder
+// type, e.g. P for individuals and C for companies.
+var panPattern = regexp.MustCompile(`^[A-Z]{3}[ABCFGHJLPT][A-Z][0-9]{4}[A-Z]$`)
+
+// GSTIN of a regular taxpayer: state code, PAN, entity number, Z and the check digit
+var gstinPattern = regexp.MustCompile(`^[0-9]{2}[A-Z]{3}[ABCFGHJLPT][A-Z][0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+
+const gstinCharset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// NormalizeTaxID upper cases a PAN or GSTIN and strips surrounding spaces
+func NormalizeTaxID(id string) string {
+	return strings.ToUpper(strings.TrimSpace(id))
+}
+
+// ValidPAN reports whether pan is a well-formed PAN
+func ValidPAN(pan string) bool {
+	return panPattern.MatchString(NormalizeTaxID(pan))
+}
+
+// ValidGSTIN checks the structure, the state code and the check digit of a GSTIN
+func ValidGSTIN(gstin string) bool {
+	gstin = NormalizeTaxID(gstin)
+	if !gstinPattern.MatchString(gstin) {
+		return false
+	}
+
+	state, _ := strconv.Atoi(gstin[:2])
+	if !validGSTStateCode(state) {
+		return false
+	}
+
+	return GSTINCheckDigit(gstin[:14]) == gstin[14]
+}
+
+// PANFromGSTIN returns the PAN embedded in a GSTIN
+func PANFromGSTIN(gstin string) string {
+	gstin = NormalizeTaxID(gstin)
+	if len(gstin) != 15 {
+		return ""
+	}
+	return gstin[2:12]
+}
+
+// GSTINCheckDigit computes the mod 36 check character over the first 14
+// characters of a GSTIN: odd positions are weighted 1 and even positions 2,
+// and the quotient and remainder of every product by 36 are summed.
+func GSTINCheckDigit(first14 string) byte {
+	sum := 0
+	for i := 0; i < len(first14); i++ {
+		value := strings.IndexByte(gstinCharset, first14[i])
+		if value < 0 {
+			return 0
+		}
+		product := value * (i%2 + 1)
+		sum += product/36 + product%36
+	}
+	return gstinCharset[(36-sum%36)%36]
+}
+
+// validGSTStateCode accepts the state and union territory codes (01-38), other
+// territory (97) and centre jurisdiction (99)
+func validGSTStateCode(code int) bool {
+	return (code >= 1 && code <= 38) || code == 97 || code == 99
+}
@@ -0,0 +1,144 @@
+package utils
+
+import "testing"
+
+// withCheckDigit completes the first 14 characters of a GSTIN with their check
+// digit, so a test can get exactly one thing wrong
+func withCheckDigit(first14 string) string {
+	return first14 + string(GSTINCheckDigit(first14))
+}
+
+func TestValidGSTIN(t *testing.T) {
+	tests := []struct {
+		name  string
+		gstin string
+		want  bool
+	}{
+		// Published GSTINs
+		{"Maharashtra firm", "27AAPFU0939F1ZV", true},
+		{"Karnataka company", "29AAGCB7383J1Z4", true},
+		{"Tamil Nadu trust", "33GSPTN0231G1ZM", true},
+		{"Gujarat company", "24AAACC1206D1ZM", true},
+		{"check digit Z", "09AAACH7409R1ZZ", true},
+
+		{"lower case", "27aapfu0939f1zv", true},
+		{"surrounding spaces", "  27AAPFU0939F1ZV\t", true},
+
+		{"wrong check digit", "27AAPFU0939F1ZW", false},
+		{"transposed characters", "27AAPFU0993F1ZV", false},
+		{"state code 00", withCheckDigit("00AAPFU0939F1Z"), false},
+		{"state code 39", withCheckDigit("39AAPFU0939F1Z"), false},
+		{"state code 96", withCheckDigit("96AAPFU0939F1Z"), false},
+		{"other territory 97", withCheckDigit("97AAPFU0939F1Z"), true},
+		{"centre jurisdiction 99", withCheckDigit("99AAPFU0939F1Z"), true},
+		{"entity number 0", withCheckDigit("27AAPFU0939F0Z"), false},
+		{"missing Z", withCheckDigit("27AAPFU0939F1Y"), false},
+		{"invalid holder type", withCheckDigit("27AAPXU0939F1Z"), false},
+		{"too short", "27AAPFU0939F1Z", false},
+		{"too long", "27AAPFU0939F1ZVV", false},
+		{"inner space", "27AAPFU 0939F1ZV", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidGSTIN(tt.gstin); got != tt.want {
+			t.Errorf("%s: ValidGSTIN(%q) = %v, want %v", tt.name, tt.gstin, got, tt.want)
+		}
+	}
+}
+
+func TestGSTINCheckDigit(t *testing.T) {
+	tests := map[string]byte{
+		"27AAPFU0939F1Z": 'V',
+		"29AAGCB7383J1Z": '4',
+		"33GSPTN0231G1Z": 'M',
+		"09AAACH7409R1Z": 'Z',
+		"27AAPFU0939F1z": 0, // not normalized
+	}
+
+	for first14, want := range tests {
+		if got := GSTINCheckDigit(first14); got != want {
+			t.Errorf("GSTINCheckDigit(%q) = %q, want %q", first14, got, want)
+		}
+	}
+}
+
+func TestValidPAN(t *testing.T) {
+	tests := []struct {
+		pan  string
+		want bool
+	}{
+		{"AAPFU0939F", true},
+		{"ABCPE1234F", true},
+		{"abcpe1234f", true},
+		{" ABCPE1234F ", true},
+		{"ABCXE1234F", false}, // X is no holder type
+		{"ABCPE1234", false},
+		{"ABCPE12345", false},
+		{"1BCPE1234F", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidPAN(tt.pan); got != tt.want {
+			t.Errorf("ValidPAN(%q) = %v, want %v", tt.pan, got, tt.want)
+		}
+	}
+}
+
+func TestPANFromGSTIN(t *testing.T) {
+	tests := map[string]string{
+		"27AAPFU0939F1ZV":     "AAPFU0939F",
+		" 27aapfu0939f1zv ":   "AAPFU0939F",
+		"27AAPFU0939F1Z":      "",
+		"27AAPFU0939F1ZVXYZ0": "",
+	}
+
+	for gstin, want := range tests {
+		if got := PANFromGSTIN(gstin); got != want {
+			t.Errorf("PANFromGSTIN(%q) = %q, want %q", gstin, got, want)
+		}
+	}
+}
+
+func TestNormalizeTaxID(t *testing.T) {
+	tests := map[string]string{
+		"27aapfu0939f1zv":       "27AAPFU0939F1ZV",
+		"\t AAPFU0939F \n":      "AAPFU0939F",
+		"":                      "",
+		" 27AaPfU0939f1Zv     ": "27AAPFU0939F1ZV",
+	}
+
+	for id, want := range tests {
+		if got := NormalizeTaxID(id); got != want {
+			t.Errorf("NormalizeTaxID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestValidateGSTINMatchesPAN(t *testing.T) {
+	type taxDetails struct {
+		PANNo string `validate:"omitempty,pan"`
+		GSTNo string `validate:"omitempty,gstin,gstin_pan=PANNo"`
+	}
+
+	tests := []struct {
+		name    string
+		details taxDetails
+		wantErr bool
+	}{
+		{"matching", taxDetails{PANNo: "AAPFU0939F", GSTNo: "27AAPFU0939F1ZV"}, false},
+		{"matching after normalization", taxDetails{PANNo: " aapfu0939f", GSTNo: "27aapfu0939f1zv "}, false},
+		{"GSTIN of another PAN", taxDetails{PANNo: "AAGCB7383J", GSTNo: "27AAPFU0939F1ZV"}, true},
+		{"no PAN to compare", taxDetails{GSTNo: "27AAPFU0939F1ZV"}, false},
+		{"invalid GSTIN", taxDetails{PANNo: "AAPFU0939F", GSTNo: "27AAPFU0939F1ZW"}, true},
+		{"invalid PAN", taxDetails{PANNo: "AAPXU0939F"}, true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateStruct(tt.details)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateStruct = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
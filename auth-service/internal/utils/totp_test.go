@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B seeds, the ASCII digits repeated to the length of each hash
+var rfc6238Secrets = map[TOTPAlgorithm][]byte{
+	TOTPAlgorithmSHA1:   []byte("12345678901234567890"),
+	TOTPAlgorithmSHA256: []byte("12345678901234567890123456789012"),
+	TOTPAlgorithmSHA512: []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+}
+
+func rfc6238Config(algorithm TOTPAlgorithm) TOTPConfig {
+	return TOTPConfig{Digits: 8, Period: 30 * time.Second, Algorithm: algorithm, Skew: 1}
+}
+
+func TestTOTPRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix      int64
+		algorithm TOTPAlgorithm
+		want      string
+	}{
+		{59, TOTPAlgorithmSHA1, "94287082"},
+		{59, TOTPAlgorithmSHA256, "46119246"},
+		{59, TOTPAlgorithmSHA512, "90693936"},
+		{1111111109, TOTPAlgorithmSHA1, "07081804"},
+		{1111111109, TOTPAlgorithmSHA256, "68084774"},
+		{1111111109, TOTPAlgorithmSHA512, "25091201"},
+		{1111111111, TOTPAlgorithmSHA1, "14050471"},
+		{1111111111, TOTPAlgorithmSHA256, "67062674"},
+		{1111111111, TOTPAlgorithmSHA512, "99943326"},
+		{1234567890, TOTPAlgorithmSHA1, "89005924"},
+		{1234567890, TOTPAlgorithmSHA256, "91819424"},
+		{1234567890, TOTPAlgorithmSHA512, "93441116"},
+		{2000000000, TOTPAlgorithmSHA1, "69279037"},
+		{2000000000, TOTPAlgorithmSHA256, "90698825"},
+		{2000000000, TOTPAlgorithmSHA512, "38618901"},
+		{20000000000, TOTPAlgorithmSHA1, "65353130"},
+		{20000000000, TOTPAlgorithmSHA256, "77737706"},
+		{20000000000, TOTPAlgorithmSHA512, "47863826"},
+	}
+
+	for _, tt := range tests {
+		cfg := rfc6238Config(tt.algorithm)
+		at := time.Unix(tt.unix, 0).UTC()
+
+		if got := TOTP(rfc6238Secrets[tt.algorithm], at, cfg); got != tt.want {
+			t.Errorf("TOTP(%s, T=%d) = %s, want %s", tt.algorithm, tt.unix, got, tt.want)
+		}
+		if _, ok := ValidateTOTP(rfc6238Secrets[tt.algorithm], tt.want, at, cfg); !ok {
+			t.Errorf("ValidateTOTP(%s, T=%d) rejected %s", tt.algorithm, tt.unix, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTPSkewWindow(t *testing.T) {
+	secret := rfc6238Secrets[TOTPAlgorithmSHA1]
+	cfg := rfc6238Config(TOTPAlgorithmSHA1)
+	now := time.Unix(1111111111, 0)
+	current := TOTPCounter(now, cfg)
+
+	tests := []struct {
+		name   string
+		step   int64
+		wantOK bool
+	}{
+		{"current step", 0, true},
+		{"one step behind", -1, true},
+		{"one step ahead", 1, true},
+		{"two steps behind", -2, false},
+		{"two steps ahead", 2, false},
+	}
+
+	for _, tt := range tests {
+		counter := uint64(int64(current) + tt.step)
+		code := HOTP(secret, counter, cfg.Digits, cfg.Algorithm)
+
+		got, ok := ValidateTOTP(secret, code, now, cfg)
+		if ok != tt.wantOK {
+			t.Errorf("%s: ValidateTOTP ok = %v, want %v", tt.name, ok, tt.wantOK)
+			continue
+		}
+		if ok && got != counter {
+			t.Errorf("%s: ValidateTOTP counter = %d, want %d", tt.name, got, counter)
+		}
+	}
+}
+
+func TestValidateTOTPRejectsMalformedCodes(t *testing.T) {
+	secret := rfc6238Secrets[TOTPAlgorithmSHA1]
+	cfg := rfc6238Config(TOTPAlgorithmSHA1)
+	now := time.Unix(59, 0)
+
+	for _, code := range []string{"", "9428708", "942870820", "abcdefgh"} {
+		if _, ok := ValidateTOTP(secret, code, now, cfg); ok {
+			t.Errorf("ValidateTOTP accepted %q", code)
+		}
+	}
+}
+
+func TestDecodeTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Users retype secrets in lower case, with spaces and with padding
+	for _, input := range []string{secret, strings.ToLower(secret), secret[:4] + " " + secret[4:], secret + "===="} {
+		decoded, err := DecodeTOTPSecret(input)
+		if err != nil {
+			t.Errorf("DecodeTOTPSecret(%q): %v", input, err)
+			continue
+		}
+		if len(decoded) != 20 {
+			t.Errorf("DecodeTOTPSecret(%q) = %d bytes, want 20", input, len(decoded))
+		}
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/go-playground/validator"
 )
@@ -17,6 +18,18 @@ Used for package initialization
 */
 func init() {
 	validate = validator.New()
+
+	// Indian tax identifiers, see tax_id.go
+	validate.RegisterValidation("pan", validatePAN)
+	validate.RegisterValidation("gstin", validateGSTIN)
+	validate.RegisterValidation("gstin_pan", validateGSTINMatchesPAN)
+}
+
+// tagMessages explains the custom tags, which mean nothing to API users on their own
+var tagMessages = map[string]string{
+	"pan":       "not a valid PAN",
+	"gstin":     "not a valid GSTIN",
+	"gstin_pan": "a GSTIN that does not contain the given PAN",
 }
 
 func ValidateStruct(s interface{}) error {
@@ -25,8 +38,53 @@ func ValidateStruct(s interface{}) error {
 	if err != nil {
 		// Return first validating error
 		for _, err := range err.(validator.ValidationErrors) {
-			return fmt.Errorf("field %s is %s", err.Field(), err.Tag())
+			return fmt.Errorf("field %s is %s", err.Field(), validationMessage(err))
 		}
 	}
 	return nil
 }
+
+// FieldError is the failed validation of a single struct field
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidateStructFields validates s and returns every failing field instead of only the first
+func ValidateStructFields(s interface{}) []FieldError {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrors []FieldError
+	for _, err := range err.(validator.ValidationErrors) {
+		fieldErrors = append(fieldErrors, FieldError{Field: err.Field(), Message: validationMessage(err)})
+	}
+	return fieldErrors
+}
+
+func validationMessage(err validator.FieldError) string {
+	if message, ok := tagMessages[err.Tag()]; ok {
+		return message
+	}
+	return err.Tag()
+}
+
+func validatePAN(fl validator.FieldLevel) bool {
+	return ValidPAN(fl.Field().String())
+}
+
+func validateGSTIN(fl validator.FieldLevel) bool {
+	return ValidGSTIN(fl.Field().String())
+}
+
+// validateGSTINMatchesPAN checks the PAN embedded in the GSTIN against the PAN in the
+// field named by the tag parameter, e.g. gstin_pan=PANNo. An empty PAN is not compared.
+func validateGSTINMatchesPAN(fl validator.FieldLevel) bool {
+	pan, kind, found := fl.GetStructFieldOK()
+	if !found || kind != reflect.String || pan.String() == "" {
+		return true
+	}
+	return PANFromGSTIN(fl.Field().String()) == NormalizeTaxID(pan.String())
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidXLSX is returned for files that are not a readable XLSX workbook
+var ErrInvalidXLSX = errors.New("file is not a valid XLSX workbook")
+
+// maxXLSXPartSize bounds how much of a single workbook part is read, the parts
+// are compressed so the upload size alone does not bound them
+const maxXLSXPartSize = 64 << 20
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (rt xlsxRichText) String() string {
+	if len(rt.Runs) == 0 {
+		return rt.Text
+	}
+	var b strings.Builder
+	for _, run := range rt.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref       string       `xml:"r,attr"`
+			Type      string       `xml:"t,attr"`
+			Value     string       `xml:"v"`
+			InlineStr xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSXRows returns the cell values of the first worksheet of an XLSX workbook.
+// Only what tabular imports need is supported: shared, inline and formula strings,
+// numbers and booleans. Empty cells between values are returned as "".
+func ReadXLSXRows(r io.ReaderAt, size int64) ([][]string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrInvalidXLSX
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(file, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	file, ok := files[sheetPath]
+	if !ok {
+		return nil, ErrInvalidXLSX
+	}
+	var sheet xlsxWorksheet
+	if err := decodeXLSXPart(file, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var values []string
+		for i, cell := range row.Cells {
+			column := i
+			if cell.Ref != "" {
+				if column, err = xlsxColumnIndex(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+			for len(values) < column {
+				values = append(values, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, ErrInvalidXLSX
+				}
+				value = shared.Items[index].String()
+			case "inlineStr":
+				value = cell.InlineStr.String()
+			case "b":
+				value = map[string]string{"1": "TRUE", "0": "FALSE"}[cell.Value]
+			}
+
+			if column < len(values) {
+				values[column] = value
+			} else {
+				values = append(values, value)
+			}
+		}
+		rows = append(rows, values)
+	}
+
+	return rows, nil
+}
+
+// firstSheetPath resolves the part of the first worksheet through the workbook relationships
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook xlsxWorkbook
+	var rels xlsxRelationships
+	workbookFile, ok := files["xl/workbook.xml"]
+	relsFile, relsOK := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !relsOK {
+		return "", ErrInvalidXLSX
+	}
+	if err := decodeXLSXPart(workbookFile, &workbook); err != nil {
+		return "", err
+	}
+	if err := decodeXLSXPart(relsFile, &rels); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", ErrInvalidXLSX
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", ErrInvalidXLSX
+}
+
+func decodeXLSXPart(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return ErrInvalidXLSX
+	}
+	defer reader.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(reader, maxXLSXPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidXLSX, file.Name, err)
+	}
+	return nil
+}
+
+// xlsxColumnIndex turns the column letters of a cell reference like "AB12" into a zero based index
+func xlsxColumnIndex(ref string) (int, error) {
+	column := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+		letters++
+	}
+	if letters == 0 || letters > 3 {
+		return 0, ErrInvalidXLSX
+	}
+	return column - 1, nil
+}
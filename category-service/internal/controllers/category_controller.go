@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/karan-bishtt/category-service/internal/database"
+	"github.com/karan-bishtt/category-service/internal/middleware"
 	"github.com/karan-bishtt/category-service/internal/models"
 	"github.com/karan-bishtt/category-service/internal/utils"
 
@@ -60,6 +61,13 @@ type AddCategoryRequest struct {
 	Name string `json:"name" validate:"required,min=1,max=100"`
 }
 
+// PublicCategory is all anonymous callers see of a category, what vendor
+// registration needs to pick one
+type PublicCategory struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
 type UpdateCategoryRequest struct {
 	Name     *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
 	IsActive *bool   `json:"is_active" gorm:"default:true"`
@@ -98,6 +106,42 @@ func respondWithPagination(w http.ResponseWriter, status int, message string, da
 
 // endregion json response
 
+// region tenant
+
+// tenantForRead returns the organization a category read is scoped to. Logged in
+// callers read their own organization. Anonymous callers, vendors picking categories
+// while registering, name an active organization by its slug and are public.
+func tenantForRead(w http.ResponseWriter, r *http.Request) (tenantID uint, public bool, ok bool) {
+	if tenantID, ok := middleware.GetTenantIDFromContext(r); ok {
+		return tenantID, false, true
+	}
+
+	slug := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("organization")))
+	if slug == "" {
+		respondWithJSON(w, http.StatusBadRequest, "organization is required", nil)
+		return 0, false, false
+	}
+
+	var org models.Organization
+	if err := database.DB.Select("id").Where("slug = ? AND is_active = ?", slug, true).First(&org).Error; err != nil {
+		respondWithJSON(w, http.StatusNotFound, "Organization not found", nil)
+		return 0, false, false
+	}
+	return org.ID, true, true
+}
+
+// tenantFromToken returns the organization of the logged in admin
+func tenantFromToken(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	tenantID, ok := middleware.GetTenantIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return 0, false
+	}
+	return tenantID, true
+}
+
+// endregion tenant
+
 // CreateCategory creates a new category
 func (cc *CategoryController) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -105,6 +149,11 @@ func (cc *CategoryController) CreateCategory(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	var req AddCategoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithJSON(w, http.StatusBadRequest, "Data is not in correct format", nil)
@@ -124,15 +173,16 @@ func (cc *CategoryController) CreateCategory(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Check if category already exists
+	// Check if category already exists in the organization
 	var existingCategory models.Category
-	if err := database.DB.Where("LOWER(name) = LOWER(?)", req.Name).First(&existingCategory).Error; err == nil {
+	if err := database.DB.Where("tenant_id = ? AND LOWER(name) = LOWER(?)", tenantID, req.Name).First(&existingCategory).Error; err == nil {
 		respondWithJSON(w, 404, "Category already exists", nil)
 		return
 	}
 
 	// Create new category
 	category := models.Category{
+		TenantID: tenantID,
 		Name:     req.Name,
 		IsActive: true,
 	}
@@ -148,18 +198,24 @@ func (cc *CategoryController) CreateCategory(w http.ResponseWriter, r *http.Requ
 
 	respondWithJSON(w, 200, "Category created successfully", map[string]interface{}{
 		"id":        category.ID,
+		"tenant_id": category.TenantID,
 		"name":      category.Name,
 		"is_active": category.IsActive,
 	})
 }
 
-// GetCategories retrieves categories with pagination
+// GetCategories retrieves the categories of an organization with pagination
 func (cc *CategoryController) GetCategories(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
 
+	tenantID, public, ok := tenantForRead(w, r)
+	if !ok {
+		return
+	}
+
 	// Parse query parameters
 	pageStr := r.URL.Query().Get("page")
 	limitStr := r.URL.Query().Get("limit")
@@ -183,7 +239,12 @@ func (cc *CategoryController) GetCategories(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Build query
-	query := database.DB.Model(&models.Category{})
+	query := database.DB.Model(&models.Category{}).Where("tenant_id = ?", tenantID)
+
+	// Anonymous callers only get to pick from the active categories
+	if public {
+		status = "active"
+	}
 
 	// Filter by status
 	switch strings.ToLower(status) {
@@ -222,16 +283,25 @@ func (cc *CategoryController) GetCategories(w http.ResponseWriter, r *http.Reque
 		TotalPages:  totalPages,
 	}
 
+	if public {
+		respondWithPagination(w, 200, "Categories retrieved successfully", publicCategories(categories), pagination)
+		return
+	}
 	respondWithPagination(w, 200, "Categories retrieved successfully", categories, pagination)
 }
 
-// GetCategory retrieves a single category by ID
+// GetCategory retrieves a single category of an organization by ID
 func (cc *CategoryController) GetCategory(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
 
+	tenantID, public, ok := tenantForRead(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
@@ -241,22 +311,45 @@ func (cc *CategoryController) GetCategory(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	query := database.DB.Where("tenant_id = ?", tenantID)
+	if public {
+		query = query.Where("is_active = ?", true)
+	}
+
 	var category models.Category
-	if err := database.DB.First(&category, uint(id)).Error; err != nil {
+	if err := query.First(&category, uint(id)).Error; err != nil {
 		respondWithJSON(w, 404, "Category not found", nil)
 		return
 	}
 
+	if public {
+		respondWithJSON(w, 200, "Category retrieved successfully", PublicCategory{ID: category.ID, Name: category.Name})
+		return
+	}
 	respondWithJSON(w, 200, "Category retrieved successfully", category)
 }
 
-// UpdateCategory updates an existing category
+// publicCategories strips categories down to what anonymous callers may see
+func publicCategories(categories []models.Category) []PublicCategory {
+	public := make([]PublicCategory, 0, len(categories))
+	for _, category := range categories {
+		public = append(public, PublicCategory{ID: category.ID, Name: category.Name})
+	}
+	return public
+}
+
+// UpdateCategory updates an existing category of the admin's organization
 func (cc *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
@@ -280,7 +373,7 @@ func (cc *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Requ
 
 	// Find existing category
 	var category models.Category
-	if err := database.DB.First(&category, uint(id)).Error; err != nil {
+	if err := database.DB.Where("tenant_id = ?", tenantID).First(&category, uint(id)).Error; err != nil {
 		respondWithJSON(w, 404, "Category not found", nil)
 		return
 	}
@@ -301,7 +394,7 @@ func (cc *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Requ
 
 		// Check if another category with this name exists
 		var existingCategory models.Category
-		if err := tx.Where("LOWER(name) = LOWER(?) AND id != ?", newName, id).First(&existingCategory).Error; err == nil {
+		if err := tx.Where("tenant_id = ? AND LOWER(name) = LOWER(?) AND id != ?", tenantID, newName, id).First(&existingCategory).Error; err == nil {
 			tx.Rollback()
 			respondWithJSON(w, 409, "Another category with this name already exists", nil)
 			return
@@ -328,13 +421,18 @@ func (cc *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Requ
 	respondWithJSON(w, 200, "Category updated successfully", category)
 }
 
-// DeleteCategory soft deletes a category
+// DeleteCategory soft deletes a category of the admin's organization
 func (cc *CategoryController) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
@@ -346,7 +444,7 @@ func (cc *CategoryController) DeleteCategory(w http.ResponseWriter, r *http.Requ
 
 	// Find existing category
 	var category models.Category
-	if err := database.DB.First(&category, uint(id)).Error; err != nil {
+	if err := database.DB.Where("tenant_id = ?", tenantID).First(&category, uint(id)).Error; err != nil {
 		respondWithJSON(w, 404, "Category not found", nil)
 		return
 	}
@@ -366,13 +464,18 @@ func (cc *CategoryController) DeleteCategory(w http.ResponseWriter, r *http.Requ
 	respondWithJSON(w, 200, "Category deleted successfully", nil)
 }
 
-// ToggleCategoryStatus toggles the active status of a category
+// ToggleCategoryStatus toggles the active status of a category of the admin's organization
 func (cc *CategoryController) ToggleCategoryStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPatch {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
@@ -384,7 +487,7 @@ func (cc *CategoryController) ToggleCategoryStatus(w http.ResponseWriter, r *htt
 
 	// Find existing category
 	var category models.Category
-	if err := database.DB.First(&category, uint(id)).Error; err != nil {
+	if err := database.DB.Where("tenant_id = ?", tenantID).First(&category, uint(id)).Error; err != nil {
 		respondWithJSON(w, 404, "Category not found", nil)
 		return
 	}
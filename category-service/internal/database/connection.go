@@ -13,6 +13,9 @@ import (
 
 var DB *gorm.DB
 
+// defaultOrganizationSlug is the organization auth-service moves existing data into
+const defaultOrganizationSlug = "default"
+
 func InitDB(databaseURL string) (*gorm.DB, error) {
 	var err error
 	fmt.Println("InitDB file initialize")
@@ -61,9 +64,48 @@ func InitDB(databaseURL string) (*gorm.DB, error) {
 	}
 	fmt.Println("✅ Auto migration completed!")
 
+	// Category names used to be unique across the platform, now per organization
+	if DB.Migrator().HasIndex(&models.Category{}, "idx_categories_name") {
+		if err := DB.Migrator().DropIndex(&models.Category{}, "idx_categories_name"); err != nil {
+			return nil, fmt.Errorf("failed to drop category name index: %w", err)
+		}
+	}
+
+	if err := backfillCategoryTenants(); err != nil {
+		return nil, fmt.Errorf("failed to backfill category organizations: %w", err)
+	}
+
 	return DB, nil
 }
 
+// backfillCategoryTenants moves the categories that predate organizations into the
+// default organization created by auth-service. When auth-service has not created it
+// yet they are moved on the next start.
+func backfillCategoryTenants() error {
+	if !DB.Migrator().HasTable("organizations") {
+		fmt.Println("⚠️  Organizations do not exist yet, categories are moved on the next start")
+		return nil
+	}
+
+	var tenantIDs []uint
+	if err := DB.Table("organizations").Where("slug = ?", defaultOrganizationSlug).Pluck("id", &tenantIDs).Error; err != nil {
+		return err
+	}
+	if len(tenantIDs) == 0 {
+		fmt.Println("⚠️  Default organization does not exist yet, categories are moved on the next start")
+		return nil
+	}
+
+	result := DB.Model(&models.Category{}).Where("tenant_id = 0").Update("tenant_id", tenantIDs[0])
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		fmt.Printf("✅ Moved %d categories into the default organization\n", result.RowsAffected)
+	}
+	return nil
+}
+
 // Helper function to mask password in database URL for logging
 func maskPassword(databaseURL string) string {
 	// Simple masking - you might want to use regex for better parsing
@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/karan-bishtt/category-service/internal/utils"
+	"gorm.io/gorm"
 )
 
 type contextKey string
@@ -12,6 +14,7 @@ type contextKey string
 const (
 	UserIDKey   contextKey = "user_id"
 	UserRoleKey contextKey = "user_role"
+	TenantIDKey contextKey = "tenant_id"
 )
 
 // AuthMiddleware validates JWT tokens and sets user context
@@ -27,21 +30,72 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		// Validate token
-		userID, role, err := utils.GetUserFromToken(tokenString)
+		claims, err := utils.ValidateToken(tokenString)
 		if err != nil {
 			http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid token"}`, http.StatusUnauthorized)
 			return
 		}
 
+		// Tokens issued before organizations existed do not say which one they act for
+		if claims.TenantID == 0 {
+			http.Error(w, `{"status": 401, "message": "Unauthorized: Token has no organization, please log in again"}`, http.StatusUnauthorized)
+			return
+		}
+
+		// Tokens of a session logged out in auth-service are rejected before they expire
+		if status, message := checkSession(claims.SessionID); status != http.StatusOK {
+			http.Error(w, message, status)
+			return
+		}
+
 		// Add user info to context
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		ctx = context.WithValue(ctx, UserRoleKey, role)
+		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+		ctx = context.WithValue(ctx, TenantIDKey, claims.TenantID)
 
 		// Call next handler with updated context
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// OptionalAuthMiddleware authenticates requests that carry a bearer token like
+// AuthMiddleware does and lets requests without one through anonymously
+func OptionalAuthMiddleware(next http.Handler) http.Handler {
+	authenticated := AuthMiddleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
+// RequirePermission middleware checks if user has specific permission
+func RequirePermission(resource, action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := r.Context().Value(UserIDKey).(uint)
+			if !ok {
+				http.Error(w, `{"status": 401, "message": "Unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+
+			hasPermission, err := checkUserPermission(userID, resource, action)
+			if err != nil {
+				http.Error(w, `{"status": 500, "message": "Failed to check permissions"}`, http.StatusInternalServerError)
+				return
+			}
+			if !hasPermission {
+				http.Error(w, `{"status": 403, "message": "Forbidden: Insufficient permissions"}`, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // RequireRole middleware checks if user has specific role
 func RequireRole(allowedRoles ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -71,6 +125,40 @@ func RequireRole(allowedRoles ...string) func(http.Handler) http.Handler {
 	}
 }
 
+// checkSession verifies the session of an access token is still active. Every
+// access token is bound to a session, one without a sid predates sessions.
+func checkSession(sessionID string) (int, string) {
+	if sessionID == "" {
+		return http.StatusUnauthorized, `{"status": 401, "message": "Unauthorized: Token has no session, please log in again"}`
+	}
+
+	active, err := activeSessions.isActive(sessionID)
+	if err != nil {
+		return http.StatusInternalServerError, `{"status": 500, "message": "Failed to check session"}`
+	}
+	if !active {
+		return http.StatusUnauthorized, `{"status": 401, "message": "Unauthorized: Session has been revoked"}`
+	}
+	return http.StatusOK, ""
+}
+
+// checkUserPermission resolves the user's permissions (cached) and checks resource/action
+func checkUserPermission(userID uint, resource, action string) (bool, error) {
+	user, err := userPermissions.get(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if !user.IsActive {
+		return false, nil
+	}
+
+	return user.HasPermission(resource, action), nil
+}
+
 // GetUserIDFromContext extracts user ID from request context
 func GetUserIDFromContext(r *http.Request) (uint, bool) {
 	userID, ok := r.Context().Value(UserIDKey).(uint)
@@ -82,3 +170,9 @@ func GetUserRoleFromContext(r *http.Request) (string, bool) {
 	role, ok := r.Context().Value(UserRoleKey).(string)
 	return role, ok
 }
+
+// GetTenantIDFromContext returns the organization the request acts for
+func GetTenantIDFromContext(r *http.Request) (uint, bool) {
+	tenantID, ok := r.Context().Value(TenantIDKey).(uint)
+	return tenantID, ok && tenantID != 0
+}
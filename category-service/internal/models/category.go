@@ -4,9 +4,11 @@ import (
 	"time"
 )
 
+// Category is owned by one buyer organization (tenant), names are unique per organization
 type Category struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name" gorm:"not null;size:100;uniqueIndex"`
+	TenantID  uint      `json:"tenant_id" gorm:"not null;default:0;uniqueIndex:idx_categories_tenant_name,priority:1"`
+	Name      string    `json:"name" gorm:"not null;size:100;uniqueIndex:idx_categories_tenant_name,priority:2"`
 	IsActive  bool      `json:"is_active" gorm:"default:true"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
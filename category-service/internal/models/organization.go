@@ -0,0 +1,13 @@
+package models
+
+// Organization is owned by auth-service, this service only reads it to resolve
+// the organization a public category request names by its slug
+type Organization struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Slug     string `json:"slug"`
+	IsActive bool   `json:"is_active"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// The tables below are owned and migrated by auth-service. They live in the
+// shared database and are only read here to resolve permissions.
+
+type User struct {
+	ID       uint `json:"id" gorm:"primaryKey"`
+	IsActive bool `json:"is_active"`
+
+	// Bumped by auth-service whenever the permissions or the active flag change
+	PermissionsVersion uint `json:"-"`
+
+	UserPermissions []UserPermission `json:"permissions,omitempty" gorm:"foreignKey:UserID"`
+}
+
+type Permission struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name"`
+	Resource  string    `json:"resource"`
+	Action    string    `json:"action"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserPermission struct {
+	ID           uint        `json:"id" gorm:"primaryKey"`
+	UserID       uint        `json:"user_id"`
+	PermissionID uint        `json:"permission_id"`
+	Permission   *Permission `json:"permission" gorm:"foreignKey:PermissionID"`
+}
+
+func (User) TableName() string {
+	return "users" // Make sure this matches your auth-service table
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+func (UserPermission) TableName() string {
+	return "user_permissions"
+}
+
+func (u *User) HasPermission(resource, action string) bool {
+	for _, up := range u.UserPermissions {
+		if up.Permission != nil &&
+			up.Permission.Resource == resource &&
+			up.Permission.Action == action {
+			return true
+		}
+	}
+	return false
+}
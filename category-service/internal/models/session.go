@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// UserSession is owned by auth-service, this service checks it to reject access
+// tokens of revoked sessions and records when the session was last used
+type UserSession struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	SessionID  string     `json:"-"`
+	UserID     uint       `json:"user_id"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
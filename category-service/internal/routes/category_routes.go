@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"net/http"
+
 	"github.com/karan-bishtt/category-service/internal/controllers"
 
 	"github.com/gorilla/mux"
@@ -15,9 +17,10 @@ func SetupCategoryRoutes() *mux.Router {
 	// Public routes (if needed)
 	api := router.PathPrefix("/api/v1").Subrouter()
 
-	// Public routes (without authentication)
-	api.HandleFunc("/categories", categoryController.GetCategories).Methods("GET")
-	api.HandleFunc("/categories/{id:[0-9]+}", categoryController.GetCategory).Methods("GET")
+	// Public routes, scoped by the organization of the token when logged in and by
+	// the organization query parameter (a slug) for vendors who are registering
+	api.Handle("/categories", middleware.OptionalAuthMiddleware(http.HandlerFunc(categoryController.GetCategories))).Methods("GET")
+	api.Handle("/categories/{id:[0-9]+}", middleware.OptionalAuthMiddleware(http.HandlerFunc(categoryController.GetCategory))).Methods("GET")
 
 	// Protected routes - require authentication and authorization
 	protected := api.PathPrefix("/categories").Subrouter()
@@ -25,6 +28,7 @@ func SetupCategoryRoutes() *mux.Router {
 	// Apply authentication middleware here
 	protected.Use(middleware.AuthMiddleware)
 	protected.Use(middleware.RequireRole("admin"))
+	protected.Use(middleware.RequirePermission("category", "manage"))
 
 	// Category CRUD routes (admin protected)
 	protected.HandleFunc("", categoryController.CreateCategory).Methods("POST")
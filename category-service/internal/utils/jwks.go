@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/karan-bishtt/category-service/config"
+)
+
+const (
+	// jwksCacheTTL is how long fetched keys are trusted before refetching
+	jwksCacheTTL = 10 * time.Minute
+	// jwksMinRefreshInterval limits refetches triggered by unknown kids
+	jwksMinRefreshInterval = 30 * time.Second
+)
+
+type jwk struct {
+	KTY string `json:"kty"`
+	KID string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// jwksCache holds the public keys published by auth-service. This service
+// never holds signing material, it can only verify tokens.
+type jwksCache struct {
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	client    *http.Client
+}
+
+var authServiceKeys = &jwksCache{
+	keys:   make(map[string]*rsa.PublicKey),
+	client: &http.Client{Timeout: 5 * time.Second},
+}
+
+// publicKey returns the key for kid, refetching the JWKS when the cache
+// expired or the kid is unknown (e.g. right after a key rotation)
+func (c *jwksCache) publicKey(kid string) (*rsa.PublicKey, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	age := time.Since(c.fetchedAt)
+	c.mu.RUnlock()
+
+	if ok && age < jwksCacheTTL {
+		return key, nil
+	}
+
+	if !ok && age < jwksMinRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if err := c.refresh(); err != nil {
+		// Keep serving known keys if auth-service is briefly unreachable
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if key, ok := c.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (c *jwksCache) refresh() error {
+	cfg := config.Load()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another request may have refreshed while we were waiting for the lock
+	if time.Since(c.fetchedAt) < jwksMinRefreshInterval {
+		return nil
+	}
+	c.fetchedAt = time.Now()
+
+	resp, err := c.client.Get(cfg.JWKSURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS endpoint returned status: %d", resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.KTY != "RSA" {
+			continue
+		}
+		key, err := parseRSAPublicKey(k)
+		if err != nil {
+			continue
+		}
+		keys[k.KID] = key
+	}
+	c.keys = keys
+
+	return nil
+}
+
+func parseRSAPublicKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
@@ -21,10 +21,11 @@ type NotificationResponse struct {
 }
 
 type SendEmailRequest struct {
-	To      string `json:"email_to" validate:"required,email"`
-	Subject string `json:"subject,omitempty"`
-	Content string `json:"content" validate:"required"`
-	SendNow bool   `json:"send_now,omitempty"` // true = send immediately, false = queue
+	To       string `json:"email_to" validate:"required,email"`
+	Subject  string `json:"subject,omitempty"`
+	Content  string `json:"content" validate:"required"`
+	SendNow  bool   `json:"send_now,omitempty"`  // true = send immediately, false = queue
+	TenantID *uint  `json:"tenant_id,omitempty"` // organization the email is sent for
 }
 
 type SendSMSRequest struct {
@@ -76,6 +77,7 @@ func (nc *NotificationController) SendEmail(w http.ResponseWriter, r *http.Reque
 
 	// Create notification record
 	notification := models.Notification{
+		TenantID:   req.TenantID,
 		Type:       models.TypeEmail,
 		To:         req.To,
 		Subject:    req.Subject,
@@ -125,7 +127,8 @@ func (nc *NotificationController) SendEmail(w http.ResponseWriter, r *http.Reque
 	// })
 }
 
-// GetNotificationStatus retrieves notification status
+// GetNotificationStatus retrieves the status of a notification sent for the
+// organization given by the required tenant_id
 func (nc *NotificationController) GetNotificationStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
@@ -144,8 +147,21 @@ func (nc *NotificationController) GetNotificationStatus(w http.ResponseWriter, r
 		return
 	}
 
+	// Every lookup is scoped to one organization, without it a caller could read
+	// the notifications of all of them
+	tenantStr := r.URL.Query().Get("tenant_id")
+	if tenantStr == "" {
+		respondWithJSON(w, http.StatusBadRequest, "Tenant ID is required", nil)
+		return
+	}
+	tenantID, err := strconv.ParseUint(tenantStr, 10, 32)
+	if err != nil || tenantID == 0 {
+		respondWithJSON(w, http.StatusBadRequest, "Invalid tenant ID", nil)
+		return
+	}
+
 	var notification models.Notification
-	if err := database.DB.First(&notification, uint(id)).Error; err != nil {
+	if err := database.DB.Where("tenant_id = ?", uint(tenantID)).First(&notification, uint(id)).Error; err != nil {
 		respondWithJSON(w, 404, "Notification not found", nil)
 		return
 	}
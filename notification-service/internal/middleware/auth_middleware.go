@@ -7,78 +7,45 @@ import (
 	"github.com/karan-bishtt/notification-service/internal/utils"
 )
 
-type contextKey string
-
-const (
-	UserIDKey   contextKey = "user_id"
-	UserRoleKey contextKey = "user_role"
-)
-
-// AuthMiddleware validates JWT tokens and sets user context
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-
-		// Extract token from header
-		tokenString, err := utils.ExtractTokenFromHeader(authHeader)
-		if err != nil {
-			http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid authorization header"}`, http.StatusUnauthorized)
-			return
-		}
-
-		// Validate token
-		userID, role, err := utils.GetUserFromToken(tokenString)
-		if err != nil {
-			http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid token"}`, http.StatusUnauthorized)
-			return
-		}
+// Every route of this service is called by other services with a client
+// credentials token, users never call it directly. That is why there is no
+// AuthMiddleware or RequirePermission here: access is granted per service
+// through token scopes instead of per user through permissions.
 
-		// Add user info to context
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		ctx = context.WithValue(ctx, UserRoleKey, role)
+type contextKey string
 
-		// Call next handler with updated context
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
-}
+const ClientIDKey contextKey = "client_id"
 
-// RequireRole middleware checks if user has specific role
-func RequireRole(allowedRoles ...string) func(http.Handler) http.Handler {
+// ServiceAuthMiddleware only lets internal services through that present a client
+// credentials token granted the given scope. User access tokens are rejected.
+func ServiceAuthMiddleware(scope string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			userRole, ok := r.Context().Value(UserRoleKey).(string)
-			if !ok {
-				http.Error(w, `{"status": 401, "message": "Unauthorized"}`, http.StatusUnauthorized)
+			tokenString, err := utils.ExtractTokenFromHeader(r.Header.Get("Authorization"))
+			if err != nil {
+				http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid authorization header"}`, http.StatusUnauthorized)
 				return
 			}
 
-			// Check if user role is in allowed roles
-			roleAllowed := false
-			for _, role := range allowedRoles {
-				if userRole == role {
-					roleAllowed = true
-					break
-				}
+			claims, err := utils.ValidateServiceToken(tokenString)
+			if err != nil {
+				http.Error(w, `{"status": 401, "message": "Unauthorized: Invalid service token"}`, http.StatusUnauthorized)
+				return
 			}
 
-			if !roleAllowed {
-				http.Error(w, `{"status": 403, "message": "Forbidden: Insufficient role"}`, http.StatusForbidden)
+			if !claims.HasScope(scope) {
+				http.Error(w, `{"status": 403, "message": "Forbidden: Insufficient scope"}`, http.StatusForbidden)
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			ctx := context.WithValue(r.Context(), ClientIDKey, claims.Subject)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-// GetUserIDFromContext extracts user ID from request context
-func GetUserIDFromContext(r *http.Request) (uint, bool) {
-	userID, ok := r.Context().Value(UserIDKey).(uint)
-	return userID, ok
-}
-
-// GetUserRoleFromContext extracts user role from request context
-func GetUserRoleFromContext(r *http.Request) (string, bool) {
-	role, ok := r.Context().Value(UserRoleKey).(string)
-	return role, ok
+// GetClientIDFromContext returns the service client a request was authenticated as
+func GetClientIDFromContext(r *http.Request) (string, bool) {
+	clientID, ok := r.Context().Value(ClientIDKey).(string)
+	return clientID, ok
 }
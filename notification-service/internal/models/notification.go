@@ -19,6 +19,7 @@ const (
 
 type Notification struct {
 	ID          uint               `json:"id" gorm:"primaryKey"`
+	TenantID    *uint              `json:"tenant_id,omitempty" gorm:"index"` // organization the notification was sent for, if any
 	Type        NotificationType   `json:"type" gorm:"not null;type:varchar(20);default:'email';check:type IN ('email')"`
 	To          string             `json:"to" gorm:"not null;size:255"` // email or phone
 	Subject     string             `json:"subject" gorm:"size:255"`     // for emails
@@ -1,8 +1,12 @@
 package routes
 
 import (
+	"net/http"
+
 	"github.com/gorilla/mux"
 	"github.com/karan-bishtt/notification-service/internal/controllers"
+	"github.com/karan-bishtt/notification-service/internal/middleware"
+	"github.com/karan-bishtt/notification-service/internal/utils"
 )
 
 func SetupNotificationRoutes() *mux.Router {
@@ -12,11 +16,11 @@ func SetupNotificationRoutes() *mux.Router {
 
 	// API routes
 	api := router.PathPrefix("/api/v1").Subrouter()
-	// api.Use(middleware.AuthMiddleware)
 
-	// Notification routes
-	api.HandleFunc("/send-email", notificationController.SendEmail).Methods("POST")
-	api.HandleFunc("/status", notificationController.GetNotificationStatus).Methods("GET")
+	// Notification routes. Sending is for internal services only, never for end users.
+	api.Handle("/send-email", middleware.ServiceAuthMiddleware(utils.ScopeNotificationsSend)(http.HandlerFunc(notificationController.SendEmail))).Methods("POST")
+	// Notifications hold the email content (reset links, OTPs), so the status is internal too
+	api.Handle("/status", middleware.ServiceAuthMiddleware(utils.ScopeNotificationsSend)(http.HandlerFunc(notificationController.GetNotificationStatus))).Methods("GET")
 
 	return router
 }
@@ -2,40 +2,65 @@ package utils
 
 import (
 	"errors"
-	"fmt"
+	"strings"
 
 	"github.com/karan-bishtt/notification-service/config"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// TokenTypeService marks client credentials tokens of internal services, the
+// only bearer tokens this service accepts
+const TokenTypeService = "service"
+
+// ScopeNotificationsSend lets an internal service send email
+const ScopeNotificationsSend = "notifications:send"
+
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	TokenType string `json:"token_type"`
+	Scope     string `json:"scope,omitempty"` // space separated
 	jwt.RegisteredClaims
 }
 
-// ValidateToken validates a JWT token and returns claims
-func ValidateToken(tokenString string) (*Claims, error) {
+// HasScope reports whether a service token was granted scope
+func (c *Claims) HasScope(scope string) bool {
+	for _, granted := range strings.Fields(c.Scope) {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateServiceToken validates a client credentials token of an internal service.
+// User tokens and tokens minted for another issuer or audience are rejected.
+func ValidateServiceToken(tokenString string) (*Claims, error) {
 	cfg := config.Load()
 
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("invalid signing method: %v", token.Header["alg"])
-		}
-		return []byte(cfg.JWTSecret), nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		return authServiceKeys.publicKey(kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(cfg.JWTIssuer),
+		jwt.WithAudience(cfg.JWTAudience),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
 
-	return nil, errors.New("invalid token")
+	if claims.TokenType != TokenTypeService || claims.ID == "" {
+		return nil, errors.New("invalid token type")
+	}
+
+	return claims, nil
 }
 
 // ExtractTokenFromHeader extracts token from Authorization header
@@ -51,13 +76,3 @@ func ExtractTokenFromHeader(authHeader string) (string, error) {
 
 	return authHeader[len(bearerPrefix):], nil
 }
-
-// GetUserFromToken extracts user information from token
-func GetUserFromToken(tokenString string) (userID uint, role string, err error) {
-	claims, err := ValidateToken(tokenString)
-	if err != nil {
-		return 0, "", err
-	}
-
-	return claims.UserID, claims.Role, nil
-}
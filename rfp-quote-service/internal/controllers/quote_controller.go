@@ -45,6 +45,33 @@ func (qc *QuoteController) SubmitQuote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
+	// Only vendors approved by the organization may quote, suspended and blacklisted
+	// ones are turned away even while their access token is still valid
+	var vendor models.VendorOrganization
+	if err := database.DB.Select("id", "status").
+		Where("user_id = ? AND organization_id = ?", userID, tenantID).
+		First(&vendor).Error; err != nil {
+		respondWithJSON(w, 403, "You do not belong to this organization", nil)
+		return
+	}
+	switch vendor.Status {
+	case models.VendorStatusApproved:
+	case models.VendorStatusSuspended:
+		respondWithJSON(w, 403, "Your vendor account is suspended", nil)
+		return
+	case models.VendorStatusBlacklisted:
+		respondWithJSON(w, 403, "Your vendor account is blacklisted", nil)
+		return
+	default:
+		respondWithJSON(w, 403, "Your vendor account is not approved", nil)
+		return
+	}
+
 	var req SubmitQuoteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithJSON(w, 400, "Invalid request format", nil)
@@ -59,7 +86,7 @@ func (qc *QuoteController) SubmitQuote(w http.ResponseWriter, r *http.Request) {
 
 	// Find RFP and validate it's still open
 	var rfp models.RFP
-	if err := database.DB.First(&rfp, req.RFPID).Error; err != nil {
+	if err := database.DB.Where("tenant_id = ?", tenantID).First(&rfp, req.RFPID).Error; err != nil {
 		respondWithJSON(w, 404, "RFP not found", nil)
 		return
 	}
@@ -106,7 +133,7 @@ func (qc *QuoteController) SubmitQuote(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, 200, "Quote submitted successfully", quote)
 }
 
-// GetAvailableRFPs gets all RFPs that a vendor can submit quotes for
+// GetAvailableRFPs gets all RFPs of the organization that a vendor can submit quotes for
 func (rc *QuoteController) GetAvailableRFPs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
@@ -126,6 +153,11 @@ func (rc *QuoteController) GetAvailableRFPs(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	var rfps []models.RFP
 
 	// Get RFPs where:
@@ -134,7 +166,7 @@ func (rc *QuoteController) GetAvailableRFPs(w http.ResponseWriter, r *http.Reque
 	// 3. Vendor hasn't already submitted a quote
 	err := database.DB.
 		Joins("INNER JOIN rfp_vendors ON rfps.id = rfp_vendors.rfp_id").
-		Where("rfp_vendors.vendor_id = ?", userID).
+		Where("rfp_vendors.vendor_id = ? AND rfps.tenant_id = ?", userID, tenantID).
 		Where("rfps.status = ? AND rfps.last_date > ? AND rfps.is_active = ?",
 			models.RFPStatusOpen, time.Now(), true).
 		Where("rfps.id NOT IN (?)",
@@ -151,7 +183,7 @@ func (rc *QuoteController) GetAvailableRFPs(w http.ResponseWriter, r *http.Reque
 	respondWithJSON(w, 200, "Available RFPs", rfps)
 }
 
-// GetVendorRFPs gets all RFPs associated with a vendor (both available and quoted)
+// GetVendorRFPs gets all RFPs of the organization associated with a vendor (both available and quoted)
 func (rc *QuoteController) GetVendorRFPs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
@@ -171,6 +203,11 @@ func (rc *QuoteController) GetVendorRFPs(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	// Get query parameter to filter status
 	status := r.URL.Query().Get("status") // available, quoted, all
 
@@ -182,7 +219,7 @@ func (rc *QuoteController) GetVendorRFPs(w http.ResponseWriter, r *http.Request)
 		// Only RFPs available for quoting
 		err = database.DB.
 			Joins("INNER JOIN rfp_vendors ON rfps.id = rfp_vendors.rfp_id").
-			Where("rfp_vendors.vendor_id = ?", userID).
+			Where("rfp_vendors.vendor_id = ? AND rfps.tenant_id = ?", userID, tenantID).
 			Where("rfps.status = ? AND rfps.last_date > ? AND rfps.is_active = ?",
 				models.RFPStatusOpen, time.Now(), true).
 			Where("rfps.id NOT IN (?)",
@@ -195,7 +232,7 @@ func (rc *QuoteController) GetVendorRFPs(w http.ResponseWriter, r *http.Request)
 		// Only RFPs where vendor has submitted quotes
 		err = database.DB.
 			Joins("INNER JOIN rfp_quotes ON rfps.id = rfp_quotes.rfp_id").
-			Where("rfp_quotes.vendor_id = ?", userID).
+			Where("rfp_quotes.vendor_id = ? AND rfps.tenant_id = ?", userID, tenantID).
 			Preload("Quotes", "vendor_id = ?", userID).
 			Find(&rfps).Error
 
@@ -203,7 +240,7 @@ func (rc *QuoteController) GetVendorRFPs(w http.ResponseWriter, r *http.Request)
 		// All RFPs associated with vendor
 		err = database.DB.
 			Joins("INNER JOIN rfp_vendors ON rfps.id = rfp_vendors.rfp_id").
-			Where("rfp_vendors.vendor_id = ?", userID).
+			Where("rfp_vendors.vendor_id = ? AND rfps.tenant_id = ?", userID, tenantID).
 			Preload("Quotes", "vendor_id = ?", userID).
 			Find(&rfps).Error
 	}
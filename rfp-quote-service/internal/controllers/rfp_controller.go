@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -57,7 +58,7 @@ type CreateRFPRequest struct {
 	MinAmount   float64  `json:"min_amount" validate:"min=0"`
 	MaxAmount   float64  `json:"max_amount" validate:"min=0"`
 	CategoryID  uint     `json:"category" validate:"required"`
-	VendorIDs   []uint   `json:"vendor,omitempty"` // Specific vendors to notify
+	VendorIDs   []uint   `json:"vendor,omitempty"` // Specific vendors to invite, every vendor of the category when empty
 }
 
 type DeleteRFPRequest struct {
@@ -83,7 +84,17 @@ func respondWithJSON(w http.ResponseWriter, status int, message string, data int
 	json.NewEncoder(w).Encode(response)
 }
 
-// CreateRFP creates a new RFP request
+// tenantFromToken returns the organization the logged in user acts for
+func tenantFromToken(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	tenantID, ok := middleware.GetTenantIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, http.StatusUnauthorized, "You are not login", nil)
+		return 0, false
+	}
+	return tenantID, true
+}
+
+// CreateRFP creates a new RFP request in the admin's organization
 func (rc *RFPController) CreateRFP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
@@ -103,6 +114,11 @@ func (rc *RFPController) CreateRFP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	var req CreateRFPRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("Error counting vendors: %v", err)
@@ -129,13 +145,50 @@ func (rc *RFPController) CreateRFP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.VendorIDs) <= 0 {
-		respondWithJSON(w, 400, "Select at least one vendor", nil)
+	// Only approved vendors serving the category can be invited
+	invited, err := rc.authService.GetCategoryVendors(req.CategoryID, tenantID)
+	if errors.Is(err, services.ErrCategoryNotFound) {
+		respondWithJSON(w, 400, "Category not found", nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch vendors of category %d: %v", req.CategoryID, err)
+		respondWithJSON(w, 502, "Failed to fetch vendors of the category", nil)
+		return
+	}
+	if len(req.VendorIDs) > 0 {
+		categoryVendors := make(map[uint]services.VendorData, len(invited))
+		for _, vendor := range invited {
+			categoryVendors[vendor.ID] = vendor
+		}
+
+		selected := make([]services.VendorData, 0, len(req.VendorIDs))
+		var invalid []uint
+		for _, vendorID := range req.VendorIDs {
+			vendor, ok := categoryVendors[vendorID]
+			if !ok {
+				invalid = append(invalid, vendorID)
+				continue
+			}
+			selected = append(selected, vendor)
+			delete(categoryVendors, vendorID) // skips duplicates
+		}
+		if len(invalid) > 0 {
+			respondWithJSON(w, 400, "Some vendors are not approved for this category", map[string]interface{}{
+				"invalid_vendor_ids": invalid,
+			})
+			return
+		}
+		invited = selected
+	}
+	if len(invited) == 0 {
+		respondWithJSON(w, 400, "No approved vendors serve this category", nil)
 		return
 	}
 
 	// Create RFP
 	rfp := models.RFP{
+		TenantID:    tenantID,
 		Title:       req.Title,
 		Description: req.Description,
 		Quantity:    req.Quantity,
@@ -156,31 +209,20 @@ func (rc *RFPController) CreateRFP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Add specific vendors
-	for _, vendorID := range req.VendorIDs {
+	// Add the invited vendors
+	for _, vendor := range invited {
 		rfpVendor := models.RFPVendor{
 			RFPID:    rfp.ID,
-			VendorID: vendorID,
+			VendorID: vendor.ID,
 		}
 		tx.Create(&rfpVendor)
 	}
 	tx.Commit()
 	// Send notifications to vendors
 	go func() {
-		var vendorEmails []string
-
-		if len(req.VendorIDs) > 0 {
-			// Send to specific vendors
-			vendorEmails = rc.authService.GetVendorEmailsByIDs(req.VendorIDs)
-		} else {
-			// Send to all vendors in the category
-			vendorEmails = rc.authService.GetVendorEmailsByCategory(req.CategoryID)
-		}
-
 		// Send notification emails
 		log.Println("email generated start")
-		for _, email := range vendorEmails {
-			log.Println("sending email to", email)
+		for _, vendor := range invited {
 			subject := "New RFP Request: " + rfp.Title
 			content := fmt.Sprintf(`
 				A new RFP request has been created.
@@ -194,14 +236,18 @@ func (rc *RFPController) CreateRFP(w http.ResponseWriter, r *http.Request) {
 				Please login to view details and submit your quote.
 			`, rfp.Title, rfp.Description, rfp.Quantity, rfp.MinAmount, rfp.MaxAmount, rfp.LastDate.Format("2006-01-02"))
 
-			rc.notificationService.SendEmail(email, subject, content)
+			// The vendor and every contact subscribed to the category
+			for _, email := range vendor.Recipients() {
+				log.Println("sending email to", email)
+				rc.notificationService.SendTenantEmail(tenantID, email, subject, content)
+			}
 		}
 	}()
 
 	respondWithJSON(w, 200, "New RFP Request is created", rfp)
 }
 
-// GetRFPs lists all RFPs (admin only)
+// GetRFPs lists the RFPs the admin created in their organization (admin only)
 func (rc *RFPController) GetRFPs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
@@ -221,11 +267,16 @@ func (rc *RFPController) GetRFPs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	// Query parameters for filtering
 	status := r.URL.Query().Get("status")
 	categoryID := r.URL.Query().Get("category_id")
 
-	query := database.DB.Where("user_id = ?", userID).Preload("Quotes")
+	query := database.DB.Where("tenant_id = ? AND user_id = ?", tenantID, userID).Preload("Quotes")
 
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -266,6 +317,11 @@ func (rc *RFPController) DeleteRFP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	rfpIDStr := vars["id"]
 
@@ -277,7 +333,7 @@ func (rc *RFPController) DeleteRFP(w http.ResponseWriter, r *http.Request) {
 
 	// Find RFP (ensure it belongs to the admin)
 	var rfp models.RFP
-	if err := database.DB.Where("id = ? AND user_id = ?", rfpID, userID).First(&rfp).Error; err != nil {
+	if err := database.DB.Where("id = ? AND tenant_id = ? AND user_id = ?", rfpID, tenantID, userID).First(&rfp).Error; err != nil {
 		respondWithJSON(w, 402, "Rfp request Not found", nil)
 		return
 	}
@@ -293,7 +349,7 @@ func (rc *RFPController) DeleteRFP(w http.ResponseWriter, r *http.Request) {
 
 	// Return updated list
 	var remainingRFPs []models.RFP
-	database.DB.Where("user_id = ?", userID).Find(&remainingRFPs)
+	database.DB.Where("tenant_id = ? AND user_id = ?", tenantID, userID).Find(&remainingRFPs)
 
 	respondWithJSON(w, 200, "Rfp request successfully deleted", remainingRFPs)
 }
@@ -317,6 +373,11 @@ func (rc *RFPController) UpdateRFPStatus(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	// Get status from the request body (could also be from query parameters)
 	var request struct {
 		Status string `json:"status" validate:"required,oneof=open closed"`
@@ -353,7 +414,7 @@ func (rc *RFPController) UpdateRFPStatus(w http.ResponseWriter, r *http.Request)
 
 	// Find the RFP
 	var rfp models.RFP
-	if err := database.DB.Where("id = ? AND user_id = ?", rfpID, userID).First(&rfp).Error; err != nil {
+	if err := database.DB.Where("id = ? AND tenant_id = ? AND user_id = ?", rfpID, tenantID, userID).First(&rfp).Error; err != nil {
 		respondWithJSON(w, 402, "RFP request not found", nil)
 		return
 	}
@@ -375,7 +436,7 @@ func (rc *RFPController) UpdateRFPStatus(w http.ResponseWriter, r *http.Request)
 
 	// Return the updated list of RFPs
 	var remainingRFPs []models.RFP
-	database.DB.Where("user_id = ?", userID).Find(&remainingRFPs)
+	database.DB.Where("tenant_id = ? AND user_id = ?", tenantID, userID).Find(&remainingRFPs)
 
 	respondWithJSON(w, 200, "RFP status updated successfully", remainingRFPs)
 }
@@ -401,6 +462,11 @@ func (rc *RFPController) GetRFPQuotes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	rfpIDStr := vars["id"]
 
@@ -411,10 +477,11 @@ func (rc *RFPController) GetRFPQuotes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Fetch all quotes for this RFP
+	// Fetch all quotes for this RFP of the admin's organization
 	var quotes []models.RFPQuote
 	if err := database.DB.
 		Where("rfp_id = ?", rfpID).
+		Where("rfp_id IN (?)", database.DB.Model(&models.RFP{}).Select("id").Where("tenant_id = ?", tenantID)).
 		Preload("RFP").
 		Preload("Vendor"). // Add this to load vendor info
 		Find(&quotes).Error; err != nil {
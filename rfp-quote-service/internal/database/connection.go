@@ -13,6 +13,9 @@ import (
 
 var DB *gorm.DB
 
+// defaultOrganizationSlug is the organization auth-service moves existing data into
+const defaultOrganizationSlug = "default"
+
 func InitDB(databaseURL string) (*gorm.DB, error) {
 	var err error
 	fmt.Println("InitDB file initialize")
@@ -63,9 +66,48 @@ func InitDB(databaseURL string) (*gorm.DB, error) {
 	}
 	fmt.Println("✅ Auto migration completed!")
 
+	if err := backfillRFPTenants(); err != nil {
+		return nil, fmt.Errorf("failed to backfill RFP organizations: %w", err)
+	}
+
 	return DB, nil
 }
 
+// backfillRFPTenants moves the RFPs that predate organizations into the organization
+// of the admin who created them, or the default organization created by auth-service.
+// When auth-service has not created organizations yet they are moved on the next start.
+func backfillRFPTenants() error {
+	if !DB.Migrator().HasTable("organizations") || !DB.Migrator().HasColumn("users", "organization_id") {
+		fmt.Println("⚠️  Organizations do not exist yet, RFPs are moved on the next start")
+		return nil
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`
+			UPDATE rfps SET tenant_id = users.organization_id
+			FROM users
+			WHERE users.id = rfps.user_id AND users.organization_id IS NOT NULL AND rfps.tenant_id = 0`)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved := result.RowsAffected
+
+		result = tx.Exec(`
+			UPDATE rfps SET tenant_id = organizations.id
+			FROM organizations
+			WHERE organizations.slug = ? AND rfps.tenant_id = 0`, defaultOrganizationSlug)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved += result.RowsAffected
+
+		if moved > 0 {
+			fmt.Printf("✅ Moved %d RFPs into their organization\n", moved)
+		}
+		return nil
+	})
+}
+
 // Helper function to mask password in database URL for logging
 func maskPassword(databaseURL string) string {
 	// Simple masking - you might want to use regex for better parsing
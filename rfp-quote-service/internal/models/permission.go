@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Permission and UserPermission are owned and migrated by auth-service.
+// They live in the shared database and are only read here to resolve permissions.
+
+type Permission struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name"`
+	Resource  string    `json:"resource"`
+	Action    string    `json:"action"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserPermission struct {
+	ID           uint        `json:"id" gorm:"primaryKey"`
+	UserID       uint        `json:"user_id"`
+	PermissionID uint        `json:"permission_id"`
+	Permission   *Permission `json:"permission" gorm:"foreignKey:PermissionID"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+func (UserPermission) TableName() string {
+	return "user_permissions"
+}
+
+func (u *User) HasPermission(resource, action string) bool {
+	for _, up := range u.UserPermissions {
+		if up.Permission != nil &&
+			up.Permission.Resource == resource &&
+			up.Permission.Action == action {
+			return true
+		}
+	}
+	return false
+}
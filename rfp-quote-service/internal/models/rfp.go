@@ -12,6 +12,12 @@ type User struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Email     string `json:"email"`
+	IsActive  bool   `json:"-"`
+
+	// Bumped by auth-service whenever the permissions or the active flag change
+	PermissionsVersion uint `json:"-"`
+
+	UserPermissions []UserPermission `json:"-" gorm:"foreignKey:UserID"`
 }
 
 func (User) TableName() string {
@@ -26,6 +32,7 @@ const (
 
 type RFP struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
+	TenantID    uint      `json:"tenant_id" gorm:"not null;default:0;index"` // Buyer organization owning the RFP
 	Title       string    `json:"title" gorm:"not null;size:255"`
 	Description string    `json:"description" gorm:"type:text"`
 	Quantity    int       `json:"quantity" gorm:"default:1"`
@@ -0,0 +1,21 @@
+package models
+
+// VendorOrganization is owned by auth-service, this service only reads the
+// approval status a buyer organization gave the vendor to keep vendors that are
+// not approved, suspended or blacklisted there from quoting on its RFPs
+type VendorOrganization struct {
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	UserID         uint   `json:"user_id"`
+	OrganizationID uint   `json:"organization_id"`
+	Status         string `json:"status"`
+}
+
+func (VendorOrganization) TableName() string {
+	return "vendor_organizations"
+}
+
+const (
+	VendorStatusApproved    = "approved"
+	VendorStatusSuspended   = "suspended"
+	VendorStatusBlacklisted = "blacklisted"
+)
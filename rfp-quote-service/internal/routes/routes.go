@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"net/http"
+
 	"github.com/karan-bishtt/rfp-quote-service/internal/controllers"
 	"github.com/karan-bishtt/rfp-quote-service/internal/middleware"
 
@@ -24,19 +26,24 @@ func SetupRoutes() *mux.Router {
 	adminRoutes := api.PathPrefix("/rfp").Subrouter()
 	adminRoutes.Use(middleware.RequireRole("admin"))
 
-	adminRoutes.HandleFunc("", rfpController.GetRFPs).Methods("GET")
-	adminRoutes.HandleFunc("", rfpController.CreateRFP).Methods("POST")
-	adminRoutes.HandleFunc("/{id:[0-9]+}", rfpController.DeleteRFP).Methods("DELETE")
-	adminRoutes.HandleFunc("/{id:[0-9]+}", rfpController.UpdateRFPStatus).Methods("PUT")
-	adminRoutes.HandleFunc("/quotes/{id:[0-9]+}", rfpController.GetRFPQuotes).Methods("GET")
+	adminRoutes.Handle("", withPermission("rfp", "read", rfpController.GetRFPs)).Methods("GET")
+	adminRoutes.Handle("", withPermission("rfp", "create", rfpController.CreateRFP)).Methods("POST")
+	adminRoutes.Handle("/{id:[0-9]+}", withPermission("rfp", "delete", rfpController.DeleteRFP)).Methods("DELETE")
+	adminRoutes.Handle("/{id:[0-9]+}", withPermission("rfp", "update", rfpController.UpdateRFPStatus)).Methods("PUT")
+	adminRoutes.Handle("/quotes/{id:[0-9]+}", withPermission("quote", "read", rfpController.GetRFPQuotes)).Methods("GET")
 
 	// Quote routes (Vendor only)
 	vendorRoutes := api.PathPrefix("/quote").Subrouter()
 	vendorRoutes.Use(middleware.RequireRole("vendor"))
 
-	vendorRoutes.HandleFunc("", quoteController.SubmitQuote).Methods("POST")
-	vendorRoutes.HandleFunc("/my-quotes", quoteController.GetVendorRFPs).Methods("GET")
-	vendorRoutes.HandleFunc("/available-rfps", quoteController.GetAvailableRFPs).Methods("GET")
+	vendorRoutes.Handle("", withPermission("quote", "create", quoteController.SubmitQuote)).Methods("POST")
+	vendorRoutes.Handle("/my-quotes", withPermission("quote", "read", quoteController.GetVendorRFPs)).Methods("GET")
+	vendorRoutes.Handle("/available-rfps", withPermission("rfp", "read", quoteController.GetAvailableRFPs)).Methods("GET")
 
 	return router
 }
+
+// withPermission gates a single handler behind a resource/action permission
+func withPermission(resource, action string, handler http.HandlerFunc) http.Handler {
+	return middleware.RequirePermission(resource, action)(handler)
+}
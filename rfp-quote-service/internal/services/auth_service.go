@@ -2,30 +2,52 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"strings"
 
 	"github.com/karan-bishtt/rfp-quote-service/config"
 )
 
+// ErrCategoryNotFound is returned when the category does not belong to the organization
+var ErrCategoryNotFound = errors.New("category not found")
+
 type AuthService struct {
 	baseURL string
 	client  *http.Client
 }
 
 type AuthResponse struct {
-	Status  int         `json: "status"`
-	Message string      `json: "message"`
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
 }
 
+// VendorData is a vendor of a category. ContactEmails are the vendor's contacts
+// subscribed to the category.
 type VendorData struct {
-	ID            uint   `json:"id"`
-	Email         string `json:"email"`
-	VendorDetails struct {
-		CategoryID *uint `json:"category_id"`
-	} `json: "vendor_details"`
+	ID            uint     `json:"id"`
+	FirstName     string   `json:"first_name"`
+	LastName      string   `json:"last_name"`
+	Email         string   `json:"email"`
+	ContactEmails []string `json:"contact_emails"`
+}
+
+// Recipients returns the login email of the vendor followed by the emails of its
+// subscribed contacts, each address once
+func (v VendorData) Recipients() []string {
+	seen := make(map[string]bool, len(v.ContactEmails)+1)
+	recipients := make([]string, 0, len(v.ContactEmails)+1)
+	for _, email := range append([]string{v.Email}, v.ContactEmails...) {
+		key := strings.ToLower(strings.TrimSpace(email))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		recipients = append(recipients, email)
+	}
+	return recipients
 }
 
 func NewAuthService() *AuthService {
@@ -37,66 +59,38 @@ func NewAuthService() *AuthService {
 	}
 }
 
-// GetVendorEmailsByIDs fetches vendor emails by their IDs
-func (as *AuthService) GetVendorEmailsByIDs(vendorIDs []uint) []string {
-	var emails []string
-	log.Println("started fetching vendor ids")
-	for _, id := range vendorIDs {
-		log.Println("vendor id", id)
-		url := fmt.Sprintf("%s/api/v1/auth/users/%d", as.baseURL, id)
-		resp, err := as.client.Get(url)
-		if err != nil {
-			continue
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode == http.StatusOK {
-			var response struct {
-				Status int `json:"status"`
-				Data   struct {
-					Email string `json:"email"`
-					Role  string `json:"role"`
-				} `json:"data"`
-			}
-
-			if json.NewDecoder(resp.Body).Decode(&response) == nil && response.Data.Role == "vendor" {
-				emails = append(emails, response.Data.Email)
-			}
-		}
+// GetCategoryVendors fetches the approved vendors approved for a category of the
+// organization tenantID. A category of another organization is not found.
+func (as *AuthService) GetCategoryVendors(categoryID, tenantID uint) ([]VendorData, error) {
+	url := fmt.Sprintf("%s/api/v1/internal/categories/%d/vendors?tenant_id=%d", as.baseURL, categoryID, tenantID)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := serviceTokens.authorize(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate with auth-service: %w", err)
 	}
 
-	return emails
-}
-
-// GetVendorEmailsByCategory fetches all vendor emails in a specific category
-func (as *AuthService) GetVendorEmailsByCategory(categoryID uint) []string {
-	url := fmt.Sprintf("%s/api/v1/vendors?category_id=%d", as.baseURL, categoryID)
-
-	resp, err := as.client.Get(url)
+	resp, err := as.client.Do(req)
 	if err != nil {
-		return []string{}
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrCategoryNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
-		return []string{}
+		return nil, fmt.Errorf("auth-service returned status %d", resp.StatusCode)
 	}
 
 	var response struct {
 		Status int          `json:"status"`
 		Data   []VendorData `json:"data"`
 	}
-
-	if json.NewDecoder(resp.Body).Decode(&response) != nil {
-		return []string{}
-	}
-
-	var emails []string
-	for _, vendor := range response.Data {
-		if vendor.VendorDetails.CategoryID != nil && *vendor.VendorDetails.CategoryID == categoryID {
-			emails = append(emails, vendor.Email)
-		}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
 	}
 
-	return emails
+	return response.Data, nil
 }
@@ -18,6 +18,8 @@ type EmailRequest struct {
 	Subject string `json:"subject"`
 	Content string `json:"content"`
 	SendNow bool   `json:"send_now"`
+	// TenantID is the organization the email is sent on behalf of, if any
+	TenantID *uint `json:"tenant_id,omitempty"`
 }
 
 func NewNotificationService() *NotificationService {
@@ -30,20 +32,42 @@ func NewNotificationService() *NotificationService {
 
 // SendEmail sends an email via notification service
 func (ns *NotificationService) SendEmail(to, subject, content string) error {
-	emailReq := EmailRequest{
+	return ns.send(EmailRequest{
 		EmailTo: to,
 		Subject: subject,
 		Content: content,
 		SendNow: true,
-	}
+	})
+}
 
+// SendTenantEmail sends an email on behalf of an organization via notification service
+func (ns *NotificationService) SendTenantEmail(tenantID uint, to, subject, content string) error {
+	return ns.send(EmailRequest{
+		EmailTo:  to,
+		Subject:  subject,
+		Content:  content,
+		SendNow:  true,
+		TenantID: &tenantID,
+	})
+}
+
+func (ns *NotificationService) send(emailReq EmailRequest) error {
 	jsonData, err := json.Marshal(emailReq)
 	if err != nil {
 		return err
 	}
 
 	url := ns.baseURL + "/api/v1/send-email"
-	resp, err := ns.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := serviceTokens.authorize(req); err != nil {
+		return err
+	}
+
+	resp, err := ns.client.Do(req)
 	if err != nil {
 		return err
 	}
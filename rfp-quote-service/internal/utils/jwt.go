@@ -2,40 +2,53 @@ package utils
 
 import (
 	"errors"
-	"fmt"
 
 	"github.com/karan-bishtt/rfp-quote-service/config"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// TokenTypeAccess is the only token type this service accepts as a bearer token
+const TokenTypeAccess = "access"
+
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	UserID    uint   `json:"user_id"`
+	Role      string `json:"role"`
+	TokenType string `json:"token_type"`
+	SessionID string `json:"sid,omitempty"`
+	TenantID  uint   `json:"tenant_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// ValidateToken validates a JWT token and returns claims
+// ValidateToken validates a JWT access token and returns claims.
+// Refresh tokens and tokens minted for another issuer or audience are rejected.
 func ValidateToken(tokenString string) (*Claims, error) {
 	cfg := config.Load()
 
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("invalid signing method: %v", token.Header["alg"])
-		}
-		return []byte(cfg.JWTSecret), nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		return authServiceKeys.publicKey(kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(cfg.JWTIssuer),
+		jwt.WithAudience(cfg.JWTAudience),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.TokenType != TokenTypeAccess || claims.ID == "" {
+		return nil, errors.New("invalid token type")
 	}
 
-	return nil, errors.New("invalid token")
+	return claims, nil
 }
 
 // ExtractTokenFromHeader extracts token from Authorization header
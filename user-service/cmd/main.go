@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/gorilla/handlers"
 	"github.com/karan-bishtt/user-service/config"
 	"github.com/karan-bishtt/user-service/internal/database"
 	"github.com/karan-bishtt/user-service/internal/routes"
@@ -13,7 +14,7 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
-	// Initialize database
+	// Initialize database, only read to check sessions and permissions of tokens
 	_, err := database.InitDB(cfg.DatabaseURL)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
@@ -22,7 +23,17 @@ func main() {
 
 	// Setup routes
 	router := routes.SetupUsersRoutes()
+	handler := handlers.CORS(
+		handlers.AllowedOrigins([]string{"*"}), // OK because we are NOT using credentials
+		handlers.AllowCredentials(),
+		handlers.AllowedMethods([]string{
+			http.MethodGet, http.MethodOptions,
+		}),
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		handlers.OptionStatusCode(204),
+	)(router)
 
-	log.Printf("Notification service starting on port %s", cfg.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.Port, router))
+	log.Printf("User service starting on port %s", cfg.Port)
+	log.Printf("Environment: %s", cfg.Environment)
+	log.Fatal(http.ListenAndServe(":"+cfg.Port, handler))
 }
@@ -2,13 +2,12 @@ package controllers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
-	"time"
 
-	"github.com/karan-bishtt/user-service/internal/database"
-	"github.com/karan-bishtt/user-service/internal/models"
+	"github.com/karan-bishtt/user-service/internal/middleware"
 	"github.com/karan-bishtt/user-service/internal/services"
 
 	"github.com/gorilla/mux"
@@ -19,14 +18,10 @@ type UserController struct {
 }
 
 type UserResponse struct {
-	Status  int         `json:"status"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-type ApprovalRequest struct {
-	IsApproved bool   `json:"is_approved"`
-	Notes      string `json:"notes,omitempty"`
+	Status     int         `json:"status"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data,omitempty"`
+	Pagination interface{} `json:"pagination,omitempty"`
 }
 
 func NewUserController() *UserController {
@@ -47,94 +42,105 @@ func respondWithJSON(w http.ResponseWriter, status int, message string, data int
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetVendors retrieves all vendors with approval status
+func respondWithPagination(w http.ResponseWriter, status int, message string, data interface{}, pagination interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	response := UserResponse{
+		Status:     status,
+		Message:    message,
+		Data:       data,
+		Pagination: pagination,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// tenantFromToken returns the organization the logged in user acts for
+func tenantFromToken(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	tenantID, ok := middleware.GetTenantIDFromContext(r)
+	if !ok {
+		respondWithJSON(w, http.StatusUnauthorized, "You are not login", nil)
+		return 0, false
+	}
+	return tenantID, true
+}
+
+// GetVendors lists the vendors of the admin's organization with their approval
+// status there. Approval itself is managed in auth-service.
 func (uc *UserController) GetVendors(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
 
-	// Fetch vendors from auth-service
-	vendors, err := uc.authService.GetVendors()
-	if err != nil {
-		respondWithJSON(w, http.StatusInternalServerError, "Failed to fetch vendors", nil)
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
 		return
 	}
 
-	// Get approval status for each vendor
-	var vendorApprovals []models.VendorApproval
-	database.DB.Find(&vendorApprovals)
-
-	// Create approval map for quick lookup
-	approvalMap := make(map[uint]models.VendorApproval)
-	for _, approval := range vendorApprovals {
-		approvalMap[approval.UserID] = approval
+	filter := services.VendorFilter{
+		Status: r.URL.Query().Get("status"), // any vendor status, e.g. pending, approved, suspended
 	}
-
-	// Combine vendor data with approval status
-	type VendorWithApproval struct {
-		models.UserResponse
-		ApprovalStatus *models.VendorApproval `json:"approval_status,omitempty"`
+	if page, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && page > 0 {
+		filter.Page = page
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && limit <= 100 {
+		filter.Limit = limit
 	}
 
-	var result []VendorWithApproval
-	for _, vendor := range vendors {
-		vendorWithApproval := VendorWithApproval{
-			UserResponse: vendor,
-		}
-
-		if approval, exists := approvalMap[vendor.ID]; exists {
-			vendorWithApproval.ApprovalStatus = &approval
-		}
-
-		result = append(result, vendorWithApproval)
+	// Fetch vendors from auth-service
+	vendors, pagination, err := uc.authService.GetVendors(tenantID, filter)
+	if err != nil {
+		log.Printf("Failed to fetch vendors of organization %d: %v", tenantID, err)
+		respondWithJSON(w, http.StatusBadGateway, "Failed to fetch vendors", nil)
+		return
 	}
 
-	respondWithJSON(w, 200, "Vendors retrieved successfully", result)
+	respondWithPagination(w, 200, "Vendors retrieved successfully", vendors, pagination)
 }
 
-// GetVendor retrieves a specific vendor with approval status
-func (uc *UserController) GetVendor(w http.ResponseWriter, r *http.Request) {
+// SearchVendors searches the vendors of the admin's organization by name and email.
+// Takes the filters, sort and cursor of auth-service vendor search: q, category_id,
+// status, revenue_min, revenue_max, employees_min, employees_max, registered_from,
+// registered_to, sort (relevance, newest, oldest, name, revenue, employees), cursor and limit.
+func (uc *UserController) SearchVendors(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
 
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-
-	userID, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		respondWithJSON(w, http.StatusBadRequest, "Invalid user ID", nil)
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
 		return
 	}
 
-	// Fetch user from auth-service
-	user, err := uc.authService.GetUserByID(uint(userID))
+	result, err := uc.authService.SearchVendors(tenantID, r.URL.Query())
 	if err != nil {
-		respondWithJSON(w, 404, "User not found", nil)
+		var searchErr *services.SearchError
+		if errors.As(err, &searchErr) {
+			respondWithJSON(w, http.StatusBadRequest, searchErr.Message, nil)
+			return
+		}
+		log.Printf("Failed to search vendors of organization %d: %v", tenantID, err)
+		respondWithJSON(w, http.StatusBadGateway, "Failed to search vendors", nil)
 		return
 	}
 
-	// Get approval status
-	var approval models.VendorApproval
-	database.DB.Where("user_id = ?", userID).First(&approval)
-
-	result := map[string]interface{}{
-		"user":            user,
-		"approval_status": approval,
-	}
-
-	respondWithJSON(w, 200, "Vendor retrieved successfully", result)
+	respondWithJSON(w, 200, "Vendors retrieved successfully", result)
 }
 
-// ApproveVendor handles vendor approval/disapproval
-func (uc *UserController) ApproveVendor(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+// GetVendor retrieves a vendor of the admin's organization with their approval status there
+func (uc *UserController) GetVendor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		respondWithJSON(w, http.StatusMethodNotAllowed, "Method not allowed", nil)
 		return
 	}
 
+	tenantID, ok := tenantFromToken(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
@@ -144,55 +150,17 @@ func (uc *UserController) ApproveVendor(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var req ApprovalRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithJSON(w, http.StatusBadRequest, "Invalid request format", nil)
-		return
-	}
-
-	// Verify user exists in auth-service
-	user, err := uc.authService.GetUserByID(uint(userID))
+	// Fetch vendor from auth-service
+	vendor, err := uc.authService.GetVendor(uint(userID), tenantID)
 	if err != nil {
-		respondWithJSON(w, 404, "User not found", nil)
-		return
-	}
-
-	if user.Role != "vendor" {
-		respondWithJSON(w, http.StatusBadRequest, "User is not a vendor", nil)
-		return
-	}
-
-	// Get current admin user ID from context (from JWT middleware)
-	// adminUserID := middleware.GetUserIDFromContext(r) // Implement this
-
-	// Create or update approval record
-	var approval models.VendorApproval
-	result := database.DB.Where("user_id = ?", userID).First(&approval)
-
-	now := time.Now()
-	if result.Error != nil {
-		// Create new approval record
-		approval = models.VendorApproval{
-			UserID:     uint(userID),
-			IsApproved: req.IsApproved,
-			// ApprovedBy: &adminUserID,
-			ApprovedAt: &now,
-			Notes:      req.Notes,
+		if errors.Is(err, services.ErrVendorNotFound) {
+			respondWithJSON(w, 404, "Vendor not found", nil)
+			return
 		}
-		database.DB.Create(&approval)
-	} else {
-		// Update existing approval record
-		approval.IsApproved = req.IsApproved
-		// approval.ApprovedBy = &adminUserID
-		approval.ApprovedAt = &now
-		approval.Notes = req.Notes
-		database.DB.Save(&approval)
-	}
-
-	action := "disapproved"
-	if req.IsApproved {
-		action = "approved"
+		log.Printf("Failed to fetch vendor %d: %v", userID, err)
+		respondWithJSON(w, http.StatusBadGateway, "Failed to fetch vendor", nil)
+		return
 	}
 
-	respondWithJSON(w, 200, fmt.Sprintf("Vendor %s successfully", action), approval)
+	respondWithJSON(w, 200, "Vendor retrieved successfully", vendor)
 }
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/karan-bishtt/user-service/internal/database"
+	"github.com/karan-bishtt/user-service/internal/models"
+)
+
+// permissionCacheTTL bounds how long a resolved permission set is trusted.
+// Changes made through auth-service bump the user's permissions_version, which is
+// compared on every lookup. The TTL only covers changes made directly in the database.
+const permissionCacheTTL = time.Minute
+
+type permissionCacheEntry struct {
+	user      *models.User
+	expiresAt time.Time
+}
+
+// permissionCache keeps each user's UserPermission rows in memory so
+// RequirePermission does not query the database on every request
+type permissionCache struct {
+	mu      sync.RWMutex
+	entries map[uint]permissionCacheEntry
+}
+
+var userPermissions = &permissionCache{
+	entries: make(map[uint]permissionCacheEntry),
+}
+
+// get returns the user with UserPermissions.Permission preloaded. The cached copy
+// is only used while the user's permissions_version is unchanged.
+func (c *permissionCache) get(userID uint) (*models.User, error) {
+	var current models.User
+	if err := database.DB.Select("permissions_version").First(&current, userID).Error; err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[userID]
+	c.mu.RUnlock()
+
+	if ok && entry.user.PermissionsVersion == current.PermissionsVersion && time.Now().Before(entry.expiresAt) {
+		return entry.user, nil
+	}
+
+	var user models.User
+	if err := database.DB.Select("id", "is_active", "permissions_version").
+		Preload("UserPermissions.Permission").
+		First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[userID] = permissionCacheEntry{user: &user, expiresAt: time.Now().Add(permissionCacheTTL)}
+	c.mu.Unlock()
+
+	return &user, nil
+}
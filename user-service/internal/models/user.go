@@ -2,43 +2,55 @@ package models
 
 import "time"
 
-// DTOs for receiving data from auth-service
+// DTOs for receiving data from auth-service. Auth-service owns vendors and their
+// approval, this service only presents them.
 type UserResponse struct {
-	ID            uint                   `json:"id"`
-	FirstName     string                 `json:"first_name"`
-	LastName      string                 `json:"last_name"`
-	Email         string                 `json:"email"`
-	Role          string                 `json:"role"`
-	IsActive      bool                   `json:"is_active"`
-	CreatedAt     time.Time              `json:"created_at"`
-	UpdatedAt     time.Time              `json:"updated_at"`
-	VendorDetails *VendorDetailsResponse `json:"vendor_details,omitempty"`
+	ID              uint                   `json:"id"`
+	FirstName       string                 `json:"first_name"`
+	LastName        string                 `json:"last_name"`
+	Email           string                 `json:"email"`
+	Role            string                 `json:"role"`
+	IsActive        bool                   `json:"is_active"`
+	EmailVerifiedAt *time.Time             `json:"email_verified_at"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+	VendorDetails   *VendorDetailsResponse `json:"vendor_details,omitempty"`
 }
 
 type VendorDetailsResponse struct {
-	ID           uint    `json:"id"`
-	UserID       uint    `json:"user_id"`
-	Revenue      float64 `json:"revenue"`
-	NoOfEmployee int     `json:"no_of_employee"`
-	GSTNo        string  `json:"gst_no"`
-	PANNo        string  `json:"pan_no"`
-	PhoneNo      string  `json:"phone_no"`
-	CategoryID   *uint   `json:"category_id"`
-	IsApproved   bool    `json:"is_approved"` // This could be managed in user-service
+	ID            uint                         `json:"id"`
+	UserID        uint                         `json:"user_id"`
+	Revenue       float64                      `json:"revenue"`
+	NoOfEmployee  int                          `json:"no_of_employee"`
+	GSTNo         string                       `json:"gst_no"`
+	PANNo         string                       `json:"pan_no"`
+	PhoneNo       string                       `json:"phone_no"`
+	Categories    []VendorCategoryResponse     `json:"categories"`
+	Organizations []VendorOrganizationResponse `json:"organizations"`
 }
 
-// Local model for vendor approval status
-type VendorApproval struct {
-	ID         uint       `json:"id" gorm:"primaryKey"`
-	UserID     uint       `json:"user_id" gorm:"uniqueIndex;not null"`
-	IsApproved bool       `json:"is_approved" gorm:"default:false"`
-	ApprovedBy *uint      `json:"approved_by"`
-	ApprovedAt *time.Time `json:"approved_at"`
-	Notes      string     `json:"notes" gorm:"type:text"`
-	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at"`
+type VendorCategoryResponse struct {
+	CategoryID uint              `json:"category_id"`
+	Status     string            `json:"status"`
+	Category   *CategoryResponse `json:"category,omitempty"`
 }
 
-func (VendorApproval) TableName() string {
-	return "vendor_approvals"
+type CategoryResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// VendorOrganizationResponse is the approval status of a vendor in one buyer organization
+type VendorOrganizationResponse struct {
+	OrganizationID uint       `json:"organization_id"`
+	Status         string     `json:"status"`
+	ApprovedAt     *time.Time `json:"approved_at"`
+}
+
+// Pagination mirrors the pagination of auth-service list responses
+type Pagination struct {
+	CurrentPage int   `json:"current_page"`
+	PerPage     int   `json:"per_page"`
+	Total       int64 `json:"total"`
+	TotalPages  int   `json:"total_pages"`
 }
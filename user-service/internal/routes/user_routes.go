@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/gorilla/mux"
 	"github.com/karan-bishtt/user-service/internal/controllers"
+	"github.com/karan-bishtt/user-service/internal/middleware"
 )
 
 func SetupUsersRoutes() *mux.Router {
@@ -13,10 +14,17 @@ func SetupUsersRoutes() *mux.Router {
 	// API routes
 	api := router.PathPrefix("/api/v1").Subrouter()
 
-	// Notification routes
-	api.HandleFunc("/vendors", userController.GetVendors).Methods("GET")
-	api.HandleFunc("/vendors/{id}", userController.GetVendor).Methods("GET")
-	api.HandleFunc("/vendors/{id}/approve", userController.ApproveVendor).Methods("POST")
+	// Apply auth middleware to all routes
+	api.Use(middleware.AuthMiddleware)
+
+	// Vendor directory (Admin only), vendors are approved through auth-service
+	vendorRoutes := api.PathPrefix("/vendors").Subrouter()
+	vendorRoutes.Use(middleware.RequireRole("admin"))
+	vendorRoutes.Use(middleware.RequirePermission("user", "manage"))
+
+	vendorRoutes.HandleFunc("", userController.GetVendors).Methods("GET")
+	vendorRoutes.HandleFunc("/search", userController.SearchVendors).Methods("GET")
+	vendorRoutes.HandleFunc("/{id:[0-9]+}", userController.GetVendor).Methods("GET")
 
 	return router
 }
@@ -2,22 +2,45 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/karan-bishtt/user-service/config"
 	"github.com/karan-bishtt/user-service/internal/models"
 )
 
+// ErrVendorNotFound is returned when the vendor does not exist or did not join the organization
+var ErrVendorNotFound = errors.New("vendor not found")
+
+// SearchError is a vendor search refused by auth-service, e.g. for an unknown sort or filter
+type SearchError struct {
+	Message string
+}
+
+func (e *SearchError) Error() string {
+	return e.Message
+}
+
 type AuthService struct {
 	baseURL string
 	client  *http.Client
 }
 
 type AuthServiceResponse struct {
-	Status  int                   `json:"status"`
-	Message string                `json:"message"`
-	Data    []models.UserResponse `json:"data"`
+	Status     int                   `json:"status"`
+	Message    string                `json:"message"`
+	Data       []models.UserResponse `json:"data"`
+	Pagination models.Pagination     `json:"pagination"`
+}
+
+// VendorFilter selects a page of the vendors of an organization
+type VendorFilter struct {
+	Status string
+	Page   int
+	Limit  int
 }
 
 func NewAuthService() *AuthService {
@@ -28,40 +51,51 @@ func NewAuthService() *AuthService {
 	}
 }
 
-// GetVendors fetches all vendor users from auth-service
-func (as *AuthService) GetVendors() ([]models.UserResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/users?role=vendor", as.baseURL)
+// GetVendors fetches a page of the vendors of the organization tenantID from
+// auth-service, each with their approval status there
+func (as *AuthService) GetVendors(tenantID uint, filter VendorFilter) ([]models.UserResponse, models.Pagination, error) {
+	query := url.Values{"tenant_id": {strconv.FormatUint(uint64(tenantID), 10)}}
+	if filter.Status != "" {
+		query.Set("status", filter.Status)
+	}
+	if filter.Page > 0 {
+		query.Set("page", strconv.Itoa(filter.Page))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
 
-	resp, err := as.client.Get(url)
+	resp, err := as.get(fmt.Sprintf("%s/api/v1/internal/vendors?%s", as.baseURL, query.Encode()))
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch vendors: %v", err)
+		return nil, models.Pagination{}, fmt.Errorf("failed to fetch vendors: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("auth service returned status: %d", resp.StatusCode)
+		return nil, models.Pagination{}, fmt.Errorf("auth service returned status: %d", resp.StatusCode)
 	}
 
 	var response AuthServiceResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+		return nil, models.Pagination{}, fmt.Errorf("failed to decode response: %v", err)
 	}
 
-	return response.Data, nil
+	return response.Data, response.Pagination, nil
 }
 
-// GetUserByID fetches a specific user from auth-service
-func (as *AuthService) GetUserByID(userID uint) (*models.UserResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/users/%d", as.baseURL, userID)
-
-	resp, err := as.client.Get(url)
+// GetVendor fetches a vendor of the organization tenantID from auth-service
+func (as *AuthService) GetVendor(userID, tenantID uint) (*models.UserResponse, error) {
+	resp, err := as.get(fmt.Sprintf("%s/api/v1/internal/users/%d?tenant_id=%d", as.baseURL, userID, tenantID))
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch user: %v", err)
+		return nil, fmt.Errorf("failed to fetch vendor: %v", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrVendorNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("user not found or auth service error")
+		return nil, fmt.Errorf("auth service returned status: %d", resp.StatusCode)
 	}
 
 	var response struct {
@@ -76,3 +110,53 @@ func (as *AuthService) GetUserByID(userID uint) (*models.UserResponse, error) {
 
 	return &response.Data, nil
 }
+
+// SearchVendors searches the vendors of the organization tenantID in auth-service.
+// The search and filters are passed through as they are, see auth-service
+// SearchVendors, and the result is returned undecoded.
+func (as *AuthService) SearchVendors(tenantID uint, params url.Values) (json.RawMessage, error) {
+	query := url.Values{}
+	for name, values := range params {
+		query[name] = values
+	}
+	query.Set("tenant_id", strconv.FormatUint(uint64(tenantID), 10))
+
+	resp, err := as.get(fmt.Sprintf("%s/api/v1/internal/vendors/search?%s", as.baseURL, query.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search vendors: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Status  int             `json:"status"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if resp.StatusCode == http.StatusBadRequest {
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %v", err)
+		}
+		return nil, &SearchError{Message: response.Message}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth service returned status: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	return response.Data, nil
+}
+
+// get calls an internal endpoint of auth-service with the service token
+func (as *AuthService) get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := serviceTokens.authorize(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate with auth-service: %w", err)
+	}
+	return as.client.Do(req)
+}
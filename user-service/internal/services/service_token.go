@@ -0,0 +1,85 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karan-bishtt/user-service/config"
+)
+
+// serviceTokenRenewBefore renews the cached token this long before it expires
+const serviceTokenRenewBefore = time.Minute
+
+// serviceTokenSource gets client credentials tokens from auth-service for calls
+// to other services and caches them until shortly before they expire
+type serviceTokenSource struct {
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	client    *http.Client
+}
+
+var serviceTokens = &serviceTokenSource{
+	client: &http.Client{Timeout: 5 * time.Second},
+}
+
+// authorize adds the service token to an outgoing request
+func (s *serviceTokenSource) authorize(req *http.Request) error {
+	token, err := s.get()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (s *serviceTokenSource) get() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Until(s.expiresAt) > serviceTokenRenewBefore {
+		return s.token, nil
+	}
+
+	cfg := config.Load()
+	if cfg.ServiceClientID == "" || cfg.ServiceClientSecret == "" {
+		return "", errors.New("SERVICE_CLIENT_ID and SERVICE_CLIENT_SECRET are not configured")
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest(http.MethodPost, cfg.AuthServiceURL+"/api/v1/auth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(cfg.ServiceClientID, cfg.ServiceClientSecret)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch service token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned status: %d", resp.StatusCode)
+	}
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("failed to decode service token: %w", err)
+	}
+
+	s.token = tokenResponse.AccessToken
+	s.expiresAt = time.Now().Add(time.Duration(tokenResponse.ExpiresIn) * time.Second)
+
+	return s.token, nil
+}
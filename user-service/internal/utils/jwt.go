@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"errors"
+
+	"github.com/karan-bishtt/user-service/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenTypeAccess is the only token type this service accepts as a bearer token
+const TokenTypeAccess = "access"
+
+type Claims struct {
+	UserID    uint   `json:"user_id"`
+	Role      string `json:"role"`
+	TokenType string `json:"token_type"`
+	SessionID string `json:"sid,omitempty"`
+	TenantID  uint   `json:"tenant_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ValidateToken validates a JWT access token and returns claims.
+// Refresh tokens and tokens minted for another issuer or audience are rejected.
+func ValidateToken(tokenString string) (*Claims, error) {
+	cfg := config.Load()
+
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return authServiceKeys.publicKey(kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(cfg.JWTIssuer),
+		jwt.WithAudience(cfg.JWTAudience),
+		jwt.WithExpirationRequired(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.TokenType != TokenTypeAccess || claims.ID == "" {
+		return nil, errors.New("invalid token type")
+	}
+
+	return claims, nil
+}
+
+// ExtractTokenFromHeader extracts token from Authorization header
+func ExtractTokenFromHeader(authHeader string) (string, error) {
+	if authHeader == "" {
+		return "", errors.New("authorization header is required")
+	}
+
+	const bearerPrefix = "Bearer "
+	if len(authHeader) < len(bearerPrefix) || authHeader[:len(bearerPrefix)] != bearerPrefix {
+		return "", errors.New("authorization header must start with 'Bearer '")
+	}
+
+	return authHeader[len(bearerPrefix):], nil
+}
+
+// GetUserFromToken extracts user information from token
+func GetUserFromToken(tokenString string) (userID uint, role string, err error) {
+	claims, err := ValidateToken(tokenString)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return claims.UserID, claims.Role, nil
+}